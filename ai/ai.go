@@ -2,7 +2,9 @@ package ai
 
 import (
 	"context"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/sashabaranov/go-openai"
 )
@@ -10,11 +12,16 @@ import (
 type ChunkHandler func(chunk string) error
 
 type TokenUsage struct {
-	PromptTokens            int
-	CompletionTokens        int
-	TotalTokens             int
-	PromptTokensDetails     *openai.PromptTokensDetails
-	CompletionTokensDetails *openai.CompletionTokensDetails
+	PromptTokens            int                             `json:"prompt_tokens"`
+	CompletionTokens        int                             `json:"completion_tokens"`
+	TotalTokens             int                             `json:"total_tokens"`
+	PromptTokensDetails     *openai.PromptTokensDetails     `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *openai.CompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+	// CacheWriteTokens counts prompt tokens written to a provider-side cache.
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+	// Backend names the provider and model that served the answer when it
+	// did not come from the primary backend alone.
+	Backend string `json:"backend,omitempty"`
 }
 
 func (u TokenUsage) String() string {
@@ -22,20 +29,78 @@ func (u TokenUsage) String() string {
 	if u.PromptTokensDetails != nil && u.PromptTokensDetails.CachedTokens > 0 {
 		s += " (CachedTokens: " + strconv.Itoa(u.PromptTokensDetails.CachedTokens) + ")"
 	}
+	if u.CacheWriteTokens > 0 {
+		s += " (CacheWriteTokens: " + strconv.Itoa(u.CacheWriteTokens) + ")"
+	}
 	s += ", Completion tokens: " + strconv.Itoa(u.CompletionTokens)
 	if u.CompletionTokensDetails != nil && u.CompletionTokensDetails.ReasoningTokens > 0 {
 		s += " (ReasoningTokens: " + strconv.Itoa(u.CompletionTokensDetails.ReasoningTokens) + ")"
 	}
 	s += ", Total tokens: " + strconv.Itoa(u.TotalTokens)
+	if u.Backend != "" {
+		s += ", Backend: " + u.Backend
+	}
 	return s
 }
 
+// Add returns the sum of two usages, merging cache and reasoning details.
+func (u TokenUsage) Add(o TokenUsage) TokenUsage {
+	sum := TokenUsage{
+		PromptTokens:     u.PromptTokens + o.PromptTokens,
+		CompletionTokens: u.CompletionTokens + o.CompletionTokens,
+		TotalTokens:      u.TotalTokens + o.TotalTokens,
+		CacheWriteTokens: u.CacheWriteTokens + o.CacheWriteTokens,
+		Backend:          mergeBackends(u.Backend, o.Backend),
+	}
+	if u.PromptTokensDetails != nil || o.PromptTokensDetails != nil {
+		sum.PromptTokensDetails = &openai.PromptTokensDetails{}
+		for _, d := range []*openai.PromptTokensDetails{u.PromptTokensDetails, o.PromptTokensDetails} {
+			if d != nil {
+				sum.PromptTokensDetails.CachedTokens += d.CachedTokens
+				sum.PromptTokensDetails.AudioTokens += d.AudioTokens
+			}
+		}
+	}
+	if u.CompletionTokensDetails != nil || o.CompletionTokensDetails != nil {
+		sum.CompletionTokensDetails = &openai.CompletionTokensDetails{}
+		for _, d := range []*openai.CompletionTokensDetails{u.CompletionTokensDetails, o.CompletionTokensDetails} {
+			if d != nil {
+				sum.CompletionTokensDetails.ReasoningTokens += d.ReasoningTokens
+				sum.CompletionTokensDetails.AudioTokens += d.AudioTokens
+			}
+		}
+	}
+	return sum
+}
+
+// mergeBackends joins the distinct backend names of two usages.
+func mergeBackends(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	names := strings.Split(a, ", ")
+	for _, name := range strings.Split(b, ", ") {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
 type Response struct {
 	Text       string
 	TokenUsage TokenUsage
 }
 
 type TextGenerator interface {
+	// Chat sends a multi-message request and returns the full response.
+	Chat(ctx context.Context, req *Request) (*Response, error)
+	// StreamChat sends a multi-message request, passes each chunk to handler
+	// and returns the token usage reported once the stream completes.
+	StreamChat(ctx context.Context, req *Request, handler ChunkHandler) (TokenUsage, error)
+
+	// ChatCompletion is Chat with a single user message and the default system prompt.
 	ChatCompletion(ctx context.Context, text string) (*Response, error)
-	StreamChatCompletion(ctx context.Context, text string, handler ChunkHandler) error
+	// StreamChatCompletion is StreamChat with a single user message and the default system prompt.
+	StreamChatCompletion(ctx context.Context, text string, handler ChunkHandler) (TokenUsage, error)
 }
@@ -60,6 +60,20 @@ func TestTokenUsage_String(t *testing.T) {
 				"Total tokens: 225",
 			},
 		},
+		{
+			name: "with cache write tokens",
+			usage: TokenUsage{
+				PromptTokens:     120,
+				CompletionTokens: 30,
+				TotalTokens:      150,
+				CacheWriteTokens: 40,
+			},
+			contains: []string{
+				"Prompt tokens: 120",
+				"CacheWriteTokens: 40",
+				"Total tokens: 150",
+			},
+		},
 		{
 			name: "with both cached and reasoning tokens",
 			usage: TokenUsage{
@@ -129,3 +143,39 @@ func TestChunkHandler(t *testing.T) {
 		assert.Equal(t, chunks, receivedChunks)
 	})
 }
+
+func TestTokenUsage_Add(t *testing.T) {
+	a := TokenUsage{
+		PromptTokens:        10,
+		CompletionTokens:    5,
+		TotalTokens:         15,
+		PromptTokensDetails: &openai.PromptTokensDetails{CachedTokens: 4},
+	}
+	b := TokenUsage{
+		PromptTokens:            20,
+		CompletionTokens:        3,
+		TotalTokens:             23,
+		CompletionTokensDetails: &openai.CompletionTokensDetails{ReasoningTokens: 2},
+		CacheWriteTokens:        1,
+	}
+
+	sum := a.Add(b)
+	assert.Equal(t, 30, sum.PromptTokens)
+	assert.Equal(t, 8, sum.CompletionTokens)
+	assert.Equal(t, 38, sum.TotalTokens)
+	assert.Equal(t, 4, sum.PromptTokensDetails.CachedTokens)
+	assert.Equal(t, 2, sum.CompletionTokensDetails.ReasoningTokens)
+	assert.Equal(t, 1, sum.CacheWriteTokens)
+	assert.Equal(t, 4, a.PromptTokensDetails.CachedTokens, "receiver must not be mutated")
+}
+
+func TestTokenUsage_AddBackends(t *testing.T) {
+	a := TokenUsage{Backend: "openai/gpt-4o"}
+	assert.Equal(t, "openai/gpt-4o", a.Add(TokenUsage{}).Backend)
+	assert.Equal(t, "openai/gpt-4o", TokenUsage{}.Add(a).Backend)
+	assert.Equal(t, "openai/gpt-4o", a.Add(a).Backend)
+
+	sum := a.Add(TokenUsage{Backend: "ollama/llama3"}).Add(TokenUsage{Backend: "openai/gpt-4o, ollama/llama3"})
+	assert.Equal(t, "openai/gpt-4o, ollama/llama3", sum.Backend)
+	assert.Contains(t, sum.String(), "Backend: openai/gpt-4o, ollama/llama3")
+}
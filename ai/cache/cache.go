@@ -0,0 +1,291 @@
+// Package cache stores model responses on disk so that repeating a request
+// with the same prompt, model and sampling settings costs no tokens.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+)
+
+// keyVersion is mixed into every key; bump it when the entry format or
+// the key derivation changes.
+const keyVersion = "reviewbot-cache-v2"
+
+const entryExt = ".json"
+
+// Scope identifies the backend and the sampling settings a response
+// depends on besides the prompt.
+type Scope struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// BaseURL and Deployment tell apart servers and Azure deployments that
+	// answer under the same model name.
+	BaseURL          string  `json:"base_url,omitempty"`
+	Deployment       string  `json:"deployment,omitempty"`
+	MaxTokens        int     `json:"max_tokens"`
+	Temperature      float32 `json:"temperature"`
+	TopP             float32 `json:"top_p"`
+	PresencePenalty  float32 `json:"presence_penalty"`
+	FrequencyPenalty float32 `json:"frequency_penalty"`
+}
+
+// ScopeFromConfig returns the scope of a client built from cfg.
+func ScopeFromConfig(cfg config.AIConfig) Scope {
+	provider := strings.ToLower(strings.TrimSpace(cfg.Provider))
+	deployment := ""
+	if ai.Provider(provider) == ai.Azure {
+		// resolved as the Azure client does; a deployment named after the
+		// model is already covered by the model
+		deployment = cfg.Azure.Deployments[cfg.Model]
+		if deployment == "" {
+			deployment = cfg.Azure.Deployment
+		}
+	}
+	return Scope{
+		Provider:         provider,
+		Model:            cfg.Model,
+		BaseURL:          strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/"),
+		Deployment:       deployment,
+		MaxTokens:        cfg.MaxTokens,
+		Temperature:      cfg.Temperature,
+		TopP:             cfg.TopP,
+		PresencePenalty:  cfg.PresencePenalty,
+		FrequencyPenalty: cfg.FrequencyPenalty,
+	}
+}
+
+// Backend names the backend of the scope the way fallback chains report it
+// in the token usage, such as "openai/gpt-4o".
+func (s Scope) Backend() string {
+	return s.Provider + "/" + s.Model
+}
+
+// Key returns the SHA-256 of the scope and the rendered request. Request
+// options override the scope defaults the same way the clients apply them.
+func Key(scope Scope, req *ai.Request) string {
+	type message struct {
+		Role    ai.Role `json:"role"`
+		Content string  `json:"content"`
+	}
+	msgs := make([]message, 0, len(req.Messages))
+	for _, m := range req.Conversation() {
+		msgs = append(msgs, message{Role: m.Role, Content: m.Content})
+	}
+
+	scope.MaxTokens = req.MaxTokensOr(scope.MaxTokens)
+	scope.Temperature = req.TemperatureOr(scope.Temperature)
+	scope.TopP = req.TopPOr(scope.TopP)
+	payload, _ := json.Marshal(struct {
+		Version  string    `json:"version"`
+		Scope    Scope     `json:"scope"`
+		System   string    `json:"system"`
+		Messages []message `json:"messages"`
+		Stop     []string  `json:"stop,omitempty"`
+	}{keyVersion, scope, req.SystemPrompt(), msgs, req.Stop})
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// Entry is a cached response.
+type Entry struct {
+	Text    string        `json:"text"`
+	Usage   ai.TokenUsage `json:"usage"`
+	Created time.Time     `json:"created"`
+}
+
+// Store keeps entries as one JSON file per key in a directory.
+type Store struct {
+	dir     string
+	ttl     time.Duration
+	maxSize int64
+	now     func() time.Time
+}
+
+// Open returns a store in dir. Entries older than ttl are dropped and the
+// oldest entries are evicted once the directory grows beyond maxSize
+// bytes; zero disables either limit. The directory is created on the
+// first write.
+func Open(dir string, ttl time.Duration, maxSize int64) *Store {
+	return &Store{dir: dir, ttl: ttl, maxSize: maxSize, now: time.Now}
+}
+
+// Dir returns the directory of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Get returns the entry stored under key, if it exists and has not expired.
+func (s *Store) Get(key string) (*Entry, bool) {
+	raw, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var e Entry
+	if err := json.Unmarshal(raw, &e); err != nil || s.expired(e.Created) {
+		_ = os.Remove(s.path(key))
+		return nil, false
+	}
+	return &e, true
+}
+
+// Put stores an entry under key and evicts entries beyond the limits.
+func (s *Store) Put(key string, e *Entry) error {
+	if e.Created.IsZero() {
+		e.Created = s.now()
+	}
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+
+	// write to a temp file first so readers never see a partial entry
+	tmp, err := os.CreateTemp(s.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path(key)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return s.prune()
+}
+
+// Clear removes every entry and returns how many were removed.
+func (s *Store) Clear() (int, error) {
+	files, err := s.files()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, f := range files {
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Stats describes the content of a store.
+type Stats struct {
+	Entries int
+	Expired int
+	Size    int64
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+// Stats reports the number and size of the stored entries.
+func (s *Store) Stats() (Stats, error) {
+	files, err := s.files()
+	if err != nil {
+		return Stats{}, err
+	}
+	var st Stats
+	for _, f := range files {
+		st.Entries++
+		st.Size += f.size
+		if s.expired(f.modTime) {
+			st.Expired++
+		}
+		if st.Oldest.IsZero() || f.modTime.Before(st.Oldest) {
+			st.Oldest = f.modTime
+		}
+		if f.modTime.After(st.Newest) {
+			st.Newest = f.modTime
+		}
+	}
+	return st, nil
+}
+
+// prune drops expired entries, then the oldest ones until the store fits
+// in maxSize.
+func (s *Store) prune() error {
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	for _, f := range files {
+		if !s.expired(f.modTime) && (s.maxSize <= 0 || total <= s.maxSize) {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		total -= f.size
+	}
+	return nil
+}
+
+type entryFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// files lists the entries of the store; a missing directory is empty.
+func (s *Store) files() ([]entryFile, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []entryFile
+	for _, de := range dirEntries {
+		if de.IsDir() || filepath.Ext(de.Name()) != entryExt {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, entryFile{
+			path:    filepath.Join(s.dir, de.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	return files, nil
+}
+
+func (s *Store) expired(created time.Time) bool {
+	return s.ttl > 0 && s.now().Sub(created) > s.ttl
+}
+
+func (s *Store) path(key string) string {
+	return filepath.Join(s.dir, key+entryExt)
+}
@@ -0,0 +1,258 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingGenerator answers with a fixed text and counts the calls that
+// reached it.
+type countingGenerator struct {
+	text    string
+	err     error
+	backend string
+	calls   int
+}
+
+func (g *countingGenerator) Chat(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+	g.calls++
+	if g.err != nil {
+		return nil, g.err
+	}
+	return &ai.Response{Text: g.text, TokenUsage: ai.TokenUsage{TotalTokens: 10, Backend: g.backend}}, nil
+}
+
+func (g *countingGenerator) StreamChat(ctx context.Context, req *ai.Request, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	g.calls++
+	for _, chunk := range strings.SplitAfter(g.text, " ") {
+		if err := handler(chunk); err != nil {
+			return ai.TokenUsage{}, err
+		}
+	}
+	return ai.TokenUsage{TotalTokens: 10, Backend: g.backend}, g.err
+}
+
+func (g *countingGenerator) ChatCompletion(ctx context.Context, text string) (*ai.Response, error) {
+	return g.Chat(ctx, ai.NewRequest(text))
+}
+
+func (g *countingGenerator) StreamChatCompletion(ctx context.Context, text string, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	return g.StreamChat(ctx, ai.NewRequest(text), handler)
+}
+
+func TestKey(t *testing.T) {
+	scope := ScopeFromConfig(config.NewDefault().AI)
+	key := Key(scope, ai.NewRequest("review this"))
+	assert.Len(t, key, 64)
+	assert.Equal(t, key, Key(scope, ai.NewRequest("review this")))
+
+	// the request overrides match the scope defaults
+	temp := scope.Temperature
+	withTemp := ai.NewRequest("review this")
+	withTemp.Temperature = &temp
+	assert.Equal(t, key, Key(scope, withTemp))
+
+	other := scope
+	other.Model = "gpt-4o"
+	hotter := scope
+	hotter.Temperature = 1
+	system := ai.NewRequest("review this")
+	system.System = "You are a reviewer."
+	for name, k := range map[string]string{
+		"prompt":      Key(scope, ai.NewRequest("review that")),
+		"model":       Key(other, ai.NewRequest("review this")),
+		"temperature": Key(hotter, ai.NewRequest("review this")),
+		"system":      Key(scope, system),
+	} {
+		assert.NotEqual(t, key, k, name)
+	}
+}
+
+func TestScopeFromConfig(t *testing.T) {
+	cfg := config.NewDefault().AI
+	cfg.Provider, cfg.Model = "OpenAI", "gpt-4o"
+	scope := ScopeFromConfig(cfg)
+	assert.Equal(t, "openai/gpt-4o", scope.Backend())
+	key := Key(scope, ai.NewRequest("review this"))
+
+	local := cfg
+	local.BaseURL = "http://localhost:8080/v1"
+	assert.NotEqual(t, key, Key(ScopeFromConfig(local), ai.NewRequest("review this")), "base_url")
+
+	// Azure settings only matter to the Azure provider
+	cfg.Azure.Deployment = "prod"
+	assert.Equal(t, key, Key(ScopeFromConfig(cfg), ai.NewRequest("review this")))
+
+	azure := cfg
+	azure.Provider = "azure"
+	prod := ScopeFromConfig(azure)
+	assert.Equal(t, "prod", prod.Deployment)
+	azure.Azure.Deployments = map[string]string{"gpt-4o": "gpt4o-eu"}
+	eu := ScopeFromConfig(azure)
+	assert.Equal(t, "gpt4o-eu", eu.Deployment)
+	assert.NotEqual(t, Key(prod, ai.NewRequest("review this")), Key(eu, ai.NewRequest("review this")))
+}
+
+func TestStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	store := Open(dir, time.Hour, 0)
+
+	_, ok := store.Get("k1")
+	assert.False(t, ok)
+	stats, err := store.Stats()
+	require.NoError(t, err)
+	assert.Zero(t, stats.Entries)
+
+	require.NoError(t, store.Put("k1", &Entry{Text: "hello", Usage: ai.TokenUsage{TotalTokens: 3}}))
+	e, ok := store.Get("k1")
+	require.True(t, ok)
+	assert.Equal(t, "hello", e.Text)
+	assert.Equal(t, 3, e.Usage.TotalTokens)
+
+	// expired entries are dropped on read
+	store.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	stats, err = store.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Expired)
+	_, ok = store.Get("k1")
+	assert.False(t, ok)
+	assert.NoFileExists(t, filepath.Join(dir, "k1.json"))
+}
+
+func TestStore_MaxSize(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, Open(dir, 0, 0).Put("probe", &Entry{Text: strings.Repeat("x", 50)}))
+	info, err := os.Stat(filepath.Join(dir, "probe.json"))
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(dir, "probe.json")))
+	// room for three entries
+	limit := 3*info.Size() + info.Size()/2
+	store := Open(dir, 0, limit)
+
+	old := time.Now().Add(-time.Minute)
+	for i, key := range []string{"a", "b", "c"} {
+		require.NoError(t, store.Put(key, &Entry{Text: strings.Repeat("x", 50)}))
+		// give the entries distinct ages, oldest first
+		at := old.Add(time.Duration(i) * time.Second)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, key+".json"), at, at))
+	}
+	require.NoError(t, store.Put("d", &Entry{Text: strings.Repeat("x", 50)}))
+
+	_, ok := store.Get("a")
+	assert.False(t, ok, "the oldest entry is evicted")
+	for _, key := range []string{"b", "c", "d"} {
+		_, ok := store.Get(key)
+		assert.True(t, ok, key)
+	}
+
+	stats, err := store.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Entries)
+	assert.LessOrEqual(t, stats.Size, limit)
+
+	removed, err := store.Clear()
+	require.NoError(t, err)
+	assert.Equal(t, 3, removed)
+	stats, err = store.Stats()
+	require.NoError(t, err)
+	assert.Zero(t, stats.Entries)
+}
+
+func TestGenerator_Chat(t *testing.T) {
+	next := &countingGenerator{text: "looks good"}
+	gen := Wrap(next, Open(t.TempDir(), time.Hour, 0), Scope{Provider: "openai", Model: "gpt-4o"})
+	ctx := context.Background()
+
+	resp, err := gen.ChatCompletion(ctx, "review")
+	require.NoError(t, err)
+	assert.Equal(t, 10, resp.TokenUsage.TotalTokens)
+
+	resp, err = gen.Chat(ctx, ai.NewRequest("review"))
+	require.NoError(t, err)
+	assert.Equal(t, "looks good", resp.Text)
+	assert.Zero(t, resp.TokenUsage.TotalTokens, "cached answers cost nothing")
+	assert.Equal(t, 1, next.calls)
+
+	_, err = gen.ChatCompletion(ctx, "another review")
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.calls)
+}
+
+func TestGenerator_StreamChat(t *testing.T) {
+	next := &countingGenerator{text: "looks good to me"}
+	gen := Wrap(next, Open(t.TempDir(), time.Hour, 0), Scope{Provider: "openai", Model: "gpt-4o"})
+	ctx := context.Background()
+
+	var first strings.Builder
+	usage, err := gen.StreamChatCompletion(ctx, "review", func(chunk string) error {
+		first.WriteString(chunk)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 10, usage.TotalTokens)
+
+	// the streamed text is replayed, and shared with non-streaming calls
+	var replay []string
+	usage, err = gen.StreamChatCompletion(ctx, "review", func(chunk string) error {
+		replay = append(replay, chunk)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Zero(t, usage.TotalTokens)
+	assert.Equal(t, first.String(), strings.Join(replay, ""))
+
+	resp, err := gen.ChatCompletion(ctx, "review")
+	require.NoError(t, err)
+	assert.Equal(t, "looks good to me", resp.Text)
+	assert.Equal(t, 1, next.calls)
+}
+
+func TestGenerator_FallbackAnswersAreNotCached(t *testing.T) {
+	next := &countingGenerator{text: "looks good", backend: "ollama/llama3"}
+	gen := Wrap(next, Open(t.TempDir(), time.Hour, 0), Scope{Provider: "openai", Model: "gpt-4o"})
+	ctx := context.Background()
+
+	for range 2 {
+		_, err := gen.ChatCompletion(ctx, "review")
+		require.NoError(t, err)
+		_, err = gen.StreamChatCompletion(ctx, "review", func(string) error { return nil })
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 4, next.calls)
+
+	next.backend = "openai/gpt-4o"
+	for range 2 {
+		_, err := gen.ChatCompletion(ctx, "review")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 5, next.calls, "answers of the primary backend are cached")
+}
+
+func TestGenerator_FailuresAreNotCached(t *testing.T) {
+	next := &countingGenerator{text: "partial answer", err: errors.New("connection reset")}
+	gen := Wrap(next, Open(t.TempDir(), time.Hour, 0), Scope{})
+	ctx := context.Background()
+
+	_, err := gen.ChatCompletion(ctx, "review")
+	require.Error(t, err)
+	_, err = gen.StreamChatCompletion(ctx, "review", func(string) error { return nil })
+	require.Error(t, err)
+
+	next.err = nil
+	next.text = "  "
+	_, err = gen.ChatCompletion(ctx, "review")
+	require.NoError(t, err)
+	_, err = gen.ChatCompletion(ctx, "review")
+	require.NoError(t, err)
+	assert.Equal(t, 4, next.calls, "errors and empty answers always reach the model")
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"strings"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+)
+
+// Generator is an ai.TextGenerator that answers repeated requests from a
+// store and forwards the others to the wrapped generator. Cached answers
+// report zero token usage since nothing was spent on them. Answers served
+// by a fallback backend are not cached, since they do not match the scope.
+type Generator struct {
+	next  ai.TextGenerator
+	store *Store
+	scope Scope
+}
+
+// Wrap returns next with responses cached in store under scope.
+func Wrap(next ai.TextGenerator, store *Store, scope Scope) *Generator {
+	return &Generator{next: next, store: store, scope: scope}
+}
+
+// Chat returns the cached response of req or asks the wrapped generator.
+func (g *Generator) Chat(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+	key := Key(g.scope, req)
+	if e, ok := g.store.Get(key); ok {
+		return &ai.Response{Text: e.Text}, nil
+	}
+
+	resp, err := g.next.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	g.put(key, resp.Text, resp.TokenUsage)
+	return resp, nil
+}
+
+// StreamChat replays a cached response through handler, or streams from
+// the wrapped generator and caches the complete text.
+func (g *Generator) StreamChat(ctx context.Context, req *ai.Request, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	key := Key(g.scope, req)
+	if e, ok := g.store.Get(key); ok {
+		return ai.TokenUsage{}, handler(e.Text)
+	}
+
+	var text strings.Builder
+	usage, err := g.next.StreamChat(ctx, req, func(chunk string) error {
+		text.WriteString(chunk)
+		return handler(chunk)
+	})
+	if err != nil {
+		return usage, err
+	}
+	g.put(key, text.String(), usage)
+	return usage, nil
+}
+
+// ChatCompletion is Chat with a single user message and the default system prompt.
+func (g *Generator) ChatCompletion(ctx context.Context, text string) (*ai.Response, error) {
+	return g.Chat(ctx, ai.NewRequest(text))
+}
+
+// StreamChatCompletion is StreamChat with a single user message and the default system prompt.
+func (g *Generator) StreamChatCompletion(ctx context.Context, text string, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	return g.StreamChat(ctx, ai.NewRequest(text), handler)
+}
+
+// put stores a response. Empty answers are not worth keeping, answers of
+// another backend would be served as the scope's own, and a cache that
+// cannot be written must not fail the request.
+func (g *Generator) put(key, text string, usage ai.TokenUsage) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	if usage.Backend != "" && usage.Backend != g.scope.Backend() {
+		return
+	}
+	_ = g.store.Put(key, &Entry{Text: text, Usage: usage})
+}
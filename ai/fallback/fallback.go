@@ -0,0 +1,113 @@
+// Package fallback sends model requests to alternative backends when the
+// primary one keeps failing for transient reasons.
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/ai/retry"
+)
+
+// Backend is one generator of the chain and the name reported in the token
+// usage of the answers it serves, such as "openai/gpt-4o".
+type Backend struct {
+	Name      string
+	Generator ai.TextGenerator
+}
+
+// Notify is called before a request moves on to the next backend, with the
+// backend that failed and its error.
+type Notify func(failed, next string, err error)
+
+// Generator is an ai.TextGenerator that tries its backends in order. It
+// moves on only when a backend failed with a retryable error, since an
+// invalid prompt or rejected credentials are not fixed by switching, and
+// never once a stream has passed a chunk to the handler.
+type Generator struct {
+	backends []Backend
+	notify   Notify
+}
+
+// New returns a generator trying backends in order. notify may be nil.
+func New(backends []Backend, notify Notify) *Generator {
+	return &Generator{backends: backends, notify: notify}
+}
+
+// Chat sends req to the first backend able to answer it.
+func (g *Generator) Chat(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+	var resp *ai.Response
+	err := g.do(ctx, func(b Backend) (bool, error) {
+		var err error
+		resp, err = b.Generator.Chat(ctx, req)
+		if err == nil {
+			resp.TokenUsage.Backend = b.Name
+		}
+		return true, err
+	})
+	return resp, err
+}
+
+// StreamChat streams req from the first backend able to answer it.
+func (g *Generator) StreamChat(ctx context.Context, req *ai.Request, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	var usage ai.TokenUsage
+	err := g.do(ctx, func(b Backend) (bool, error) {
+		emitted := false
+		var err error
+		usage, err = b.Generator.StreamChat(ctx, req, func(chunk string) error {
+			emitted = true
+			return handler(chunk)
+		})
+		if err == nil {
+			usage.Backend = b.Name
+		}
+		return !emitted, err
+	})
+	return usage, err
+}
+
+// ChatCompletion is Chat with a single user message and the default system prompt.
+func (g *Generator) ChatCompletion(ctx context.Context, text string) (*ai.Response, error) {
+	return g.Chat(ctx, ai.NewRequest(text))
+}
+
+// StreamChatCompletion is StreamChat with a single user message and the default system prompt.
+func (g *Generator) StreamChatCompletion(ctx context.Context, text string, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	return g.StreamChat(ctx, ai.NewRequest(text), handler)
+}
+
+// do runs attempt against each backend until one succeeds or fails in a
+// way another backend cannot help with. attempt reports whether the
+// request may still be sent elsewhere.
+func (g *Generator) do(ctx context.Context, attempt func(b Backend) (bool, error)) error {
+	var errs []error
+	for i, b := range g.backends {
+		switchable, err := attempt(b)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", b.Name, err))
+		if !switchable || ctx.Err() != nil || !retryable(err) || i == len(g.backends)-1 {
+			break
+		}
+		if g.notify != nil {
+			g.notify(b.Name, g.backends[i+1].Name, err)
+		}
+	}
+	if len(errs) == 1 {
+		return errors.Unwrap(errs[0])
+	}
+	return errors.Join(errs...)
+}
+
+// retryable reports whether err is a transient failure, using the class a
+// retrying backend already determined when there is one.
+func retryable(err error) bool {
+	var rerr *retry.Error
+	if errors.As(err, &rerr) {
+		return rerr.Class.Retryable()
+	}
+	return retry.Classify(err, 0).Retryable()
+}
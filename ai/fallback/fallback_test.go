@@ -0,0 +1,140 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/ai/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stub answers with text or fails with err, streaming the text in chunks
+// before failing when partial is set.
+type stub struct {
+	text    string
+	err     error
+	partial bool
+	calls   int
+}
+
+func (s *stub) Chat(_ context.Context, _ *ai.Request) (*ai.Response, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &ai.Response{Text: s.text, TokenUsage: ai.TokenUsage{TotalTokens: 3}}, nil
+}
+
+func (s *stub) StreamChat(_ context.Context, _ *ai.Request, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	s.calls++
+	if s.partial {
+		if err := handler(s.text); err != nil {
+			return ai.TokenUsage{}, err
+		}
+	}
+	if s.err != nil {
+		return ai.TokenUsage{}, s.err
+	}
+	if err := handler(s.text); err != nil {
+		return ai.TokenUsage{}, err
+	}
+	return ai.TokenUsage{TotalTokens: 3}, nil
+}
+
+func (s *stub) ChatCompletion(ctx context.Context, text string) (*ai.Response, error) {
+	return s.Chat(ctx, ai.NewRequest(text))
+}
+
+func (s *stub) StreamChatCompletion(ctx context.Context, text string, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	return s.StreamChat(ctx, ai.NewRequest(text), handler)
+}
+
+func rateLimited() error {
+	return &retry.Error{Class: retry.ClassRateLimit, Attempts: 3, Err: errors.New("429 too many requests")}
+}
+
+func TestGenerator_ChatFallsBack(t *testing.T) {
+	primary := &stub{err: rateLimited()}
+	secondary := &stub{text: "from secondary"}
+
+	var notified []string
+	g := New([]Backend{{"openai/gpt-4o", primary}, {"ollama/llama3", secondary}}, func(failed, next string, err error) {
+		notified = append(notified, failed+" -> "+next)
+	})
+
+	resp, err := g.ChatCompletion(context.Background(), "hi")
+	require.NoError(t, err)
+	assert.Equal(t, "from secondary", resp.Text)
+	assert.Equal(t, "ollama/llama3", resp.TokenUsage.Backend)
+	assert.Equal(t, 3, resp.TokenUsage.TotalTokens)
+	assert.Equal(t, []string{"openai/gpt-4o -> ollama/llama3"}, notified)
+}
+
+func TestGenerator_PrimaryServes(t *testing.T) {
+	primary := &stub{text: "ok"}
+	secondary := &stub{text: "unused"}
+	g := New([]Backend{{"openai/gpt-4o", primary}, {"ollama/llama3", secondary}}, nil)
+
+	resp, err := g.ChatCompletion(context.Background(), "hi")
+	require.NoError(t, err)
+	assert.Equal(t, "openai/gpt-4o", resp.TokenUsage.Backend)
+	assert.Zero(t, secondary.calls)
+}
+
+func TestGenerator_NoFallbackOnPermanentError(t *testing.T) {
+	authErr := &retry.Error{Class: retry.ClassAuth, Attempts: 1, Err: errors.New("401 unauthorized")}
+	primary := &stub{err: authErr}
+	secondary := &stub{text: "unused"}
+	g := New([]Backend{{"openai/gpt-4o", primary}, {"ollama/llama3", secondary}}, nil)
+
+	_, err := g.ChatCompletion(context.Background(), "hi")
+	assert.ErrorIs(t, err, authErr)
+	assert.Zero(t, secondary.calls)
+}
+
+func TestGenerator_ClassifiesPlainErrors(t *testing.T) {
+	primary := &stub{err: errors.New("model is overloaded")}
+	secondary := &stub{text: "ok"}
+	g := New([]Backend{{"a/x", primary}, {"b/y", secondary}}, nil)
+
+	resp, err := g.ChatCompletion(context.Background(), "hi")
+	require.NoError(t, err)
+	assert.Equal(t, "b/y", resp.TokenUsage.Backend)
+}
+
+func TestGenerator_AllBackendsFail(t *testing.T) {
+	first, second := rateLimited(), rateLimited()
+	g := New([]Backend{{"a/x", &stub{err: first}}, {"b/y", &stub{err: second}}}, nil)
+
+	_, err := g.ChatCompletion(context.Background(), "hi")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, first)
+	assert.ErrorIs(t, err, second)
+	assert.Contains(t, err.Error(), "a/x: ")
+	assert.Contains(t, err.Error(), "b/y: ")
+}
+
+func TestGenerator_StreamFallsBackBeforeFirstChunk(t *testing.T) {
+	g := New([]Backend{{"a/x", &stub{err: rateLimited()}}, {"b/y", &stub{text: "streamed"}}}, nil)
+
+	var got string
+	usage, err := g.StreamChatCompletion(context.Background(), "hi", func(chunk string) error {
+		got += chunk
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "streamed", got)
+	assert.Equal(t, "b/y", usage.Backend)
+}
+
+func TestGenerator_StreamKeepsPartialFailure(t *testing.T) {
+	secondary := &stub{text: "unused"}
+	g := New([]Backend{{"a/x", &stub{text: "part", err: rateLimited(), partial: true}}, {"b/y", secondary}}, nil)
+
+	_, err := g.StreamChatCompletion(context.Background(), "hi", func(string) error { return nil })
+	assert.Error(t, err)
+	assert.Zero(t, secondary.calls)
+}
@@ -4,9 +4,11 @@ type Provider string
 
 const (
 	OpenAI    Provider = "openai"
+	Azure     Provider = "azure"
 	Anthropic Provider = "anthropic"
 	DeepSeek  Provider = "deepseek"
 	Gemini    Provider = "gemini"
+	Ollama    Provider = "ollama"
 )
 
 func (p Provider) String() string {
@@ -17,6 +17,11 @@ func TestProvider_String(t *testing.T) {
 			provider: OpenAI,
 			expected: "openai",
 		},
+		{
+			name:     "Azure provider",
+			provider: Azure,
+			expected: "azure",
+		},
 		{
 			name:     "Anthropic provider",
 			provider: Anthropic,
@@ -32,6 +37,11 @@ func TestProvider_String(t *testing.T) {
 			provider: Gemini,
 			expected: "gemini",
 		},
+		{
+			name:     "Ollama provider",
+			provider: Ollama,
+			expected: "ollama",
+		},
 	}
 
 	for _, tt := range tests {
@@ -44,7 +54,9 @@ func TestProvider_String(t *testing.T) {
 func TestProviderConstants(t *testing.T) {
 	// Verify that provider constants are defined correctly
 	assert.Equal(t, Provider("openai"), OpenAI)
+	assert.Equal(t, Provider("azure"), Azure)
 	assert.Equal(t, Provider("anthropic"), Anthropic)
 	assert.Equal(t, Provider("deepseek"), DeepSeek)
 	assert.Equal(t, Provider("gemini"), Gemini)
+	assert.Equal(t, Provider("ollama"), Ollama)
 }
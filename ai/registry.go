@@ -0,0 +1,70 @@
+package ai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/loveRyujin/ReviewBot/proxy"
+)
+
+// Factory builds a TextGenerator from the AI and proxy settings.
+type Factory func(cfg config.AIConfig, proxyCfg *proxy.Config) (TextGenerator, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a provider factory available under the given name.
+// It is intended to be called from the init function of an llm package
+// and panics if the name is empty, the factory is nil, or the name is
+// already registered.
+func Register(name string, factory Factory) {
+	name = normalizeProviderName(name)
+	if name == "" {
+		panic("ai: Register called with empty provider name")
+	}
+	if factory == nil {
+		panic("ai: Register factory is nil for provider " + name)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, dup := registry[name]; dup {
+		panic("ai: Register called twice for provider " + name)
+	}
+	registry[name] = factory
+}
+
+// Providers returns the sorted names of all registered providers.
+func Providers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New looks up the named provider and builds a TextGenerator with it.
+func New(name string, cfg config.AIConfig, proxyCfg *proxy.Config) (TextGenerator, error) {
+	registryMu.RLock()
+	factory, ok := registry[normalizeProviderName(name)]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unsupported LLM provider %q (registered: %s)", name, strings.Join(Providers(), ", "))
+	}
+	return factory(cfg, proxyCfg)
+}
+
+func normalizeProviderName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
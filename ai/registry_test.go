@@ -0,0 +1,76 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/loveRyujin/ReviewBot/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubGenerator struct {
+	model string
+}
+
+func (s *stubGenerator) Chat(ctx context.Context, req *Request) (*Response, error) {
+	return &Response{Text: s.model}, nil
+}
+
+func (s *stubGenerator) StreamChat(ctx context.Context, req *Request, handler ChunkHandler) (TokenUsage, error) {
+	return TokenUsage{}, handler(s.model)
+}
+
+func (s *stubGenerator) ChatCompletion(ctx context.Context, text string) (*Response, error) {
+	return s.Chat(ctx, NewRequest(text))
+}
+
+func (s *stubGenerator) StreamChatCompletion(ctx context.Context, text string, handler ChunkHandler) (TokenUsage, error) {
+	return s.StreamChat(ctx, NewRequest(text), handler)
+}
+
+// withProvider registers a stub provider for the duration of a test.
+func withProvider(t *testing.T, name string) {
+	t.Helper()
+
+	Register(name, func(cfg config.AIConfig, proxyCfg *proxy.Config) (TextGenerator, error) {
+		return &stubGenerator{model: cfg.Model}, nil
+	})
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, normalizeProviderName(name))
+		registryMu.Unlock()
+	})
+}
+
+func TestRegistry_New(t *testing.T) {
+	withProvider(t, "stub")
+
+	gen, err := New(" STUB ", config.AIConfig{Model: "stub-model"}, &proxy.Config{})
+	require.NoError(t, err)
+
+	resp, err := gen.ChatCompletion(context.Background(), "hi")
+	require.NoError(t, err)
+	assert.Equal(t, "stub-model", resp.Text)
+	assert.Contains(t, Providers(), "stub")
+}
+
+func TestRegistry_UnknownProvider(t *testing.T) {
+	withProvider(t, "stub-a")
+	withProvider(t, "stub-b")
+
+	_, err := New("missing", config.AIConfig{}, &proxy.Config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unsupported LLM provider "missing"`)
+	assert.Contains(t, err.Error(), "stub-a, stub-b")
+}
+
+func TestRegistry_RegisterPanics(t *testing.T) {
+	withProvider(t, "stub-dup")
+
+	factory := func(config.AIConfig, *proxy.Config) (TextGenerator, error) { return nil, nil }
+	assert.Panics(t, func() { Register("", factory) })
+	assert.Panics(t, func() { Register("stub-nil", nil) })
+	assert.Panics(t, func() { Register("stub-dup", factory) })
+}
@@ -0,0 +1,93 @@
+package ai
+
+import "strings"
+
+// DefaultSystemPrompt is sent when a caller does not provide its own.
+const DefaultSystemPrompt = "You are a helpful assistant."
+
+// Role identifies the author of a chat message.
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message is a single turn in a chat conversation.
+type Message struct {
+	Role    Role
+	Content string
+}
+
+// Request describes a chat call. Zero-valued options fall back to the
+// client's configured defaults.
+type Request struct {
+	// System is the system prompt. Messages with RoleSystem are appended to it.
+	System   string
+	Messages []Message
+
+	MaxTokens   int
+	Temperature *float32
+	TopP        *float32
+	Stop        []string
+}
+
+// NewRequest builds a single-turn request with the default system prompt.
+func NewRequest(text string) *Request {
+	return &Request{
+		System: DefaultSystemPrompt,
+		Messages: []Message{
+			{Role: RoleUser, Content: text},
+		},
+	}
+}
+
+// SystemPrompt joins System with the content of any RoleSystem messages.
+func (r *Request) SystemPrompt() string {
+	parts := make([]string, 0, 1)
+	if s := strings.TrimSpace(r.System); s != "" {
+		parts = append(parts, s)
+	}
+	for _, m := range r.Messages {
+		if m.Role == RoleSystem && strings.TrimSpace(m.Content) != "" {
+			parts = append(parts, strings.TrimSpace(m.Content))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Conversation returns the non-system messages in order.
+func (r *Request) Conversation() []Message {
+	msgs := make([]Message, 0, len(r.Messages))
+	for _, m := range r.Messages {
+		if m.Role != RoleSystem {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs
+}
+
+// MaxTokensOr returns the request's max tokens, or def when unset.
+func (r *Request) MaxTokensOr(def int) int {
+	if r.MaxTokens > 0 {
+		return r.MaxTokens
+	}
+	return def
+}
+
+// TemperatureOr returns the request's temperature, or def when unset.
+func (r *Request) TemperatureOr(def float32) float32 {
+	if r.Temperature != nil {
+		return *r.Temperature
+	}
+	return def
+}
+
+// TopPOr returns the request's top_p, or def when unset.
+func (r *Request) TopPOr(def float32) float32 {
+	if r.TopP != nil {
+		return *r.TopP
+	}
+	return def
+}
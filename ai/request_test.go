@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRequest(t *testing.T) {
+	req := NewRequest("review this")
+
+	assert.Equal(t, DefaultSystemPrompt, req.System)
+	assert.Equal(t, []Message{{Role: RoleUser, Content: "review this"}}, req.Messages)
+}
+
+func TestRequest_SystemPromptAndConversation(t *testing.T) {
+	req := &Request{
+		System: "You review Go code.",
+		Messages: []Message{
+			{Role: RoleSystem, Content: "Answer in English."},
+			{Role: RoleUser, Content: "diff 1"},
+			{Role: RoleAssistant, Content: "review 1"},
+			{Role: RoleSystem, Content: "  "},
+			{Role: RoleUser, Content: "diff 2"},
+		},
+	}
+
+	assert.Equal(t, "You review Go code.\n\nAnswer in English.", req.SystemPrompt())
+	assert.Equal(t, []Message{
+		{Role: RoleUser, Content: "diff 1"},
+		{Role: RoleAssistant, Content: "review 1"},
+		{Role: RoleUser, Content: "diff 2"},
+	}, req.Conversation())
+}
+
+func TestRequest_OptionFallbacks(t *testing.T) {
+	req := &Request{}
+	assert.Equal(t, 1000, req.MaxTokensOr(1000))
+	assert.Equal(t, float32(0.7), req.TemperatureOr(0.7))
+	assert.Equal(t, float32(1), req.TopPOr(1))
+
+	temperature := float32(0)
+	topP := float32(0.5)
+	req = &Request{MaxTokens: 64, Temperature: &temperature, TopP: &topP}
+	assert.Equal(t, 64, req.MaxTokensOr(1000))
+	assert.Equal(t, float32(0), req.TemperatureOr(0.7))
+	assert.Equal(t, float32(0.5), req.TopPOr(1))
+}
@@ -0,0 +1,100 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Class is the kind of failure of a model request.
+type Class string
+
+const (
+	// ClassUnknown is any failure that is not worth retrying.
+	ClassUnknown Class = "unknown"
+	// ClassRateLimit means the account is over its request or token quota.
+	ClassRateLimit Class = "rate_limit"
+	// ClassOverloaded means the provider is overloaded or failed internally.
+	ClassOverloaded Class = "overloaded"
+	// ClassTimeout means the request timed out before an answer arrived.
+	ClassTimeout Class = "timeout"
+	// ClassAuth means the credentials were rejected.
+	ClassAuth Class = "auth"
+	// ClassContextLength means the prompt does not fit the model.
+	ClassContextLength Class = "context_length"
+	// ClassCanceled means the caller gave up.
+	ClassCanceled Class = "canceled"
+)
+
+// Retryable reports whether a request failing this way may succeed when
+// sent again unchanged.
+func (c Class) Retryable() bool {
+	switch c {
+	case ClassRateLimit, ClassOverloaded, ClassTimeout:
+		return true
+	}
+	return false
+}
+
+// Classify maps a request error to its class. status is the HTTP status of
+// the last response, or zero when it is unknown, in which case the error
+// message is inspected; providers also report rate limits and overload in
+// stream events that arrive with a 200 status.
+func Classify(err error, status int) Class {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, context.Canceled) {
+		return ClassCanceled
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "insufficient_quota"):
+		// a 429 as well, but waiting does not refill the balance
+		return ClassUnknown
+	case status == http.StatusTooManyRequests:
+		return ClassRateLimit
+	case status == http.StatusRequestTimeout, status == http.StatusGatewayTimeout:
+		return ClassTimeout
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return ClassAuth
+	case status >= http.StatusInternalServerError:
+		return ClassOverloaded
+	case status >= http.StatusBadRequest:
+		if isContextLength(msg) {
+			return ClassContextLength
+		}
+		return ClassUnknown
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ClassTimeout
+	case isContextLength(msg):
+		return ClassContextLength
+	case containsAny(msg, "rate limit", "rate_limit", "too many requests", "resource_exhausted"):
+		return ClassRateLimit
+	case containsAny(msg, "overloaded", "unavailable", "server_error", "internal server error"):
+		return ClassOverloaded
+	case containsAny(msg, "unauthorized", "invalid api key", "invalid_api_key", "authentication", "permission denied"):
+		return ClassAuth
+	}
+	return ClassUnknown
+}
+
+func isContextLength(msg string) bool {
+	return containsAny(msg, "context length", "context_length", "context window", "maximum context", "prompt is too long", "too many tokens")
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+// Package retry resends model requests that failed for transient reasons
+// such as rate limits, overload and timeouts.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/loveRyujin/ReviewBot/proxy"
+)
+
+const (
+	// DefaultMaxAttempts is the number of attempts per request, the first
+	// one included.
+	DefaultMaxAttempts = 3
+	// DefaultBaseDelay is the wait before the first retry; it doubles with
+	// every further attempt.
+	DefaultBaseDelay = time.Second
+	// DefaultMaxDelay caps the wait between two attempts.
+	DefaultMaxDelay = 30 * time.Second
+)
+
+// Config controls the retry policy. Zero values use the defaults.
+type Config struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// FromConfig maps the ai.retry configuration section onto a retry policy.
+func FromConfig(cfg config.RetryConfig) Config {
+	return Config{MaxAttempts: cfg.MaxAttempts, MaxDelay: cfg.MaxDelay}
+}
+
+// Error is returned once a request failed for good. It keeps the class of
+// the last failure so callers can decide whether another backend may help.
+type Error struct {
+	Class    Class
+	Attempts int
+	Err      error
+}
+
+func (e *Error) Error() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf("%v (gave up after %d attempts)", e.Err, e.Attempts)
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Generator is an ai.TextGenerator that retries failed requests of the
+// wrapped generator with jittered exponential backoff, or after the wait a
+// Retry-After header asks for. A stream is never retried once a chunk has
+// been passed to the handler, since the caller already consumed it.
+type Generator struct {
+	next ai.TextGenerator
+	cfg  Config
+
+	// sleep and jitter are replaced in tests.
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter func(d time.Duration) time.Duration
+}
+
+// Wrap returns next retrying with the given policy.
+func Wrap(next ai.TextGenerator, cfg Config) *Generator {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = DefaultBaseDelay
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = DefaultMaxDelay
+	}
+	return &Generator{next: next, cfg: cfg, sleep: sleep, jitter: jitter}
+}
+
+// Chat sends req until it succeeds or fails for a reason retrying cannot fix.
+func (g *Generator) Chat(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+	var resp *ai.Response
+	err := g.do(ctx, func(ctx context.Context) (bool, error) {
+		var err error
+		resp, err = g.next.Chat(ctx, req)
+		return true, err
+	})
+	return resp, err
+}
+
+// StreamChat streams req, retrying only failures that happen before the
+// first chunk reached handler.
+func (g *Generator) StreamChat(ctx context.Context, req *ai.Request, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	var usage ai.TokenUsage
+	err := g.do(ctx, func(ctx context.Context) (bool, error) {
+		emitted := false
+		var err error
+		usage, err = g.next.StreamChat(ctx, req, func(chunk string) error {
+			emitted = true
+			return handler(chunk)
+		})
+		return !emitted, err
+	})
+	return usage, err
+}
+
+// ChatCompletion is Chat with a single user message and the default system prompt.
+func (g *Generator) ChatCompletion(ctx context.Context, text string) (*ai.Response, error) {
+	return g.Chat(ctx, ai.NewRequest(text))
+}
+
+// StreamChatCompletion is StreamChat with a single user message and the default system prompt.
+func (g *Generator) StreamChatCompletion(ctx context.Context, text string, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	return g.StreamChat(ctx, ai.NewRequest(text), handler)
+}
+
+// do runs attempt until it succeeds, fails permanently, or the attempts are
+// used up. attempt reports whether it may be repeated.
+func (g *Generator) do(ctx context.Context, attempt func(ctx context.Context) (bool, error)) error {
+	for n := 1; ; n++ {
+		var last response
+		repeatable, err := attempt(proxy.WithResponseObserver(ctx, last.observe))
+		if err == nil {
+			return nil
+		}
+
+		status, retryAfter := last.get()
+		class := Classify(err, status)
+		if ctx.Err() != nil {
+			class = ClassCanceled
+		}
+		if !class.Retryable() || !repeatable || n >= g.cfg.MaxAttempts {
+			return &Error{Class: class, Attempts: n, Err: err}
+		}
+
+		delay := g.backoff(n)
+		if retryAfter > 0 {
+			if retryAfter > g.cfg.MaxDelay {
+				// waiting less would only hit the limit again
+				return &Error{Class: class, Attempts: n, Err: fmt.Errorf("%w (retry after %s exceeds the maximum delay)", err, retryAfter)}
+			}
+			delay = retryAfter
+		}
+		if sleepErr := g.sleep(ctx, delay); sleepErr != nil {
+			return &Error{Class: ClassCanceled, Attempts: n, Err: fmt.Errorf("%w (stopped waiting to retry: %w)", err, sleepErr)}
+		}
+	}
+}
+
+// backoff returns the jittered wait after the given failed attempt:
+// BaseDelay doubled per attempt, capped at MaxDelay.
+func (g *Generator) backoff(attempt int) time.Duration {
+	d := g.cfg.MaxDelay
+	if shift := attempt - 1; shift < 30 {
+		d = min(g.cfg.BaseDelay<<shift, g.cfg.MaxDelay)
+	}
+	return g.jitter(d)
+}
+
+// jitter spreads waits over [d/2, d) so that parallel requests do not
+// retry in lockstep.
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(d-half)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// response records the status and Retry-After hint of the last response
+// received during an attempt.
+type response struct {
+	mu         sync.Mutex
+	status     int
+	retryAfter time.Duration
+}
+
+func (r *response) observe(resp *http.Response) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = resp.StatusCode
+	r.retryAfter = parseRetryAfter(resp.Header, time.Now())
+}
+
+func (r *response) get() (int, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status, r.retryAfter
+}
+
+// parseRetryAfter reads the wait a server asks for, from the millisecond
+// variant some providers send or from Retry-After in seconds or as a date.
+func parseRetryAfter(h http.Header, now time.Time) time.Duration {
+	if ms, err := strconv.ParseFloat(strings.TrimSpace(h.Get("Retry-After-Ms")), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return max(time.Duration(secs*float64(time.Second)), 0)
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
@@ -0,0 +1,251 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	llmopenai "github.com/loveRyujin/ReviewBot/llm/openai"
+	"github.com/loveRyujin/ReviewBot/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const completion = `{"choices":[{"index":0,"message":{"role":"assistant","content":"looks good"}}],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`
+
+// failure is one scripted error response of the flaky server.
+type failure struct {
+	status  int
+	headers map[string]string
+	body    string
+}
+
+// flakyServer fails the first requests as scripted and answers the rest
+// with answer.
+func flakyServer(t *testing.T, failures []failure, answer http.HandlerFunc) (*llmopenai.Client, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n <= len(failures) {
+			f := failures[n-1]
+			for k, v := range f.headers {
+				w.Header().Set(k, v)
+			}
+			w.WriteHeader(f.status)
+			_, _ = fmt.Fprint(w, f.body)
+			return
+		}
+		answer(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := (&llmopenai.Config{BaseURL: server.URL + "/v1", ApiKey: "k", Model: "gpt-4o"}).New(&proxy.Config{})
+	require.NoError(t, err)
+	return client, &calls
+}
+
+func answerJSON(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = fmt.Fprint(w, completion)
+}
+
+// wrap returns a generator that records its waits instead of sleeping.
+func wrap(next ai.TextGenerator, cfg Config) (*Generator, *[]time.Duration) {
+	g := Wrap(next, cfg)
+	var waits []time.Duration
+	g.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	g.jitter = func(d time.Duration) time.Duration { return d }
+	return g, &waits
+}
+
+func TestGenerator_RetriesTransientFailures(t *testing.T) {
+	client, calls := flakyServer(t, []failure{
+		{status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": "2"}, body: `{"error":{"message":"Rate limit reached","type":"requests"}}`},
+		{status: http.StatusServiceUnavailable, body: `{"error":{"message":"The server is overloaded","type":"server_error"}}`},
+	}, answerJSON)
+	g, waits := wrap(client, Config{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond})
+
+	resp, err := g.ChatCompletion(context.Background(), "review")
+	require.NoError(t, err)
+	assert.Equal(t, "looks good", resp.Text)
+	assert.Equal(t, int32(3), calls.Load())
+	// Retry-After wins over the backoff, then the second backoff step
+	assert.Equal(t, []time.Duration{2 * time.Second, 200 * time.Millisecond}, *waits)
+}
+
+func TestGenerator_GivesUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures []failure
+		cfg      Config
+		class    Class
+		calls    int32
+		errText  string
+	}{
+		{
+			name:     "attempts used up",
+			failures: []failure{{status: 500}, {status: 502}, {status: 503}},
+			cfg:      Config{MaxAttempts: 2},
+			class:    ClassOverloaded,
+			calls:    2,
+			errText:  "gave up after 2 attempts",
+		},
+		{
+			name:     "auth is permanent",
+			failures: []failure{{status: 401, body: `{"error":{"message":"Incorrect API key provided","code":"invalid_api_key"}}`}},
+			cfg:      Config{MaxAttempts: 3},
+			class:    ClassAuth,
+			calls:    1,
+			errText:  "Incorrect API key",
+		},
+		{
+			name:     "context length is permanent",
+			failures: []failure{{status: 400, body: `{"error":{"message":"This model's maximum context length is 8192 tokens","code":"context_length_exceeded"}}`}},
+			cfg:      Config{MaxAttempts: 3},
+			class:    ClassContextLength,
+			calls:    1,
+		},
+		{
+			name:     "retry after beyond the maximum delay",
+			failures: []failure{{status: 429, headers: map[string]string{"Retry-After": "120"}}},
+			cfg:      Config{MaxAttempts: 3, MaxDelay: 10 * time.Second},
+			class:    ClassRateLimit,
+			calls:    1,
+			errText:  "retry after 2m0s exceeds the maximum delay",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, calls := flakyServer(t, tt.failures, answerJSON)
+			g, _ := wrap(client, tt.cfg)
+
+			_, err := g.ChatCompletion(context.Background(), "review")
+			var retryErr *Error
+			require.ErrorAs(t, err, &retryErr)
+			assert.Equal(t, tt.class, retryErr.Class)
+			assert.Equal(t, tt.calls, calls.Load())
+			if tt.errText != "" {
+				assert.ErrorContains(t, err, tt.errText)
+			}
+		})
+	}
+}
+
+func TestGenerator_Stream(t *testing.T) {
+	t.Run("retried before the first chunk", func(t *testing.T) {
+		client, calls := flakyServer(t, []failure{{status: http.StatusBadGateway}}, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+			_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+		})
+		g, _ := wrap(client, Config{MaxAttempts: 3})
+
+		var got strings.Builder
+		_, err := g.StreamChatCompletion(context.Background(), "review", func(chunk string) error {
+			got.WriteString(chunk)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Hi", got.String())
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("not retried after a chunk", func(t *testing.T) {
+		client, calls := flakyServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+			w.(http.Flusher).Flush()
+			// drop the connection in the middle of the stream
+			panic(http.ErrAbortHandler)
+		})
+		g, _ := wrap(client, Config{MaxAttempts: 3})
+
+		var chunks []string
+		_, err := g.StreamChatCompletion(context.Background(), "review", func(chunk string) error {
+			chunks = append(chunks, chunk)
+			return nil
+		})
+		require.Error(t, err)
+		assert.Equal(t, []string{"Hi"}, chunks)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+func TestGenerator_Canceled(t *testing.T) {
+	client, calls := flakyServer(t, []failure{{status: 503}, {status: 503}}, answerJSON)
+	g := Wrap(client, Config{MaxAttempts: 3, BaseDelay: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := g.ChatCompletion(ctx, "review")
+	var retryErr *Error
+	require.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, ClassCanceled, retryErr.Class)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestBackoff(t *testing.T) {
+	g := Wrap(nil, Config{BaseDelay: time.Second, MaxDelay: 5 * time.Second})
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 100: 5 * time.Second} {
+		d := g.backoff(attempt)
+		assert.GreaterOrEqual(t, d, want/2, attempt)
+		assert.Less(t, d, want, attempt)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	header := func(kv ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+
+	assert.Equal(t, 3*time.Second, parseRetryAfter(header("Retry-After", "3"), now))
+	assert.Equal(t, 1500*time.Millisecond, parseRetryAfter(header("Retry-After-Ms", "1500", "Retry-After", "2"), now))
+	assert.Equal(t, 30*time.Second, parseRetryAfter(header("Retry-After", now.Add(30*time.Second).Format(http.TimeFormat)), now))
+	assert.Zero(t, parseRetryAfter(header("Retry-After", now.Add(-time.Minute).Format(http.TimeFormat)), now))
+	assert.Zero(t, parseRetryAfter(header("Retry-After", "soon"), now))
+	assert.Zero(t, parseRetryAfter(header(), now))
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		want   Class
+	}{
+		{errors.New("boom"), 429, ClassRateLimit},
+		{errors.New("You exceeded your current quota: insufficient_quota"), 429, ClassUnknown},
+		{errors.New("boom"), 529, ClassOverloaded},
+		{errors.New("boom"), 504, ClassTimeout},
+		{errors.New("boom"), 403, ClassAuth},
+		{errors.New("prompt is too long: 210000 tokens > 200000 maximum"), 400, ClassContextLength},
+		{errors.New("bad request"), 400, ClassUnknown},
+		{errors.New("anthropic: overloaded_error: Overloaded"), 0, ClassOverloaded},
+		{errors.New("Error 429, Message: Resource has been exhausted, Status: RESOURCE_EXHAUSTED"), 0, ClassRateLimit},
+		{fmt.Errorf("post: %w", context.DeadlineExceeded), 0, ClassTimeout},
+		{fmt.Errorf("post: %w", context.Canceled), 0, ClassCanceled},
+		{errors.New("unexpected EOF"), 0, ClassUnknown},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Classify(tt.err, tt.status), tt.err.Error())
+	}
+	assert.True(t, ClassRateLimit.Retryable())
+	assert.False(t, ClassAuth.Retryable())
+}
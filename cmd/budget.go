@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fatih/color"
+	"github.com/loveRyujin/ReviewBot/pkg/token"
+	"github.com/loveRyujin/ReviewBot/prompt"
+	"github.com/loveRyujin/ReviewBot/review"
+)
+
+// fitDiff checks the prompt rendered from tmpl against the context window of
+// the configured model and shrinks diff until it fits. Chunked review is used
+// instead of truncation when chunkTokens is positive.
+func fitDiff(diff string, tmpl string, data map[string]any, chunkTokens int) (*review.FitResult, error) {
+	counter := token.ForModel(globalConfig.AI.Model)
+	budget := token.NewBudget(globalConfig.AI.Model, globalConfig.AI.ContextWindow, globalConfig.AI.MaxTokens)
+	available, err := budget.Available()
+	if err != nil {
+		return nil, budgetError(err, budget)
+	}
+
+	fit, err := review.Fit(diff, review.FitOptions{
+		Budget: available,
+		Count:  counter.Count,
+		Render: func(d string) (string, error) {
+			values := map[string]any{prompt.FileDiff: d}
+			for k, v := range data {
+				values[k] = v
+			}
+			return prompt.GetPromptTmpl(tmpl, values)
+		},
+		Exclude:     globalConfig.Git.ExcludedList,
+		ChunkTokens: chunkTokens,
+	})
+	if err != nil {
+		return nil, budgetError(err, budget)
+	}
+	return fit, nil
+}
+
+// checkPrompt returns an error when a prompt that cannot be shrunk, such as
+// the aggregation of chunk reviews, does not fit the context window.
+func checkPrompt(text string) error {
+	budget := token.NewBudget(globalConfig.AI.Model, globalConfig.AI.ContextWindow, globalConfig.AI.MaxTokens)
+	available, err := budget.Available()
+	if err != nil {
+		return budgetError(err, budget)
+	}
+	if tokens := token.ForModel(globalConfig.AI.Model).Count(text); tokens > available {
+		return budgetError(fmt.Errorf("%w (%d > %d tokens)", review.ErrPromptTooLarge, tokens, available), budget)
+	}
+	return nil
+}
+
+func budgetError(err error, budget token.Budget) error {
+	return fmt.Errorf("%w (model %s, context window %d tokens)", err, globalConfig.AI.Model, budget.Window)
+}
+
+// reportFit prints what was removed from the diff to make it fit. With w
+// set, plain lines are written to it instead of colored stdout output.
+func reportFit(fit *review.FitResult, w io.Writer) {
+	for _, note := range fit.Notes() {
+		if w != nil {
+			_, _ = fmt.Fprintf(w, "context budget: %s\n", note)
+			continue
+		}
+		color.Yellow("⚠️ Context budget: %s", note)
+	}
+}
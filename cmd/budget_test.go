@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/loveRyujin/ReviewBot/pkg/token"
+	"github.com/loveRyujin/ReviewBot/prompt"
+	"github.com/loveRyujin/ReviewBot/review"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFitDiff_NoRoomForPrompt(t *testing.T) {
+	globalConfig = config.NewDefault()
+	globalConfig.AI.Model = "gpt-4"
+	globalConfig.AI.MaxTokens = 8192
+
+	_, err := fitDiff("diff --git a/a.go b/a.go\n", prompt.CodeReviewFileDiffTmpl, nil, 0)
+	assert.ErrorIs(t, err, token.ErrNoPromptRoom)
+	assert.ErrorContains(t, err, "model gpt-4, context window 8192 tokens")
+}
+
+func TestCheckPrompt(t *testing.T) {
+	globalConfig = config.NewDefault()
+	globalConfig.AI.Model = "gpt-4"
+	globalConfig.AI.ContextWindow = 1000
+	globalConfig.AI.MaxTokens = 200
+
+	require.NoError(t, checkPrompt("merge these reviews"))
+	assert.ErrorIs(t, checkPrompt(strings.Repeat("review remark ", 1000)), review.ErrPromptTooLarge)
+
+	globalConfig.AI.MaxTokens = 1000
+	assert.ErrorIs(t, checkPrompt("merge these reviews"), token.ErrNoPromptRoom)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheStatsCmd)
+}
+
+// cacheCmd groups the commands managing the response cache.
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of model responses",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := initConfig(); err != nil {
+			cobra.CheckErr(err)
+		}
+	},
+}
+
+// cacheClearCmd removes every cached response.
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached responses",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openCache()
+		if err != nil {
+			return err
+		}
+		removed, err := store.Clear()
+		if err != nil {
+			return err
+		}
+		color.Green("Removed %d cached response(s) from %s", removed, store.Dir())
+		return nil
+	},
+}
+
+// cacheStatsCmd prints the location, size and age of the cache.
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the size of the response cache",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openCache()
+		if err != nil {
+			return err
+		}
+		stats, err := store.Stats()
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		_, _ = fmt.Fprintf(w, "Directory: %s\n", store.Dir())
+		_, _ = fmt.Fprintf(w, "Enabled:   %t\n", globalConfig.Cache.Enabled)
+		_, _ = fmt.Fprintf(w, "Entries:   %d (%d expired)\n", stats.Entries, stats.Expired)
+		limit, ttl := "unlimited", "none"
+		if globalConfig.Cache.MaxSize > 0 {
+			limit = formatBytes(int64(globalConfig.Cache.MaxSize))
+		}
+		if globalConfig.Cache.TTL > 0 {
+			ttl = globalConfig.Cache.TTL.String()
+		}
+		_, _ = fmt.Fprintf(w, "Size:      %s of %s\n", formatBytes(stats.Size), limit)
+		_, _ = fmt.Fprintf(w, "TTL:       %s\n", ttl)
+		if stats.Entries > 0 {
+			_, _ = fmt.Fprintf(w, "Oldest:    %s\n", stats.Oldest.Format("2006-01-02 15:04:05"))
+			_, _ = fmt.Fprintf(w, "Newest:    %s\n", stats.Newest.Format("2006-01-02 15:04:05"))
+		}
+		return nil
+	},
+}
+
+// formatBytes renders a size with a binary unit, e.g. 1.5 MiB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
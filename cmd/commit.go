@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"html"
+	"os"
 
 	"github.com/erikgeiser/promptkit/confirmation"
+	"github.com/erikgeiser/promptkit/selection"
 	"github.com/fatih/color"
 	"github.com/loveRyujin/ReviewBot/ai"
-	"github.com/loveRyujin/ReviewBot/git"
+	"github.com/loveRyujin/ReviewBot/commitmsg"
+	"github.com/loveRyujin/ReviewBot/pkg/editor"
+	"github.com/loveRyujin/ReviewBot/pkg/progress"
 	"github.com/loveRyujin/ReviewBot/prompt"
 	"github.com/spf13/cobra"
 )
@@ -18,8 +23,20 @@ var (
 	excludedList     []string
 	amend            bool
 	autoStage        bool
+	candidateCount   int
+	fastCommit       bool
+	breakingChange   bool
 )
 
+const (
+	previewCommit = "Commit"
+	previewEdit   = "Edit in $EDITOR"
+	previewCancel = "Cancel"
+)
+
+// previewActions are offered by --preview before committing.
+var previewActions = []string{previewCommit, previewEdit, previewCancel}
+
 func init() {
 	commitCmd.PersistentFlags().BoolVar(&preview, "preview", false, "preview the commit message before committing")
 	commitCmd.PersistentFlags().IntVar(&diffUnifiedLines, "diff_unified", 3, "number of context lines to show in diff")
@@ -27,6 +44,9 @@ func init() {
 	commitCmd.PersistentFlags().BoolVar(&amend, "amend", false, "amend the commit message")
 	commitCmd.PersistentFlags().StringVar(&outputLang, "output_lang", "en", "output language of the commit message(default: English)")
 	commitCmd.PersistentFlags().BoolVar(&autoStage, "auto_stage", false, "automatically run 'git add .' before generating the commit message")
+	commitCmd.PersistentFlags().IntVar(&candidateCount, "candidates", 1, "number of commit message candidates to choose from")
+	commitCmd.PersistentFlags().BoolVar(&fastCommit, "fast", false, "generate the commit message in a single request")
+	commitCmd.PersistentFlags().BoolVar(&breakingChange, "breaking", false, "mark the commit as a breaking change")
 }
 
 // commitCmd is a Cobra command that automates the generation of commit messages
@@ -40,6 +60,11 @@ var commitCmd = &cobra.Command{
 		applyCommitOverrides()
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// the flags were applied after the config was validated on load
+		if err := globalConfig.Runtime.Commit.Validate(); err != nil {
+			return fmt.Errorf("commit: %w", err)
+		}
+
 		g := globalConfig.GitCommandConfig().New()
 
 		if autoStage {
@@ -75,14 +100,28 @@ var commitCmd = &cobra.Command{
 			return fmt.Errorf("git diff input size (%d bytes) exceeds limit (%d). adjust --max_input_size or split changes", len(diff), maxInputSize)
 		}
 
-		currentModel := globalConfig.AI.Model
-		provider := ai.Provider(globalConfig.AI.Provider)
-		client, err := GetModelClient(provider)
+		// the scope follows every staged file, including those dropped to fit the context
+		scope, err := inferScope(diff, globalConfig.Git.Scopes)
+		if err != nil {
+			color.Yellow("⚠️ Cannot infer the commit scope: %v", err)
+		}
+
+		// make sure the prompt fits the model context before sending it
+		fast := globalConfig.Runtime.Commit.Fast
+		tmpl := prompt.CommitFileDiffTmpl
+		if fast {
+			tmpl = prompt.CommitMessageJSONTmpl
+		}
+		fit, err := fitDiff(diff, tmpl, nil, 0)
 		if err != nil {
 			return err
 		}
-		// get file diff summary prompt for commit message
-		instruction, err := prompt.GetPromptTmpl(prompt.CommitFileDiffTmpl, map[string]any{prompt.FileDiff: diff})
+		reportFit(fit, nil)
+		diff = fit.Diff
+
+		currentModel := globalConfig.AI.Model
+		provider := ai.Provider(globalConfig.AI.Provider)
+		client, err := GetModelClient(provider)
 		if err != nil {
 			return err
 		}
@@ -90,62 +129,81 @@ var commitCmd = &cobra.Command{
 		color.Green("Using %s model for commit message generation\n", currentModel)
 		color.Green("We are trying to generate commit message\n")
 
-		// generate file diff summary
-		color.Cyan("Generating file diff summary...\n")
-		resp, err := client.ChatCompletion(cmd.Context(), instruction)
-		if err != nil {
-			return err
+		var (
+			body       string
+			breaking   []string
+			candidates []commitmsg.Message
+			usage      ai.TokenUsage
+		)
+		if fast {
+			msg, u, err := generateFast(cmd.Context(), client, diff, progress.NewStages(os.Stdout, 1))
+			usage = u
+			switch {
+			case errors.Is(err, errUnparsable):
+				color.Yellow("⚠️ %v, falling back to the three-step pipeline", err)
+			case err != nil:
+				return err
+			default:
+				body, breaking = msg.Body, msg.Breaking
+				candidates = []commitmsg.Message{{Type: msg.Type, Scope: msg.Scope, Title: msg.Title}}
+			}
 		}
-		summary := resp.Text
-		color.Magenta(resp.TokenUsage.String())
 
-		// generate commit message prefix
-		color.Cyan("Generating commit message prefix...\n")
-		instruction, err = prompt.GetPromptTmpl(prompt.CommitMessagePrefixTmpl, map[string]any{prompt.SummaryPoint: summary})
-		if err != nil {
-			return err
-		}
-		resp, err = client.ChatCompletion(cmd.Context(), instruction)
-		if err != nil {
-			return err
+		n := max(globalConfig.Runtime.Commit.Candidates, 1)
+		if candidates == nil {
+			var (
+				summary string
+				u       ai.TokenUsage
+			)
+			summary, candidates, u, err = generateStepwise(cmd.Context(), client, diff, n, progress.NewStages(os.Stdout, 3))
+			usage = usage.Add(u)
+			if err != nil {
+				return err
+			}
+			body, breaking = commitmsg.SplitBreaking(summary)
 		}
-		prefix := resp.Text
-		color.Magenta(resp.TokenUsage.String())
+		candidates = applyConventions(candidates, body, breaking, scope, globalConfig.Runtime.Commit.Breaking)
 
-		// generate commit message title
-		color.Cyan("Generating commit message title...\n")
-		instruction, err = prompt.GetPromptTmpl(prompt.CommitMessageTitleTmpl, map[string]any{prompt.SummaryPoint: summary})
-		if err != nil {
-			return err
-		}
-		resp, err = client.ChatCompletion(cmd.Context(), instruction)
-		if err != nil {
-			return err
+		candidate := candidates[0]
+		if n > 1 {
+			if candidate, err = chooseCandidate(candidates); err != nil {
+				return err
+			}
 		}
-		title := resp.Text
-		color.Magenta(resp.TokenUsage.String())
 
-		// generate commit message
-		commitMsg, err := git.GetCommitMessageTmpl(map[string]any{
-			git.CommitMessagePrefix:  prefix,
-			git.CommitMessageTitle:   title,
-			git.CommitMessageSummary: summary,
-		})
+		// check the message before translating it, only its title and body
+		// are translated afterwards
+		commitMsg, err := commitMessage(candidate)
 		if err != nil {
 			return err
 		}
+		commitOutput := html.UnescapeString(commitMsg)
+		if err := commitmsg.Validate(commitOutput); err != nil && !preview {
+			return fmt.Errorf("%w, commit with --preview to edit it:\n\n%s", err, commitOutput)
+		}
 
-		escapeCommitMsg := html.UnescapeString(commitMsg)
-		lang := prompt.GetLanguage(globalConfig.Git.Lang)
-		commitOutput := escapeCommitMsg
-
-		if lang != prompt.DefaultLanguage {
-			translated, err := translateContent(cmd.Context(), client, escapeCommitMsg, lang)
+		if lang := prompt.GetLanguage(globalConfig.Git.Lang); lang != prompt.DefaultLanguage {
+			color.Cyan("We are trying to translate the commit message to " + lang)
+			var translated commitmsg.Message
+			err := progress.WithSpinnerAndCustomMessages(
+				"🌐 Translating commit message...",
+				"Translation completed",
+				"Failed to translate commit message",
+				func() error {
+					t, u, err := translateMessage(cmd.Context(), client, candidate, lang)
+					translated, usage = t, usage.Add(u)
+					return err
+				},
+			)
 			if err != nil {
 				return err
 			}
-			commitOutput = translated
+			if commitMsg, err = commitMessage(translated); err != nil {
+				return err
+			}
+			commitOutput = html.UnescapeString(commitMsg)
 		}
+		color.Magenta(usage.String())
 
 		// Output commit message from AI
 		color.Yellow("================Commit Summary====================")
@@ -153,13 +211,32 @@ var commitCmd = &cobra.Command{
 		color.Yellow("==================================================")
 
 		if preview {
-			ready, err := confirmation.New("\nWhether to commit this preview message?", confirmation.Yes).RunPrompt()
-			if err != nil {
-				return err
-			}
-			if !ready {
-				return nil
+			// offer the preview again until the message is valid or the user gives up
+			for {
+				action, err := selection.New("\nWhat do you want to do with this preview message?", previewActions).RunPrompt()
+				if err != nil {
+					return err
+				}
+				switch action {
+				case previewCancel:
+					return nil
+				case previewEdit:
+					if commitOutput, err = editor.Edit(commitOutput); err != nil {
+						return err
+					}
+					if commitOutput == "" {
+						color.Yellow("Aborting commit due to empty commit message.")
+						return nil
+					}
+				}
+				if err := commitmsg.Validate(commitOutput); err != nil {
+					color.Red("✗ %v", err)
+					continue
+				}
+				break
 			}
+		} else if err := commitmsg.Validate(commitOutput); err != nil {
+			return fmt.Errorf("%w, commit with --preview to edit it:\n\n%s", err, commitOutput)
 		}
 
 		output, err := g.Commit(commitOutput)
@@ -189,9 +266,21 @@ func applyCommitOverrides() {
 	if preview {
 		globalConfig.Runtime.Commit.Preview = true
 	}
+	if candidateCount != 1 {
+		globalConfig.Runtime.Commit.Candidates = candidateCount
+	}
+	if fastCommit {
+		globalConfig.Runtime.Commit.Fast = true
+	}
+	if breakingChange {
+		globalConfig.Runtime.Commit.Breaking = true
+	}
 	if aiProviderFlag != "" {
 		globalConfig.AI.Provider = aiProviderFlag
 	}
+	if noCache {
+		globalConfig.Cache.Enabled = false
+	}
 	if aiModelFlag != "" {
 		globalConfig.AI.Model = aiModelFlag
 	}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/erikgeiser/promptkit/selection"
+	"github.com/erikgeiser/promptkit/textinput"
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/commitmsg"
+	"github.com/loveRyujin/ReviewBot/git"
+	"github.com/loveRyujin/ReviewBot/pkg/progress"
+	"github.com/loveRyujin/ReviewBot/prompt"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// anotherPrefix and anotherTitle follow up on earlier answers when more
+	// than one candidate is requested.
+	anotherPrefix = "Give another label from the list that also fits these changes, or repeat the best label if no other one does. Write only the label."
+	anotherTitle  = "Write another title for the same changes, worded differently from the previous ones. Remember to write only one line, no more than 60 characters."
+
+	editChoice = "✎ Write my own title"
+)
+
+// commitMessage renders the full commit message.
+func commitMessage(m commitmsg.Message) (string, error) {
+	return git.GetCommitMessageTmpl(map[string]any{
+		git.CommitMessagePrefix:  m.Prefix(),
+		git.CommitMessageTitle:   m.Title,
+		git.CommitMessageSummary: m.Body,
+		git.CommitMessageFooter:  m.Footer(),
+	})
+}
+
+// generateCandidates asks for n prefix and title variants of the same
+// summary. Prefixes and titles only depend on the summary, so the two run
+// concurrently as stages 2 and 3 of the pipeline. Identical variants are
+// dropped, so fewer than n may be returned.
+func generateCandidates(ctx context.Context, client ai.TextGenerator, summary string, n int, stages *progress.Stages) ([]commitmsg.Message, ai.TokenUsage, error) {
+	prefixPrompt, err := prompt.GetPromptTmpl(prompt.CommitMessagePrefixTmpl, map[string]any{prompt.SummaryPoint: summary})
+	if err != nil {
+		return nil, ai.TokenUsage{}, err
+	}
+	titlePrompt, err := prompt.GetPromptTmpl(prompt.CommitMessageTitleTmpl, map[string]any{prompt.SummaryPoint: summary})
+	if err != nil {
+		return nil, ai.TokenUsage{}, err
+	}
+
+	n = max(n, 1)
+	var (
+		prefixes, titles        []string
+		prefixUsage, titleUsage ai.TokenUsage
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return stages.Run(2, stageMessage(n, "prefix", "prefixes"), func() error {
+			var err error
+			prefixes, prefixUsage, err = askVariants(gctx, client, prefixPrompt, anotherPrefix, n)
+			return err
+		})
+	})
+	g.Go(func() error {
+		return stages.Run(3, stageMessage(n, "title", "titles"), func() error {
+			var err error
+			titles, titleUsage, err = askVariants(gctx, client, titlePrompt, anotherTitle, n)
+			return err
+		})
+	})
+	err = g.Wait()
+	usage := prefixUsage.Add(titleUsage)
+	if err != nil {
+		return nil, usage, err
+	}
+
+	candidates := make([]commitmsg.Message, 0, n)
+	seen := make(map[string]struct{}, n)
+	for i := range n {
+		c, err := commitmsg.ParseLabel(prefixes[i])
+		if err != nil {
+			return nil, usage, err
+		}
+		c.Title = titles[i]
+		if _, dup := seen[c.Header()]; dup {
+			continue
+		}
+		seen[c.Header()] = struct{}{}
+		candidates = append(candidates, c)
+	}
+	return candidates, usage, nil
+}
+
+func stageMessage(n int, one, many string) string {
+	if n == 1 {
+		return "generating " + one
+	}
+	return fmt.Sprintf("generating %d %s", n, many)
+}
+
+// askVariants sends instruction n times. Every request after the first
+// carries the earlier answers and asks for a different one, which also
+// keeps their cache keys apart.
+func askVariants(ctx context.Context, client ai.TextGenerator, instruction, again string, n int) ([]string, ai.TokenUsage, error) {
+	var usage ai.TokenUsage
+	answers := make([]string, 0, n)
+	for range n {
+		answer, u, err := askVariant(ctx, client, instruction, answers, again)
+		usage = usage.Add(u)
+		if err != nil {
+			return nil, usage, err
+		}
+		answers = append(answers, answer)
+	}
+	return answers, usage, nil
+}
+
+// askVariant sends instruction followed by the earlier answers, each
+// answered with again.
+func askVariant(ctx context.Context, client ai.TextGenerator, instruction string, previous []string, again string) (string, ai.TokenUsage, error) {
+	req := ai.NewRequest(instruction)
+	for _, answer := range previous {
+		req.Messages = append(req.Messages,
+			ai.Message{Role: ai.RoleAssistant, Content: answer},
+			ai.Message{Role: ai.RoleUser, Content: again},
+		)
+	}
+	resp, err := client.Chat(ctx, req)
+	if err != nil {
+		return "", ai.TokenUsage{}, err
+	}
+	return strings.TrimSpace(resp.Text), resp.TokenUsage, nil
+}
+
+// chooseCandidate lets the user pick one of the candidates or write a first
+// line of their own, starting from the first candidate.
+func chooseCandidate(candidates []commitmsg.Message) (commitmsg.Message, error) {
+	choices := make([]string, 0, len(candidates)+1)
+	for _, c := range candidates {
+		choices = append(choices, c.Header())
+	}
+	choices = append(choices, editChoice)
+
+	sel := selection.New("Which commit message do you want to use?", choices)
+	sel.Filter = nil
+	choice, err := sel.RunPrompt()
+	if err != nil {
+		return commitmsg.Message{}, err
+	}
+	if choice != editChoice {
+		return candidates[slices.Index(choices, choice)], nil
+	}
+
+	input := textinput.New("Commit title:")
+	input.InitialValue = candidates[0].Header()
+	input.Validate = func(line string) error {
+		_, err := commitmsg.ParseHeader(line)
+		return err
+	}
+	line, err := input.RunPrompt()
+	if err != nil {
+		return commitmsg.Message{}, err
+	}
+	return editedCandidate(candidates[0], line)
+}
+
+// editedCandidate replaces the first line of c with line. The breaking
+// changes of c are kept while line is still marked with "!".
+func editedCandidate(c commitmsg.Message, line string) (commitmsg.Message, error) {
+	m, err := commitmsg.ParseHeader(line)
+	if err != nil {
+		return commitmsg.Message{}, err
+	}
+	m.Body = c.Body
+	if len(m.Breaking) > 0 && len(c.Breaking) > 0 {
+		m.Breaking = c.Breaking
+	}
+	return m, nil
+}
+
+// generateStepwise runs the three-stage pipeline: the diff summary, then
+// the prefixes and titles derived from it.
+func generateStepwise(ctx context.Context, client ai.TextGenerator, diff string, n int, stages *progress.Stages) (string, []commitmsg.Message, ai.TokenUsage, error) {
+	instruction, err := prompt.GetPromptTmpl(prompt.CommitFileDiffTmpl, map[string]any{prompt.FileDiff: diff})
+	if err != nil {
+		return "", nil, ai.TokenUsage{}, err
+	}
+
+	var resp *ai.Response
+	err = stages.Run(1, "summarizing diff", func() error {
+		resp, err = client.ChatCompletion(ctx, instruction)
+		return err
+	})
+	if err != nil {
+		return "", nil, ai.TokenUsage{}, err
+	}
+
+	candidates, usage, err := generateCandidates(ctx, client, resp.Text, n, stages)
+	return resp.Text, candidates, resp.TokenUsage.Add(usage), err
+}
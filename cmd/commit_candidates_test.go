@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/commitmsg"
+	"github.com/loveRyujin/ReviewBot/pkg/progress"
+	"github.com/loveRyujin/ReviewBot/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// isPrefixRequest matches label requests with the given number of earlier answers.
+func isPrefixRequest(previous int) any {
+	return mock.MatchedBy(func(req *ai.Request) bool {
+		return strings.Contains(req.Messages[0].Content, "best label") && len(req.Messages) == 1+2*previous
+	})
+}
+
+// isTitleRequest matches title requests with the given number of earlier answers.
+func isTitleRequest(previous int) any {
+	return mock.MatchedBy(func(req *ai.Request) bool {
+		return strings.Contains(req.Messages[0].Content, "PULL REQUEST TITLE") && len(req.Messages) == 1+2*previous
+	})
+}
+
+func quietStages() *progress.Stages {
+	return progress.NewStages(io.Discard, 3)
+}
+
+// chatFunc is a TextGenerator answering Chat requests with a function.
+type chatFunc func(ctx context.Context, req *ai.Request) (*ai.Response, error)
+
+func (f chatFunc) Chat(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+	return f(ctx, req)
+}
+
+func (f chatFunc) StreamChat(context.Context, *ai.Request, ai.ChunkHandler) (ai.TokenUsage, error) {
+	return ai.TokenUsage{}, errors.New("not implemented")
+}
+
+func (f chatFunc) ChatCompletion(ctx context.Context, text string) (*ai.Response, error) {
+	return f(ctx, ai.NewRequest(text))
+}
+
+func (f chatFunc) StreamChatCompletion(context.Context, string, ai.ChunkHandler) (ai.TokenUsage, error) {
+	return ai.TokenUsage{}, errors.New("not implemented")
+}
+
+func isPrefix(req *ai.Request) bool {
+	return strings.Contains(req.Messages[0].Content, "best label")
+}
+
+func reply(text string) *ai.Response {
+	return &ai.Response{Text: text, TokenUsage: ai.TokenUsage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12}}
+}
+
+func TestGenerateCandidates(t *testing.T) {
+	client := new(mocks.MockTextGenerator)
+	client.On("Chat", mock.Anything, isPrefixRequest(0)).Return(reply("feat\n"), nil).Once()
+	client.On("Chat", mock.Anything, isTitleRequest(0)).Return(reply("Add profile switching"), nil).Once()
+	client.On("Chat", mock.Anything, isPrefixRequest(1)).Return(reply("feat"), nil).Once()
+	client.On("Chat", mock.Anything, isTitleRequest(1)).Return(reply("Support named AI profiles"), nil).Once()
+	client.On("Chat", mock.Anything, isPrefixRequest(2)).Return(reply("feat"), nil).Once()
+	client.On("Chat", mock.Anything, isTitleRequest(2)).Return(reply("Add profile switching"), nil).Once()
+
+	candidates, usage, err := generateCandidates(context.Background(), client, "- add profiles", 3, quietStages())
+	require.NoError(t, err)
+	assert.Equal(t, []commitmsg.Message{
+		{Type: "feat", Title: "Add profile switching"},
+		{Type: "feat", Title: "Support named AI profiles"},
+	}, candidates, "duplicates are dropped")
+	assert.Equal(t, 72, usage.TotalTokens, "usage covers every call")
+	client.AssertExpectations(t)
+}
+
+func TestGenerateCandidates_FollowUpCarriesEarlierAnswers(t *testing.T) {
+	client := new(mocks.MockTextGenerator)
+	client.On("Chat", mock.Anything, isPrefixRequest(0)).Return(reply("fix"), nil).Once()
+	client.On("Chat", mock.Anything, isTitleRequest(0)).Return(reply("Handle empty diffs"), nil).Once()
+	client.On("Chat", mock.Anything, isPrefixRequest(1)).Return(reply("fix"), nil).Once()
+	client.On("Chat", mock.Anything, mock.MatchedBy(func(req *ai.Request) bool {
+		return len(req.Messages) == 3 &&
+			req.Messages[1] == ai.Message{Role: ai.RoleAssistant, Content: "Handle empty diffs"} &&
+			req.Messages[2].Content == anotherTitle
+	})).Return(reply("Skip commits without changes"), nil).Once()
+
+	candidates, _, err := generateCandidates(context.Background(), client, "- handle empty diffs", 2, quietStages())
+	require.NoError(t, err)
+	assert.Len(t, candidates, 2)
+	client.AssertExpectations(t)
+}
+
+func TestGenerateCandidates_Error(t *testing.T) {
+	client := new(mocks.MockTextGenerator)
+	client.On("Chat", mock.Anything, isPrefixRequest(0)).Return(reply("fix"), nil).Once()
+	client.On("Chat", mock.Anything, isTitleRequest(0)).Return(nil, errors.New("boom")).Once()
+
+	_, usage, err := generateCandidates(context.Background(), client, "- x", 1, quietStages())
+	assert.EqualError(t, err, "boom")
+	assert.Equal(t, 12, usage.TotalTokens)
+}
+
+func TestGenerateCandidates_PrefixAndTitleRunConcurrently(t *testing.T) {
+	// the title request only answers once the prefix request is in flight,
+	// which never happens if the two run one after the other
+	prefixStarted := make(chan struct{})
+	client := chatFunc(func(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+		if isPrefix(req) {
+			close(prefixStarted)
+			return reply("feat"), nil
+		}
+		select {
+		case <-prefixStarted:
+			return reply("Add stages"), nil
+		case <-time.After(5 * time.Second):
+			return nil, errors.New("prefix and title were not generated concurrently")
+		}
+	})
+
+	candidates, _, err := generateCandidates(context.Background(), client, "- x", 1, quietStages())
+	require.NoError(t, err)
+	assert.Equal(t, []commitmsg.Message{{Type: "feat", Title: "Add stages"}}, candidates)
+}
+
+func TestGenerateCandidates_FailureCancelsOtherStage(t *testing.T) {
+	client := chatFunc(func(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+		if isPrefix(req) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return nil, errors.New("title failed")
+	})
+
+	_, _, err := generateCandidates(context.Background(), client, "- x", 1, quietStages())
+	assert.EqualError(t, err, "title failed")
+}
+
+func TestGenerateCandidates_NormalizesLabels(t *testing.T) {
+	client := new(mocks.MockTextGenerator)
+	client.On("Chat", mock.Anything, isPrefixRequest(0)).Return(reply("The best label is `fix`."), nil).Once()
+	client.On("Chat", mock.Anything, isTitleRequest(0)).Return(reply("Handle empty diffs"), nil).Once()
+
+	candidates, _, err := generateCandidates(context.Background(), client, "- x", 1, quietStages())
+	require.NoError(t, err)
+	assert.Equal(t, []commitmsg.Message{{Type: "fix", Title: "Handle empty diffs"}}, candidates)
+
+	client = new(mocks.MockTextGenerator)
+	client.On("Chat", mock.Anything, isPrefixRequest(0)).Return(reply("improvement"), nil).Once()
+	client.On("Chat", mock.Anything, isTitleRequest(0)).Return(reply("Handle empty diffs"), nil).Once()
+
+	_, _, err = generateCandidates(context.Background(), client, "- x", 1, quietStages())
+	assert.ErrorIs(t, err, commitmsg.ErrInvalid)
+}
+
+func TestEditedCandidate(t *testing.T) {
+	generated := commitmsg.Message{Type: "feat", Title: "Drop v1 config", Body: "- Remove the loader", Breaking: []string{"v1 config files are rejected"}}
+
+	m, err := editedCandidate(generated, "feat(config)!: Remove the v1 loader")
+	require.NoError(t, err)
+	assert.Equal(t, commitmsg.Message{Type: "feat", Scope: "config", Title: "Remove the v1 loader", Body: "- Remove the loader", Breaking: []string{"v1 config files are rejected"}}, m)
+
+	m, err = editedCandidate(generated, "refactor: Remove the v1 loader")
+	require.NoError(t, err)
+	assert.Empty(t, m.Breaking, "dropping the ! drops the breaking changes")
+
+	_, err = editedCandidate(generated, "Remove the v1 loader")
+	assert.ErrorIs(t, err, commitmsg.ErrNotConventional)
+}
+
+func TestCommitMessage(t *testing.T) {
+	msg, err := commitMessage(commitmsg.Message{Type: "docs", Title: "Explain profiles", Body: "- Add a profiles section"})
+	require.NoError(t, err)
+	assert.Equal(t, "docs: Explain profiles\n\n- Add a profiles section\n", msg)
+
+	msg, err = commitMessage(commitmsg.Message{Type: "feat", Scope: "cli", Title: "Rename --fast", Breaking: []string{"--fast is now --single"}})
+	require.NoError(t, err)
+	assert.Equal(t, "feat(cli)!: Rename --fast\n\nBREAKING-CHANGE: --fast is now --single\n", msg)
+	assert.NoError(t, commitmsg.Validate(msg))
+}
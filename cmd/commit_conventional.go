@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/commitmsg"
+	"github.com/loveRyujin/ReviewBot/git"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+)
+
+// inferScope maps the files of diff to a scope with rules. Files are named
+// by their new path, or their old one when deleted.
+func inferScope(diff string, rules []config.ScopeRule) (string, error) {
+	if len(rules) == 0 {
+		return "", nil
+	}
+	files, err := git.ParseDiff(diff)
+	if err != nil {
+		return "", err
+	}
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Name())
+	}
+	return commitmsg.InferScope(paths, rules), nil
+}
+
+// applyConventions completes the generated first lines into full messages.
+// A scope inferred from the changed paths replaces the generated one, and
+// every candidate carries the body and breaking changes. force marks the
+// commit as breaking even when none was reported; the title then describes
+// the change.
+func applyConventions(candidates []commitmsg.Message, body string, breaking []string, scope string, force bool) []commitmsg.Message {
+	messages := make([]commitmsg.Message, 0, len(candidates))
+	for _, c := range candidates {
+		if scope != "" {
+			c.Scope = scope
+		}
+		c.Body = body
+		c.Breaking = breaking
+		if force && len(breaking) == 0 {
+			c.Breaking = []string{c.Title}
+		}
+		messages = append(messages, c)
+	}
+	return messages
+}
+
+// translateMessage translates the title and body of m into lang. The type,
+// scope and footer tokens stay as they are, so the translated message still
+// follows Conventional Commits.
+func translateMessage(ctx context.Context, client ai.TextGenerator, m commitmsg.Message, lang string) (commitmsg.Message, ai.TokenUsage, error) {
+	resp, err := translate(ctx, client, m.Title, lang)
+	if err != nil {
+		return m, ai.TokenUsage{}, err
+	}
+	usage := resp.TokenUsage
+	// the header is a single line; a translation adding more would break it
+	if title, _, _ := strings.Cut(strings.TrimSpace(resp.Text), "\n"); strings.TrimSpace(title) != "" {
+		m.Title = strings.TrimSpace(title)
+	}
+
+	if strings.TrimSpace(m.Body) != "" {
+		resp, err = translate(ctx, client, m.Body, lang)
+		if err != nil {
+			return m, usage, err
+		}
+		usage = usage.Add(resp.TokenUsage)
+		m.Body = strings.TrimSpace(resp.Text)
+	}
+	return m, usage, nil
+}
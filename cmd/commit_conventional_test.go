@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/commitmsg"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyConventions(t *testing.T) {
+	candidates := []commitmsg.Message{{Type: "feat", Scope: "ai", Title: "Add profiles"}, {Type: "feat", Title: "Support profiles"}}
+
+	got := applyConventions(candidates, "- Add profiles", nil, "config", false)
+	assert.Equal(t, []commitmsg.Message{
+		{Type: "feat", Scope: "config", Title: "Add profiles", Body: "- Add profiles"},
+		{Type: "feat", Scope: "config", Title: "Support profiles", Body: "- Add profiles"},
+	}, got, "the inferred scope replaces the generated one")
+
+	got = applyConventions(candidates, "", []string{"ai.model is required"}, "", false)
+	assert.Equal(t, "ai", got[0].Scope, "the generated scope stays without an inferred one")
+	assert.Equal(t, []string{"ai.model is required"}, got[1].Breaking)
+
+	got = applyConventions(candidates, "", nil, "", true)
+	assert.Equal(t, []string{"Add profiles"}, got[0].Breaking)
+	assert.Equal(t, "feat(ai)!: Add profiles", got[0].Header())
+}
+
+func TestInferScope(t *testing.T) {
+	rules := []config.ScopeRule{{Pattern: "cmd/", Scope: "cli"}}
+	diff := `diff --git a/cmd/old.go b/cmd/new.go
+similarity index 90%
+rename from cmd/old.go
+rename to cmd/new.go
+diff --git a/cmd/gone.go b/cmd/gone.go
+deleted file mode 100644
+index 1234567..0000000
+--- a/cmd/gone.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package cmd
+diff --git "a/cmd/caf\303\251.go" "b/cmd/caf\303\251.go"
+index 1234567..89abcde 100644
+--- "a/cmd/caf\303\251.go"
++++ "b/cmd/caf\303\251.go"
+@@ -1 +1 @@
+-package a
++package cmd
+`
+	scope, err := inferScope(diff, rules)
+	require.NoError(t, err)
+	assert.Equal(t, "cli", scope)
+
+	scope, err = inferScope(diff+"diff --git a/go.mod b/go.mod\nindex 1234567..89abcde 100644\n", rules)
+	require.NoError(t, err)
+	assert.Empty(t, scope)
+
+	scope, err = inferScope("garbage", nil)
+	require.NoError(t, err, "nothing is parsed without rules")
+	assert.Empty(t, scope)
+}
+
+func TestTranslateMessage(t *testing.T) {
+	client := chatFunc(func(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+		switch text := req.Messages[0].Content; {
+		case strings.Contains(text, "- Add profiles"):
+			return reply("- 添加配置档案\n"), nil
+		case strings.Contains(text, "Add profiles"):
+			// a stray second line must not reach the header
+			return reply("新增：添加配置档案\n（翻译）"), nil
+		}
+		return nil, assert.AnError
+	})
+	m := commitmsg.Message{Type: "feat", Scope: "config", Title: "Add profiles", Body: "- Add profiles", Breaking: []string{"ai.model is required"}}
+
+	got, usage, err := translateMessage(context.Background(), client, m, "Simplified Chinese")
+	require.NoError(t, err)
+	assert.Equal(t, "feat(config)!: 新增：添加配置档案", got.Header())
+	assert.Equal(t, "- 添加配置档案", got.Body)
+	assert.Equal(t, m.Breaking, got.Breaking)
+	assert.Equal(t, 24, usage.TotalTokens)
+
+	text, err := commitMessage(got)
+	require.NoError(t, err)
+	assert.NoError(t, commitmsg.Validate(text))
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/commitmsg"
+	"github.com/loveRyujin/ReviewBot/pkg/progress"
+	"github.com/loveRyujin/ReviewBot/prompt"
+)
+
+// errUnparsable marks a single-request answer that is not a usable commit
+// message, so that the caller can fall back to the three-step pipeline.
+var errUnparsable = errors.New("could not parse the single-request commit message")
+
+// generateFast asks for the type, scope, title and body of the commit
+// message as JSON in one request, the only stage of the pipeline.
+func generateFast(ctx context.Context, client ai.TextGenerator, diff string, stages *progress.Stages) (*commitmsg.Message, ai.TokenUsage, error) {
+	instruction, err := prompt.GetPromptTmpl(prompt.CommitMessageJSONTmpl, map[string]any{prompt.FileDiff: diff})
+	if err != nil {
+		return nil, ai.TokenUsage{}, err
+	}
+	var resp *ai.Response
+	err = stages.Run(1, "generating commit message", func() error {
+		resp, err = client.ChatCompletion(ctx, instruction)
+		return err
+	})
+	if err != nil {
+		return nil, ai.TokenUsage{}, err
+	}
+	msg, err := commitmsg.Parse(resp.Text)
+	if err != nil {
+		return nil, resp.TokenUsage, fmt.Errorf("%w: %w", errUnparsable, err)
+	}
+	return msg, resp.TokenUsage, nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/commitmsg"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/loveRyujin/ReviewBot/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateFast(t *testing.T) {
+	client := new(mocks.MockTextGenerator)
+	client.On("ChatCompletion", mock.Anything, mock.MatchedBy(func(text string) bool {
+		return strings.Contains(text, "added_line")
+	})).Return(reply("```json\n{\"type\":\"feat\",\"scope\":\"cli\",\"title\":\"Add fast mode\",\"body\":\"- One request\"}\n```"), nil)
+
+	msg, usage, err := generateFast(context.Background(), client, "+added_line", quietStages())
+	require.NoError(t, err)
+	assert.Equal(t, &commitmsg.Message{Type: "feat", Scope: "cli", Title: "Add fast mode", Body: "- One request"}, msg)
+	assert.Equal(t, 12, usage.TotalTokens)
+}
+
+func TestGenerateFast_Unparsable(t *testing.T) {
+	client := new(mocks.MockTextGenerator)
+	client.On("ChatCompletion", mock.Anything, mock.Anything).Return(reply("feat: Add fast mode"), nil)
+
+	_, usage, err := generateFast(context.Background(), client, "diff", quietStages())
+	assert.ErrorIs(t, err, errUnparsable)
+	assert.Equal(t, 12, usage.TotalTokens, "the failed attempt still counts")
+}
+
+func TestGenerateFast_RequestError(t *testing.T) {
+	client := new(mocks.MockTextGenerator)
+	client.On("ChatCompletion", mock.Anything, mock.Anything).Return(nil, errors.New("boom"))
+
+	_, _, err := generateFast(context.Background(), client, "diff", quietStages())
+	assert.EqualError(t, err, "boom")
+	assert.NotErrorIs(t, err, errUnparsable)
+}
+
+func TestCommitCmd_FastWithCandidates(t *testing.T) {
+	globalConfig = config.NewDefault()
+	fastCommit, candidateCount = true, 3
+	t.Cleanup(func() { fastCommit, candidateCount = false, 1 })
+
+	applyCommitOverrides()
+	err := commitCmd.RunE(commitCmd, nil)
+	assert.ErrorContains(t, err, "fast cannot be combined with candidates")
+}
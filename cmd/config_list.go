@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"sort"
+	"strings"
 
 	"github.com/fatih/color"
+	"github.com/loveRyujin/ReviewBot/ai"
 	"github.com/rodaine/table"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -15,33 +17,56 @@ func init() {
 
 // availableKeys is a map of configuration keys and their descriptions
 var availableKeys = map[string]string{
-	"git.diff_file":        "Path to the diff file to be reviewed",
-	"git.max_input_size":   "Maximum git diff input size (default: 20MB, units: bytes)",
-	"git.diff_unified":     "Number of context lines in git diff output (default: 3)",
-	"git.exclude_list":     "Files to exclude from git diff command",
-	"git.lang":             "Language for summarization output (default: English)",
-	"git.template_file":    "Path to template file for commit messages",
-	"git.template_string":  "Template string for formatting commit messages",
-	"ai.socks":             "SOCKS proxy URL for API connections",
-	"ai.api_key":           "Authentication key for OpenAI API access",
-	"ai.model":             "AI model identifier to use for requests",
-	"ai.proxy":             "HTTP proxy URL for API connections",
-	"ai.base_url":          "Custom base URL for API requests",
-	"ai.timeout":           "Maximum duration to wait for API response",
-	"ai.max_tokens":        "Maximum token limit for generated completions",
-	"ai.temperature":       "Randomness control parameter (0-1): lower values for focused results, higher for creative variety",
-	"ai.provider":          "Service provider selection ('openai' or 'azure')",
-	"ai.skip_verify":       "Option to bypass TLS certificate verification",
-	"ai.headers":           "Additional custom HTTP headers for API requests",
-	"ai.top_p":             "Nucleus sampling parameter: controls diversity by limiting to top percentage of probability mass",
-	"ai.frequency_penalty": "Parameter to reduce repetition by penalizing tokens based on their frequency",
-	"ai.presence_penalty":  "Parameter to encourage topic diversity by penalizing previously used tokens",
-	"prompt.folder":        "Directory path for custom prompt templates",
+	"git.diff_file":         "Path to the diff file to be reviewed",
+	"git.max_input_size":    "Maximum git diff input size (default: 20MB, units: bytes)",
+	"git.diff_unified":      "Number of context lines in git diff output (default: 3)",
+	"git.exclude_list":      "Files to exclude from git diff command",
+	"git.lang":              "Language for summarization output (default: English)",
+	"git.template_file":     "Path to template file for commit messages",
+	"git.template_string":   "Template string for formatting commit messages",
+	"ai.socks":              "SOCKS proxy URL for API connections",
+	"ai.api_key":            "Authentication key for OpenAI API access",
+	"ai.model":              "AI model identifier to use for requests",
+	"ai.proxy":              "HTTP proxy URL for API connections",
+	"ai.base_url":           "Custom base URL for API requests",
+	"ai.timeout":            "Maximum duration to wait for API response",
+	"ai.max_tokens":         "Maximum token limit for generated completions",
+	"ai.context_window":     "Context window of the model in tokens (0: use the built-in table)",
+	"ai.temperature":        "Randomness control parameter (0-1): lower values for focused results, higher for creative variety",
+	"ai.provider":           "Service provider selection",
+	"ai.skip_verify":        "Option to bypass TLS certificate verification",
+	"ai.headers":            "Additional custom HTTP headers for API requests",
+	"ai.top_p":              "Nucleus sampling parameter: controls diversity by limiting to top percentage of probability mass",
+	"ai.frequency_penalty":  "Parameter to reduce repetition by penalizing tokens based on their frequency",
+	"ai.presence_penalty":   "Parameter to encourage topic diversity by penalizing previously used tokens",
+	"ai.azure.api_version":  "Azure OpenAI REST API version (e.g. 2024-10-21)",
+	"ai.azure.auth_type":    "Azure OpenAI authentication ('api_key' or 'azure_ad' bearer token in ai.api_key)",
+	"ai.azure.deployment":   "Azure OpenAI deployment name used when the model has no explicit mapping",
+	"ai.azure.deployments":  "Map of model name to Azure OpenAI deployment name, set as 'model=deployment,...'",
+	"ai.retry.max_attempts": "Attempts per model request on rate limits, overload and timeouts (1: no retries)",
+	"ai.retry.max_delay":    "Maximum wait between two attempts; longer Retry-After hints give up instead",
+	"ai.profile":            "Active profile from ai.profiles (switch with 'reviewbot config use')",
+	"github.base_url":       "GitHub API root (https://<host>/api/v3 for GitHub Enterprise)",
+	"github.token":          "GitHub token used to read pull requests and post reviews",
+	"gitlab.base_url":       "GitLab API root (https://<host>/api/v4 for self-hosted instances)",
+	"gitlab.token":          "GitLab token used to read merge requests and post discussions",
+	"cache.enabled":         "Cache model responses on disk and reuse them for identical requests",
+	"cache.dir":             "Directory of the response cache (default: cache under the user config dir)",
+	"cache.ttl":             "Maximum age of a cached response (0: no expiry)",
+	"cache.max_size":        "Maximum size of the response cache in bytes (0: unlimited)",
+	"prompt.folder":         "Directory path for custom prompt templates",
+}
+
+// secretKeys lists configuration keys whose values are masked by config list.
+var secretKeys = map[string]struct{}{
+	"ai.api_key":   {},
+	"github.token": {},
+	"gitlab.token": {},
 }
 
 // configListCmd represents the "list" command which lists all configuration settings.
 // It displays the settings in a formatted table with the configuration name and value.
-// Sensitive information like "openai.api_key" is masked for security purposes.
+// Sensitive information like "ai.api_key" is masked for security purposes.
 var configListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all configuration settings",
@@ -68,8 +93,12 @@ var configListCmd = &cobra.Command{
 
 		// Add the key and value to the table
 		for _, v := range keys {
-			// Hide the api key
-			if v == "openai.api_key" {
+			if v == "ai.provider" {
+				tbl.AddRow(v, viper.Get(v), providerDescription())
+				continue
+			}
+			// Hide credentials
+			if _, ok := secretKeys[v]; ok {
 				tbl.AddRow(v, "****************", availableKeys[v])
 				continue
 			}
@@ -80,3 +109,8 @@ var configListCmd = &cobra.Command{
 		tbl.Print()
 	},
 }
+
+// providerDescription lists the registered providers for the ai.provider key.
+func providerDescription() string {
+	return availableKeys["ai.provider"] + " (" + strings.Join(ai.Providers(), ", ") + ")"
+}
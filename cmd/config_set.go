@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -17,7 +18,8 @@ func init() {
 // configuration value. It requires at least two arguments: a key and a value.
 // The command validates the key against a predefined list of available keys
 // and updates the configuration using Viper. If the key is "git.exclude_list",
-// the value is split into a list using commas. The updated configuration is
+// the value is split into a list using commas; "ai.azure.deployments" takes
+// comma separated model=deployment pairs. The updated configuration is
 // then written to the configuration file. On success, a confirmation message
 // is displayed with the path to the configuration file.
 var configSetCmd = &cobra.Command{
@@ -36,9 +38,16 @@ var configSetCmd = &cobra.Command{
 		}
 
 		// set the config value in viper
-		if args[0] == "git.exclude_list" {
+		switch args[0] {
+		case "git.exclude_list":
 			viper.Set(args[0], strings.Split(args[1], ","))
-		} else {
+		case "ai.azure.deployments":
+			deployments, err := config.ParseDeployments(args[1])
+			if err != nil {
+				return err
+			}
+			viper.Set(args[0], deployments)
+		default:
 			viper.Set(args[0], args[1])
 		}
 
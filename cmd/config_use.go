@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	configCmd.AddCommand(configUseCmd)
+}
+
+// configUseCmd makes a profile from ai.profiles the active one by writing
+// ai.profile to the config file. The file is read directly rather than
+// through initConfig, so that a profile that was removed or broken can
+// still be switched away from.
+var configUseCmd = &cobra.Command{
+	Use:   "use <profile>",
+	Short: "Switch the active AI profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := configFile()
+		if err != nil {
+			return err
+		}
+		v := viper.New()
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return err
+		}
+
+		cfg := config.NewDefault()
+		if err := v.Unmarshal(cfg, config.DecodeHook()); err != nil {
+			return err
+		}
+		profile, err := cfg.AI.WithProfile(args[0])
+		if err != nil {
+			return err
+		}
+		profile.Profiles = nil
+		if err := profile.Validate(); err != nil {
+			return fmt.Errorf("profile %s: %w", profile.Profile, err)
+		}
+
+		v.Set("ai.profile", profile.Profile)
+		if err := v.WriteConfig(); err != nil {
+			return err
+		}
+		color.Green("switched to profile %s (%s/%s), you can see the config file: %s", profile.Profile, profile.Provider, profile.Model, file)
+		return nil
+	},
+}
+
+// configFile returns the path of the config file initConfig reads.
+func configFile() (string, error) {
+	if configPath != "" {
+		return config.ResolveConfigPath(configPath)
+	}
+	if err := ensureDefaultConfigFile(); err != nil {
+		return "", err
+	}
+	for _, dir := range searchDirs() {
+		file := filepath.Join(dir, defaultConfigFile)
+		if _, err := os.Stat(file); err == nil {
+			return filepath.Abs(file)
+		}
+	}
+	return "", fmt.Errorf("config file %s not found", defaultConfigFile)
+}
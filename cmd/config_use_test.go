@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigUse(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "reviewbot.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+ai:
+  profiles:
+    local:
+      provider: ollama
+      model: llama3.1
+    broken:
+      provider: gemini
+      model: gemini-2.5-pro
+`), 0o600))
+	old := configPath
+	configPath = file
+	t.Cleanup(func() { configPath = old })
+
+	require.NoError(t, configUseCmd.RunE(configUseCmd, []string{"Local"}))
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "profile: local")
+
+	assert.ErrorContains(t, configUseCmd.RunE(configUseCmd, []string{"broken"}), "api_key cannot be empty")
+	assert.ErrorContains(t, configUseCmd.RunE(configUseCmd, []string{"missing"}), "unknown profile")
+}
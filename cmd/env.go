@@ -5,28 +5,44 @@ import (
 )
 
 var keyToEnv = map[string]string{
-	"git.diff_file":        "GIT_DIFF_FILE",
-	"git.max_input_size":   "GIT_MAX_INPUT_SIZE",
-	"git.diff_unified":     "GIT_DIFF_UNIFIED",
-	"git.exclude_list":     "GIT_EXCLUDE_LIST",
-	"git.lang":             "GIT_LANG",
-	"git.template_file":    "GIT_TEMPLATE_FILE",
-	"git.template_string":  "GIT_TEMPLATE_STRING",
-	"ai.socks":             "AI_SOCKS",
-	"ai.api_key":           "AI_API_KEY",
-	"ai.model":             "AI_MODEL",
-	"ai.proxy":             "AI_PROXY",
-	"ai.base_url":          "AI_BASE_URL",
-	"ai.timeout":           "AI_TIMEOUT",
-	"ai.max_tokens":        "AI_MAX_TOKENS",
-	"ai.temperature":       "AI_TEMPERATURE",
-	"ai.provider":          "AI_PROVIDER",
-	"ai.skip_verify":       "AI_SKIP_VERIFY",
-	"ai.headers":           "AI_HEADERS",
-	"ai.top_p":             "AI_TOP_P",
-	"ai.frequency_penalty": "AI_FREQUENCY_PENALTY",
-	"ai.presence_penalty":  "AI_PRESENCE_PENALTY",
-	"prompt.folder":        "PROMPT_FOLDER",
+	"git.diff_file":         "GIT_DIFF_FILE",
+	"git.max_input_size":    "GIT_MAX_INPUT_SIZE",
+	"git.diff_unified":      "GIT_DIFF_UNIFIED",
+	"git.exclude_list":      "GIT_EXCLUDE_LIST",
+	"git.lang":              "GIT_LANG",
+	"git.template_file":     "GIT_TEMPLATE_FILE",
+	"git.template_string":   "GIT_TEMPLATE_STRING",
+	"ai.socks":              "AI_SOCKS",
+	"ai.api_key":            "AI_API_KEY",
+	"ai.model":              "AI_MODEL",
+	"ai.proxy":              "AI_PROXY",
+	"ai.base_url":           "AI_BASE_URL",
+	"ai.timeout":            "AI_TIMEOUT",
+	"ai.max_tokens":         "AI_MAX_TOKENS",
+	"ai.context_window":     "AI_CONTEXT_WINDOW",
+	"ai.temperature":        "AI_TEMPERATURE",
+	"ai.provider":           "AI_PROVIDER",
+	"ai.skip_verify":        "AI_SKIP_VERIFY",
+	"ai.headers":            "AI_HEADERS",
+	"ai.top_p":              "AI_TOP_P",
+	"ai.frequency_penalty":  "AI_FREQUENCY_PENALTY",
+	"ai.presence_penalty":   "AI_PRESENCE_PENALTY",
+	"ai.azure.api_version":  "AI_AZURE_API_VERSION",
+	"ai.azure.auth_type":    "AI_AZURE_AUTH_TYPE",
+	"ai.azure.deployment":   "AI_AZURE_DEPLOYMENT",
+	"ai.azure.deployments":  "AI_AZURE_DEPLOYMENTS",
+	"ai.retry.max_attempts": "AI_RETRY_MAX_ATTEMPTS",
+	"ai.retry.max_delay":    "AI_RETRY_MAX_DELAY",
+	"ai.profile":            "AI_PROFILE",
+	"github.base_url":       "GITHUB_BASE_URL",
+	"github.token":          "GITHUB_TOKEN",
+	"gitlab.base_url":       "GITLAB_BASE_URL",
+	"gitlab.token":          "GITLAB_TOKEN",
+	"cache.enabled":         "CACHE_ENABLED",
+	"cache.dir":             "CACHE_DIR",
+	"cache.ttl":             "CACHE_TTL",
+	"cache.max_size":        "CACHE_MAX_SIZE",
+	"prompt.folder":         "PROMPT_FOLDER",
 }
 
 func init() {
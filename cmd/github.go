@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/forge/github"
+	"github.com/loveRyujin/ReviewBot/prompt"
+	"github.com/loveRyujin/ReviewBot/review"
+)
+
+// reviewGitHubPR reviews a pull request and publishes the findings as a PR
+// review with inline comments. Progress goes to stderr; in json and sarif
+// format the report is also written to w.
+func reviewGitHubPR(ctx context.Context, ref string, w io.Writer) error {
+	if err := globalConfig.Runtime.Review.Validate(); err != nil {
+		return fmt.Errorf("review: %w", err)
+	}
+	pr, err := github.ParsePullRequestRef(ref)
+	if err != nil {
+		return err
+	}
+	if globalConfig.GitHub.Token == "" {
+		return errors.New("github.token is required to publish reviews, set it with 'reviewbot config set github.token <token>' or REVIEWBOT_GITHUB_TOKEN")
+	}
+
+	gh, err := github.FromConfig(globalConfig.GitHub).New(globalConfig.ProxyConfig())
+	if err != nil {
+		return err
+	}
+
+	info := color.New(color.FgCyan)
+	_, _ = info.Fprintf(os.Stderr, "Fetching pull request %s\n", pr)
+	meta, err := gh.PullRequest(ctx, pr)
+	if err != nil {
+		return err
+	}
+	diff, err := gh.PullRequestDiff(ctx, pr, globalConfig.Git.MaxInputSize)
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		return fmt.Errorf("pull request %s has no changes to review", pr)
+	}
+
+	_, _ = info.Fprintf(os.Stderr, "Reviewing %q\n", meta.Title)
+	report, err := forgeReview(ctx, diff)
+	if err != nil {
+		return err
+	}
+
+	// anchor comments to the diff GitHub knows, not the budgeted one
+	req := github.NewReviewRequest(report, diff, meta.Head.SHA)
+	published, err := gh.CreateReview(ctx, pr, req)
+	if err != nil {
+		return fmt.Errorf("publish review: %w", err)
+	}
+	_, _ = color.New(color.FgGreen).Fprintf(os.Stderr, "Published review with %d inline comment(s): %s\n", len(req.Comments), published.HTMLURL)
+
+	return writeForgeReport(w, report, diff)
+}
+
+// forgeReview reviews the diff of a pull or merge request into a report and
+// prints the token usage to stderr.
+func forgeReview(ctx context.Context, diff string) (*review.Report, error) {
+	client, err := GetModelClient(ai.Provider(globalConfig.AI.Provider))
+	if err != nil {
+		return nil, err
+	}
+	lang := prompt.GetLanguage(globalConfig.Git.Lang)
+
+	report, _, err := structuredReview(ctx, client, diff, lang)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = color.New(color.FgMagenta).Fprintln(os.Stderr, report.TokenUsage.String())
+	return report, nil
+}
+
+// writeForgeReport writes the report of a published review to w in json and
+// sarif format; the text formats only go to the forge.
+func writeForgeReport(w io.Writer, report *review.Report, diff string) error {
+	switch globalConfig.Runtime.Review.Format {
+	case FormatJSON:
+		return report.WriteJSON(w)
+	case FormatSARIF:
+		return review.WriteSARIFRuns(w, review.SARIFRun{Report: report, Diff: diff})
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/forge/github"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/loveRyujin/ReviewBot/review"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const githubPRDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,2 +1,3 @@
+ package main
++import "os"
+ func main() {}
+`
+
+func TestReviewGitHubPR(t *testing.T) {
+	var posted github.ReviewRequest
+	gh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer gh-token", r.Header.Get("Authorization"))
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/o/r/pulls/5/reviews":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+			_, _ = w.Write([]byte(`{"id":1,"html_url":"https://github.test/o/r/pull/5#pullrequestreview-1"}`))
+		case r.URL.Path == "/repos/o/r/pulls/5" && r.Header.Get("Accept") == "application/vnd.github.diff":
+			_, _ = w.Write([]byte(githubPRDiff))
+		case r.URL.Path == "/repos/o/r/pulls/5":
+			_, _ = w.Write([]byte(`{"number":5,"title":"Add import","head":{"sha":"deadbeef"}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(gh.Close)
+
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := `{"summary":"One unused import.","findings":[{"file":"main.go","start_line":2,"end_line":2,"severity":"low","category":"style","title":"Unused import","message":"os is not used."}]}`
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"message": map[string]string{"role": "assistant", "content": content},
+			"done":    true,
+		})
+	}))
+	t.Cleanup(llm.Close)
+
+	globalConfig = config.NewDefault()
+	globalConfig.AI.Provider = "ollama"
+	globalConfig.AI.BaseURL = llm.URL
+	globalConfig.AI.Model = "llama3.1"
+	globalConfig.Cache.Dir = t.TempDir()
+	globalConfig.GitHub = config.GitHubConfig{BaseURL: gh.URL, Token: "gh-token"}
+	globalConfig.Runtime.Review.Format = FormatJSON
+
+	var out bytes.Buffer
+	require.NoError(t, reviewGitHubPR(context.Background(), "o/r#5", &out))
+
+	assert.Equal(t, "deadbeef", posted.CommitID)
+	assert.Equal(t, "COMMENT", posted.Event)
+	require.Len(t, posted.Comments, 1)
+	assert.Equal(t, github.ReviewComment{Path: "main.go", Position: 2, Body: "**[LOW] Style: Unused import**\n\nos is not used."}, posted.Comments[0])
+	assert.Contains(t, posted.Body, "One unused import.")
+
+	var report review.Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Len(t, report.Findings, 1)
+}
+
+func TestReviewGitHubPRRequiresToken(t *testing.T) {
+	globalConfig = config.NewDefault()
+	err := reviewGitHubPR(context.Background(), "o/r#5", &bytes.Buffer{})
+	assert.ErrorContains(t, err, "github.token is required")
+
+	globalConfig.GitHub.Token = "x"
+	err = reviewGitHubPR(context.Background(), "o/r", &bytes.Buffer{})
+	assert.ErrorContains(t, err, "invalid pull request")
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/loveRyujin/ReviewBot/forge/gitlab"
+)
+
+// reviewGitLabMR reviews a merge request and publishes the findings as
+// discussion threads plus a summary note. Progress goes to stderr; in json
+// and sarif format the report is also written to w.
+func reviewGitLabMR(ctx context.Context, ref string, w io.Writer) error {
+	if err := globalConfig.Runtime.Review.Validate(); err != nil {
+		return fmt.Errorf("review: %w", err)
+	}
+	mr, err := gitlab.ParseMergeRequestRef(ref)
+	if err != nil {
+		return err
+	}
+	if globalConfig.GitLab.Token == "" {
+		return errors.New("gitlab.token is required to publish reviews, set it with 'reviewbot config set gitlab.token <token>' or REVIEWBOT_GITLAB_TOKEN")
+	}
+
+	gl, err := gitlab.FromConfig(globalConfig.GitLab).New(globalConfig.ProxyConfig())
+	if err != nil {
+		return err
+	}
+
+	info := color.New(color.FgCyan)
+	_, _ = info.Fprintf(os.Stderr, "Fetching merge request %s\n", mr)
+	changes, err := gl.MergeRequestChanges(ctx, mr, globalConfig.Git.MaxInputSize)
+	if err != nil {
+		return err
+	}
+	if changes.Overflow {
+		_, _ = color.New(color.FgYellow).Fprintf(os.Stderr, "GitLab left out some changes of %s, only part of the merge request is reviewed\n", mr)
+	}
+	diff := changes.Diff()
+	if diff == "" {
+		return fmt.Errorf("merge request %s has no changes to review", mr)
+	}
+	if len(diff) >= globalConfig.Git.MaxInputSize {
+		return fmt.Errorf("diff of merge request %s exceeds the input size limit", mr)
+	}
+
+	_, _ = info.Fprintf(os.Stderr, "Reviewing %q\n", changes.Title)
+	report, err := forgeReview(ctx, diff)
+	if err != nil {
+		return err
+	}
+
+	discussions, err := gl.PublishReview(ctx, mr, changes, report)
+	if err != nil {
+		return fmt.Errorf("publish review: %w", err)
+	}
+	_, _ = color.New(color.FgGreen).Fprintf(os.Stderr, "Published %d discussion(s) and a summary note: %s\n", discussions, changes.WebURL)
+
+	return writeForgeReport(w, report, diff)
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/forge/gitlab"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewGitLabMR(t *testing.T) {
+	var discussions []gitlab.Discussion
+	var note gitlab.Note
+	gl := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gl-token", r.Header.Get("PRIVATE-TOKEN"))
+		switch r.URL.EscapedPath() {
+		case "/projects/g%2Fp/merge_requests/5/changes":
+			_, _ = w.Write([]byte(`{"iid":5,"title":"Add import","web_url":"https://gitlab.test/g/p/-/merge_requests/5",
+				"diff_refs":{"base_sha":"b","start_sha":"s","head_sha":"h"},
+				"changes":[{"old_path":"main.go","new_path":"main.go","a_mode":"100644","b_mode":"100644",
+				"diff":"@@ -1,2 +1,3 @@\n package main\n+import \"os\"\n func main() {}\n"}]}`))
+		case "/projects/g%2Fp/merge_requests/5/discussions":
+			var d gitlab.Discussion
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&d))
+			discussions = append(discussions, d)
+			w.WriteHeader(http.StatusCreated)
+		case "/projects/g%2Fp/merge_requests/5/notes":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&note))
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(gl.Close)
+
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := `{"summary":"One unused import.","findings":[{"file":"main.go","start_line":2,"end_line":2,"severity":"low","category":"style","title":"Unused import","message":"os is not used."}]}`
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"message": map[string]string{"role": "assistant", "content": content},
+			"done":    true,
+		})
+	}))
+	t.Cleanup(llm.Close)
+
+	globalConfig = config.NewDefault()
+	globalConfig.AI.Provider = "ollama"
+	globalConfig.AI.BaseURL = llm.URL
+	globalConfig.AI.Model = "llama3.1"
+	globalConfig.Cache.Dir = t.TempDir()
+	globalConfig.GitLab = config.GitLabConfig{BaseURL: gl.URL, Token: "gl-token"}
+
+	var out bytes.Buffer
+	require.NoError(t, reviewGitLabMR(context.Background(), "g/p!5", &out))
+
+	require.Len(t, discussions, 1)
+	assert.Equal(t, "**[LOW] Style: Unused import**\n\nos is not used.", discussions[0].Body)
+	assert.Equal(t, &gitlab.Position{PositionType: "text", BaseSHA: "b", StartSHA: "s", HeadSHA: "h", OldPath: "main.go", NewPath: "main.go", NewLine: 2}, discussions[0].Position)
+	assert.Contains(t, note.Body, "One unused import.")
+	assert.Empty(t, out.String())
+}
+
+func TestReviewGitLabMRRequiresToken(t *testing.T) {
+	globalConfig = config.NewDefault()
+	err := reviewGitLabMR(context.Background(), "g/p!5", &bytes.Buffer{})
+	assert.ErrorContains(t, err, "gitlab.token is required")
+
+	globalConfig.GitLab.Token = "x"
+	err = reviewGitLabMR(context.Background(), "g/p#5", &bytes.Buffer{})
+	assert.ErrorContains(t, err, "invalid merge request")
+}
@@ -1,39 +1,86 @@
 package cmd
 
 import (
-	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/fatih/color"
 	"github.com/loveRyujin/ReviewBot/ai"
-	"github.com/loveRyujin/ReviewBot/llm/gemini"
-	"github.com/loveRyujin/ReviewBot/llm/openai"
+	"github.com/loveRyujin/ReviewBot/ai/cache"
+	"github.com/loveRyujin/ReviewBot/ai/fallback"
+	"github.com/loveRyujin/ReviewBot/ai/retry"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+
+	// Register the built-in LLM providers.
+	_ "github.com/loveRyujin/ReviewBot/llm/anthropic"
+	_ "github.com/loveRyujin/ReviewBot/llm/gemini"
+	_ "github.com/loveRyujin/ReviewBot/llm/ollama"
+	_ "github.com/loveRyujin/ReviewBot/llm/openai"
 )
 
-func NewOpenAIClient() (*openai.Client, error) {
-	proxyCfg := globalConfig.ProxyConfig()
-	return globalConfig.OpenAIConfig().New(proxyCfg)
+// GetModelClient builds the text generator registered for the given
+// provider. Transient failures are retried, then sent to the configured
+// fallback backends in order, and answers come from the response cache
+// unless it is disabled.
+func GetModelClient(provider ai.Provider) (ai.TextGenerator, error) {
+	primary := globalConfig.AI
+	primary.Provider = provider.String()
+	client, err := newRetryingClient(primary)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(primary.Fallbacks) > 0 {
+		backends := []fallback.Backend{{Name: backendName(primary), Generator: client}}
+		for i := range primary.Fallbacks {
+			cfg := primary.Fallback(i)
+			next, err := newRetryingClient(cfg)
+			if err != nil {
+				return nil, fmt.Errorf("ai.fallbacks[%d]: %w", i, err)
+			}
+			backends = append(backends, fallback.Backend{Name: backendName(cfg), Generator: next})
+		}
+		client = fallback.New(backends, func(failed, next string, err error) {
+			_, _ = color.New(color.FgYellow).Fprintf(os.Stderr, "⚠️ %s failed (%v), falling back to %s\n", failed, err, next)
+		})
+	}
+	if !globalConfig.Cache.Enabled {
+		return client, nil
+	}
+
+	store, err := openCache()
+	if err != nil {
+		return nil, err
+	}
+	return cache.Wrap(client, store, cache.ScopeFromConfig(primary)), nil
 }
 
-func NewDeepSeekClient() (*openai.Client, error) {
-	proxyCfg := globalConfig.ProxyConfig()
-	return globalConfig.DeepSeekConfig().New(proxyCfg)
+// newRetryingClient builds the client of one backend with the retry policy
+// of the ai section.
+func newRetryingClient(cfg config.AIConfig) (ai.TextGenerator, error) {
+	base, err := ai.New(cfg.Provider, cfg, globalConfig.ProxyConfig())
+	if err != nil {
+		return nil, err
+	}
+	return retry.Wrap(base, retry.FromConfig(cfg.Retry)), nil
 }
 
-func NewGeminiClient() (*gemini.Client, error) {
-	proxyCfg := globalConfig.ProxyConfig()
-	return globalConfig.GeminiConfig().New(proxyCfg)
+// backendName identifies a backend in token usage and fallback notices.
+func backendName(cfg config.AIConfig) string {
+	return strings.ToLower(strings.TrimSpace(cfg.Provider)) + "/" + cfg.Model
 }
 
-func GetModelClient(provider ai.Provider) (ai.TextGenerator, error) {
-	switch provider {
-	case ai.OpenAI:
-		return NewOpenAIClient()
-	case ai.Anthropic:
-		return nil, nil
-	case ai.DeepSeek:
-		return NewDeepSeekClient()
-	case ai.Gemini:
-		return NewGeminiClient()
-	default:
-		return nil, errors.New("unsupported LLM provider")
+// openCache opens the response cache configured in the cache section.
+func openCache() (*cache.Store, error) {
+	dir := globalConfig.Cache.Dir
+	if dir == "" {
+		configDir, err := resolveDefaultConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(configDir, "cache")
 	}
+	return cache.Open(dir, globalConfig.Cache.TTL, int64(globalConfig.Cache.MaxSize)), nil
 }
@@ -1,11 +1,19 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
 	"testing"
 
 	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/ai/cache"
 	"github.com/loveRyujin/ReviewBot/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetModelClient(t *testing.T) {
@@ -47,10 +55,16 @@ func TestGetModelClient(t *testing.T) {
 			wantNil:  false,
 		},
 		{
-			name:     "anthropic provider (not implemented)",
+			name:     "anthropic provider",
 			provider: ai.Anthropic,
 			wantErr:  false,
-			wantNil:  true, // Returns nil, nil for anthropic
+			wantNil:  false,
+		},
+		{
+			name:     "ollama provider",
+			provider: ai.Ollama,
+			wantErr:  false,
+			wantNil:  false,
 		},
 		{
 			name:     "unsupported provider",
@@ -78,56 +92,177 @@ func TestGetModelClient(t *testing.T) {
 	}
 }
 
-func TestNewOpenAIClient(t *testing.T) {
-	globalConfig = &config.Config{
-		AI: config.AIConfig{
-			Provider:    "openai",
-			APIKey:      "sk-test-key",
-			Model:       "gpt-4",
-			MaxTokens:   1000,
-			Temperature: 0.7,
-			TopP:        1.0,
+func TestGetModelClient_ProviderConfigs(t *testing.T) {
+	tests := []struct {
+		name string
+		ai   config.AIConfig
+	}{
+		{
+			name: "openai",
+			ai: config.AIConfig{
+				Provider:    "openai",
+				APIKey:      "sk-test-key",
+				Model:       "gpt-4",
+				MaxTokens:   1000,
+				Temperature: 0.7,
+				TopP:        1.0,
+			},
+		},
+		{
+			name: "deepseek",
+			ai: config.AIConfig{
+				Provider:    "deepseek",
+				APIKey:      "sk-test-key",
+				Model:       "deepseek-chat",
+				MaxTokens:   1000,
+				Temperature: 0.7,
+				TopP:        1.0,
+			},
+		},
+		{
+			name: "gemini",
+			ai: config.AIConfig{
+				Provider:    "gemini",
+				APIKey:      "test-gemini-key",
+				Model:       "gemini-pro",
+				MaxTokens:   2048,
+				Temperature: 0.9,
+				TopP:        0.95,
+			},
+		},
+		{
+			name: "anthropic",
+			ai: config.AIConfig{
+				Provider:    "anthropic",
+				APIKey:      "sk-ant-test",
+				Model:       "claude-sonnet-4-5",
+				MaxTokens:   1024,
+				Temperature: 0.7,
+				TopP:        1.0,
+			},
+		},
+		{
+			name: "ollama",
+			ai: config.AIConfig{
+				Provider:    "ollama",
+				Model:       "llama3.1",
+				MaxTokens:   1024,
+				Temperature: 0.7,
+				TopP:        1.0,
+			},
+		},
+		{
+			name: "azure",
+			ai: config.AIConfig{
+				Provider:  "azure",
+				APIKey:    "azure-key",
+				BaseURL:   "https://res.openai.azure.com",
+				Model:     "gpt-4o",
+				MaxTokens: 1000,
+				Azure:     config.AzureConfig{APIVersion: "2024-10-21"},
+			},
 		},
-		Proxy: config.ProxyConfig{},
 	}
 
-	client, err := NewOpenAIClient()
-	assert.NoError(t, err)
-	assert.NotNil(t, client)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			globalConfig = &config.Config{AI: tt.ai, Proxy: config.ProxyConfig{}}
+
+			client, err := GetModelClient(ai.Provider(tt.ai.Provider))
+			assert.NoError(t, err)
+			assert.NotNil(t, client)
+		})
+	}
 }
 
-func TestNewDeepSeekClient(t *testing.T) {
-	globalConfig = &config.Config{
-		AI: config.AIConfig{
-			Provider:    "deepseek",
-			APIKey:      "sk-test-key",
-			Model:       "deepseek-chat",
-			MaxTokens:   1000,
-			Temperature: 0.7,
-			TopP:        1.0,
-		},
-		Proxy: config.ProxyConfig{},
+func TestGetModelClient_UnknownProviderListsRegistered(t *testing.T) {
+	globalConfig = &config.Config{}
+
+	_, err := GetModelClient(ai.Provider("cohere"))
+	assert.Error(t, err)
+	for _, name := range []string{"anthropic", "azure", "deepseek", "gemini", "ollama", "openai"} {
+		assert.Contains(t, err.Error(), name)
 	}
+}
 
-	client, err := NewDeepSeekClient()
-	assert.NoError(t, err)
-	assert.NotNil(t, client)
+func TestGetModelClient_Cache(t *testing.T) {
+	globalConfig = config.NewDefault()
+	globalConfig.AI.APIKey = "test-key"
+	globalConfig.Cache.Dir = t.TempDir()
+
+	client, err := GetModelClient(ai.OpenAI)
+	require.NoError(t, err)
+	assert.IsType(t, &cache.Generator{}, client)
+
+	globalConfig.Cache.Enabled = false
+	client, err = GetModelClient(ai.OpenAI)
+	require.NoError(t, err)
+	assert.NotEqual(t, reflect.TypeOf(&cache.Generator{}), reflect.TypeOf(client))
 }
 
-func TestNewGeminiClient(t *testing.T) {
-	globalConfig = &config.Config{
-		AI: config.AIConfig{
-			Provider:    "gemini",
-			APIKey:      "test-gemini-key",
-			Model:       "gemini-pro",
-			MaxTokens:   2048,
-			Temperature: 0.9,
-			TopP:        0.95,
-		},
-		Proxy: config.ProxyConfig{},
+func TestGetModelClient_Fallbacks(t *testing.T) {
+	overloaded := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = fmt.Fprint(w, `{"error":"server overloaded"}`)
+	}))
+	t.Cleanup(overloaded.Close)
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"model":"qwen3","message":{"role":"assistant","content":"looks good"},"done":true,"prompt_eval_count":4,"eval_count":2}`)
+	}))
+	t.Cleanup(healthy.Close)
+
+	globalConfig = config.NewDefault()
+	globalConfig.Cache.Enabled = false
+	globalConfig.AI.Provider = "ollama"
+	globalConfig.AI.Model = "llama3"
+	globalConfig.AI.BaseURL = overloaded.URL
+	globalConfig.AI.Retry.MaxAttempts = 1
+	globalConfig.AI.Fallbacks = []config.FallbackConfig{{Model: "qwen3", BaseURL: healthy.URL}}
+
+	client, err := GetModelClient(ai.Ollama)
+	require.NoError(t, err)
+
+	resp, err := client.ChatCompletion(context.Background(), "review this")
+	require.NoError(t, err)
+	assert.Equal(t, "looks good", resp.Text)
+	assert.Equal(t, "ollama/qwen3", resp.TokenUsage.Backend)
+}
+
+func TestGetModelClient_CacheSkipsFallbackAnswers(t *testing.T) {
+	overloaded := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(overloaded.Close)
+	var hits atomic.Int32
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"model":"qwen3","message":{"role":"assistant","content":"looks good"},"done":true,"prompt_eval_count":4,"eval_count":2}`)
+	}))
+	t.Cleanup(healthy.Close)
+
+	globalConfig = config.NewDefault()
+	globalConfig.Cache.Dir = t.TempDir()
+	globalConfig.AI.Provider = "ollama"
+	globalConfig.AI.Model = "llama3"
+	globalConfig.AI.BaseURL = overloaded.URL
+	globalConfig.AI.Retry.MaxAttempts = 1
+	globalConfig.AI.Fallbacks = []config.FallbackConfig{{Model: "qwen3", BaseURL: healthy.URL}}
+
+	ask := func() {
+		client, err := GetModelClient(ai.Ollama)
+		require.NoError(t, err)
+		_, err = client.ChatCompletion(context.Background(), "review this")
+		require.NoError(t, err)
 	}
+	ask()
+	ask()
+	assert.Equal(t, int32(2), hits.Load(), "answers of the fallback are not cached for llama3")
 
-	client, err := NewGeminiClient()
-	assert.NoError(t, err)
-	assert.NotNil(t, client)
+	// once the primary answers itself, its answer is cached
+	globalConfig.AI.BaseURL = healthy.URL
+	ask()
+	ask()
+	assert.Equal(t, int32(3), hits.Load())
 }
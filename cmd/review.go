@@ -2,21 +2,26 @@ package cmd
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"io"
 	"os"
 	"strings"
 
 	"github.com/fatih/color"
 	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/git"
 	"github.com/loveRyujin/ReviewBot/pkg/progress"
 	"github.com/loveRyujin/ReviewBot/prompt"
+	"github.com/loveRyujin/ReviewBot/review"
+	"github.com/loveRyujin/ReviewBot/source"
 	"github.com/spf13/cobra"
 )
 
 const (
-	ModeLocal    = "local"
-	ModeExternal = "external"
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatSARIF    = "sarif"
 )
 
 var (
@@ -25,17 +30,39 @@ var (
 	maxInputSize int
 	outputLang   string
 	stream       bool
+	format       string
+	chunkTokens  int
+	concurrency  int
+	baseRev      string
+	headRev      string
+	revRange     string
+	perCommit    bool
+	worktree     bool
+	untracked    bool
+	githubPR     string
+	gitlabMR     string
 )
 
 func init() {
 	reviewCmd.PersistentFlags().IntVar(&diffUnifiedLines, "diff_unified", 3, "number of context lines to show in diff")
 	reviewCmd.PersistentFlags().StringArrayVar(&excludedList, "exclude_list", []string{}, "list of files to exclude from review")
 	reviewCmd.PersistentFlags().BoolVar(&amend, "amend", false, "amend the commit message")
-	reviewCmd.PersistentFlags().StringVar(&mode, "mode", ModeLocal, "mode of fetch git diff information (local or external)")
+	reviewCmd.PersistentFlags().StringVar(&mode, "mode", source.Local, "source of the diff to review ("+strings.Join(source.Sources(), ", ")+")")
 	reviewCmd.PersistentFlags().StringVar(&diffFile, "diff_file", "", "path of the diff file to be reviewed")
 	reviewCmd.PersistentFlags().IntVar(&maxInputSize, "max_input_size", 20*1024*1024, "maximum git diff input size(default: 20MB, units: bytes)")
 	reviewCmd.PersistentFlags().StringVar(&outputLang, "output_lang", "en", "output language of the review summary(default: English)")
 	reviewCmd.PersistentFlags().BoolVar(&stream, "stream", false, "enable streaming mode for AI provider")
+	reviewCmd.PersistentFlags().StringVar(&format, "format", FormatText, "output format of the review (text, markdown, json or sarif)")
+	reviewCmd.PersistentFlags().IntVar(&chunkTokens, "chunk_tokens", 12000, "token budget of one review request, larger diffs are reviewed in chunks (0 disables chunking)")
+	reviewCmd.PersistentFlags().IntVar(&concurrency, "concurrency", review.DefaultConcurrency, "maximum number of chunks reviewed in parallel")
+	reviewCmd.PersistentFlags().StringVar(&baseRev, "base", "", "review the changes since the merge base with this revision instead of the staged changes")
+	reviewCmd.PersistentFlags().StringVar(&headRev, "head", "", "head revision of the changes to review together with --base (default: HEAD)")
+	reviewCmd.PersistentFlags().StringVar(&revRange, "range", "", "revision range to review, e.g. main..HEAD or main...feature")
+	reviewCmd.PersistentFlags().BoolVar(&worktree, "worktree", false, "review unstaged working-tree changes instead of the staged changes")
+	reviewCmd.PersistentFlags().BoolVar(&untracked, "untracked", false, "include untracked files as new files in --worktree mode")
+	reviewCmd.PersistentFlags().StringVar(&githubPR, "github-pr", "", "review a GitHub pull request (owner/repo#123) and publish the findings as a PR review")
+	reviewCmd.PersistentFlags().StringVar(&gitlabMR, "gitlab-mr", "", "review a GitLab merge request (group/project!123) and publish the findings as discussions")
+	reviewCmd.PersistentFlags().BoolVar(&perCommit, "per_commit", false, "review every commit of --range or --base on its own instead of the squashed diff")
 }
 
 // reviewCmd defines the "review" command for auto-reviewing staged git code changes using AI.
@@ -49,8 +76,15 @@ var reviewCmd = &cobra.Command{
 		applyReviewOverrides()
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if ref := globalConfig.Runtime.Review.GitHubPR; ref != "" {
+			return reviewGitHubPR(cmd.Context(), ref, cmd.OutOrStdout())
+		}
+		if ref := globalConfig.Runtime.Review.GitLabMR; ref != "" {
+			return reviewGitLabMR(cmd.Context(), ref, cmd.OutOrStdout())
+		}
+
 		// generate diff info
-		diff, err := getDiffContent(args)
+		targets, err := getReviewTargets(cmd.Context(), args)
 		if err != nil {
 			return err
 		}
@@ -61,50 +95,176 @@ var reviewCmd = &cobra.Command{
 			return err
 		}
 
-		// get file diff summary prompt for code review
-		reviewPrompt, err := prompt.GetPromptTmpl(prompt.CodeReviewFileDiffTmpl, map[string]any{prompt.FileDiff: diff})
-		if err != nil {
-			return err
-		}
-
 		// get the language of output summary
 		lang := prompt.GetLanguage(globalConfig.Git.Lang)
 
-		color.Cyan("We are trying to review code changes")
-
-		return executeReview(cmd.Context(), aiModelClient, reviewPrompt, lang)
+		outputFormat := globalConfig.Runtime.Review.Format
+		switch outputFormat {
+		case FormatJSON, FormatSARIF:
+			return executeStructuredReviews(cmd.Context(), aiModelClient, targets, lang, outputFormat, cmd.OutOrStdout())
+		case "", FormatText, FormatMarkdown:
+			for _, target := range targets {
+				if err := reviewTarget(cmd.Context(), aiModelClient, target, lang, outputFormat, cmd.OutOrStdout()); err != nil {
+					return err
+				}
+			}
+			return nil
+		default:
+			return fmt.Errorf("invalid output format %q, please use 'text', 'markdown', 'json' or 'sarif'", outputFormat)
+		}
 	},
 }
 
-// getDiffContent get git diff content
-func getDiffContent(args []string) (string, error) {
-	var gitDiffContent string
-	var err error
+// reviewDiff is one diff to review. Commit is set when a range is reviewed
+// commit by commit.
+type reviewDiff struct {
+	Commit git.CommitInfo
+	Diff   string
+}
 
-	switch mode {
-	case ModeLocal:
-		g := globalConfig.GitCommandConfig().New()
-		gitDiffContent, err = g.DiffFiles()
-	case ModeExternal:
-		if len(args) != 0 { // get git diff content from arguments
-			if len(args[0]) >= globalConfig.Git.MaxInputSize {
-				return "", errors.New("git diff input size exceeds limit")
-			}
-			gitDiffContent = args[0]
-		} else if diffFile != "" { // get git diff content from file
-			gitDiffContent, err = processFileInput(globalConfig.Git.DiffFile)
-		} else { // get git diff content from stdin
-			gitDiffContent, err = processStdinInput()
+// title returns the heading of the review.
+func (d reviewDiff) title() string {
+	if d.Commit.Hash == "" {
+		return "Code Review"
+	}
+	return fmt.Sprintf("Code Review: %s %s", d.Commit.ShortHash(), d.Commit.Subject)
+}
+
+// getReviewTargets returns the diffs to review: the staged or external diff
+// by default, the unstaged changes in worktree mode, otherwise the
+// merge-base diff of the selected revisions, either squashed or split per
+// commit.
+func getReviewTargets(ctx context.Context, args []string) ([]reviewDiff, error) {
+	rc := globalConfig.Runtime.Review
+	if err := rc.Validate(); err != nil {
+		return nil, fmt.Errorf("review: %w", err)
+	}
+
+	if rc.Worktree {
+		diff, err := globalConfig.GitCommandConfig().New().DiffWorktree(rc.Untracked, globalConfig.Git.MaxInputSize)
+		if err != nil {
+			return nil, err
 		}
+		return []reviewDiff{{Diff: diff}}, nil
+	}
 
-		if len(gitDiffContent) == 0 {
-			return "", errors.New("please provide the diff content to review")
+	base, head := rc.Base, rc.Head
+	if rc.Range != "" {
+		var err error
+		base, head, err = git.ParseRange(rc.Range)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if base == "" {
+		diff, err := getDiffContent(ctx, args)
+		if err != nil {
+			return nil, err
 		}
-	default:
-		return "", errors.New("invalid input mode, please use 'local' or 'external'")
+		return []reviewDiff{{Diff: diff}}, nil
 	}
 
-	return gitDiffContent, err
+	g := globalConfig.GitCommandConfig().New()
+	if !rc.PerCommit {
+		diff, err := g.DiffRange(base, head)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkInputSize(diff); err != nil {
+			return nil, err
+		}
+		return []reviewDiff{{Diff: diff}}, nil
+	}
+
+	commits, err := g.RangeCommits(base, head)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]reviewDiff, 0, len(commits))
+	for _, c := range commits {
+		diff, err := g.DiffCommit(c.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if diff == "" { // the commit only touches excluded files
+			continue
+		}
+		if err := checkInputSize(diff); err != nil {
+			return nil, fmt.Errorf("commit %s: %w", c.ShortHash(), err)
+		}
+		targets = append(targets, reviewDiff{Commit: c, Diff: diff})
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no changes to review between %s and %s", base, head)
+	}
+	return targets, nil
+}
+
+// reviewTarget reviews a single diff in text or markdown format.
+func reviewTarget(ctx context.Context, client ai.TextGenerator, target reviewDiff, lang, outputFormat string, w io.Writer) error {
+	// make sure the prompt fits the model context before sending it
+	fit, err := fitDiff(target.Diff, prompt.CodeReviewFileDiffTmpl, map[string]any{}, globalConfig.Runtime.Review.ChunkTokens)
+	if err != nil {
+		return err
+	}
+	quiet := outputFormat == FormatMarkdown
+	if quiet {
+		reportFit(fit, os.Stderr)
+	} else {
+		reportFit(fit, nil)
+	}
+	diff, chunks := fit.Diff, fit.Chunks
+
+	opts := review.ChunkOptions{
+		Concurrency: globalConfig.Runtime.Review.Concurrency,
+		Lang:        lang,
+	}
+
+	var reviewPrompt string
+	if len(chunks) > 1 {
+		// review each chunk on its own and let the final pass merge the results
+		reviewPrompt, err = reviewInChunks(ctx, client, chunks, opts, quiet)
+	} else {
+		// get file diff summary prompt for code review
+		reviewPrompt, err = prompt.GetPromptTmpl(prompt.CodeReviewFileDiffTmpl, map[string]any{prompt.FileDiff: diff})
+	}
+	if err != nil {
+		return err
+	}
+
+	if quiet {
+		return executeMarkdownReview(ctx, client, reviewPrompt, lang, target.title(), w)
+	}
+	if target.Commit.Hash != "" {
+		color.Cyan("We are trying to review commit %s %s", target.Commit.ShortHash(), target.Commit.Subject)
+	} else {
+		color.Cyan("We are trying to review code changes")
+	}
+	return executeReview(ctx, client, reviewPrompt, lang)
+}
+
+// checkInputSize rejects a diff reaching the configured input size limit,
+// as source.Read does for the staged and external diffs.
+func checkInputSize(diff string) error {
+	if maxSize := globalConfig.Git.MaxInputSize; maxSize > 0 && len(diff) >= maxSize {
+		return source.ErrInputTooLarge
+	}
+	return nil
+}
+
+// getDiffContent reads the diff from the source selected with --mode.
+func getDiffContent(ctx context.Context, args []string) (string, error) {
+	name := globalConfig.Runtime.Review.Mode
+	if name == "" {
+		name = source.Local
+	}
+	return source.Read(ctx, name, source.Input{
+		Args:     args,
+		DiffFile: globalConfig.Git.DiffFile,
+		Stdin:    os.Stdin,
+		Git:      globalConfig.GitCommandConfig().New(),
+		MaxSize:  globalConfig.Git.MaxInputSize,
+	})
 }
 
 // executeReview perform code review and process output
@@ -169,10 +329,16 @@ func streamOutput(ctx context.Context, client ai.TextGenerator, reviewPrompt str
 		return nil
 	}
 
-	if err := client.StreamChatCompletion(ctx, reviewPrompt, chunkHandler); err != nil {
+	color.Yellow("================Review Summary====================" + "\n\n")
+
+	tokenUsage, err := client.StreamChatCompletion(ctx, reviewPrompt, chunkHandler)
+	if err != nil {
 		return err
 	}
 
+	color.Yellow("\n" + "==================================================")
+	color.Magenta(tokenUsage.String())
+
 	return nil
 }
 
@@ -192,23 +358,16 @@ func streamTranslation(ctx context.Context, client ai.TextGenerator, content str
 
 // translateContent translates the given content into the specified language using the provided AI text generator.
 func translateContent(ctx context.Context, client ai.TextGenerator, content string, lang string) (string, error) {
-	instruction, err := prompt.GetPromptTmpl(prompt.TranslationTmpl, map[string]any{
-		prompt.OutputLang:    lang,
-		prompt.OutputMessage: content,
-	})
-	if err != nil {
-		return "", err
-	}
-
 	color.Cyan("We are trying to translate the code review summary to " + lang)
 
 	var resp *ai.Response
+	var err error
 	spinnerErr := progress.WithSpinnerAndCustomMessages(
 		"🌐 Translating review summary...",
 		"Translation completed",
 		"Failed to translate review summary",
 		func() error {
-			resp, err = client.ChatCompletion(ctx, instruction)
+			resp, err = translate(ctx, client, content, lang)
 			return err
 		},
 	)
@@ -220,81 +379,184 @@ func translateContent(ctx context.Context, client ai.TextGenerator, content stri
 	return resp.Text, nil
 }
 
-// processFileInput reads the diff content from a file
-func processFileInput(filepath string) (string, error) {
-	file, err := os.Open(filepath)
+// translate asks the model to translate content without any terminal output.
+func translate(ctx context.Context, client ai.TextGenerator, content string, lang string) (*ai.Response, error) {
+	instruction, err := prompt.GetPromptTmpl(prompt.TranslationTmpl, map[string]any{
+		prompt.OutputLang:    lang,
+		prompt.OutputMessage: content,
+	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer func() {
-		_ = file.Close()
-	}()
 
-	allowed, err := checkFileSize(file)
-	if err != nil {
-		return "", err
+	return client.ChatCompletion(ctx, instruction)
+}
+
+// executeMarkdownReview writes the review as plain Markdown to w. Progress and
+// token usage go to stderr so that stdout can be redirected into a file.
+func executeMarkdownReview(ctx context.Context, client ai.TextGenerator, reviewPrompt string, lang string, title string, w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "# %s\n\n", title); err != nil {
+		return err
 	}
-	if !allowed {
-		return "", errors.New("git diff input size exceeds limit")
+
+	var usage ai.TokenUsage
+	if stream && lang == prompt.DefaultLanguage {
+		var err error
+		usage, err = client.StreamChatCompletion(ctx, reviewPrompt, func(chunk string) error {
+			_, err := io.WriteString(w, chunk)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	} else {
+		resp, err := client.ChatCompletion(ctx, reviewPrompt)
+		if err != nil {
+			return err
+		}
+		summary := resp.Text
+		usage = resp.TokenUsage
+
+		if lang != prompt.DefaultLanguage {
+			translated, err := translate(ctx, client, summary, lang)
+			if err != nil {
+				return err
+			}
+			summary, usage = translated.Text, usage.Add(translated.TokenUsage)
+		}
+
+		if _, err := fmt.Fprintln(w, strings.TrimSpace(summary)); err != nil {
+			return err
+		}
 	}
 
-	diff, err := processInput(file)
+	_, err := fmt.Fprintf(w, "\n---\n_Reviewed by ReviewBot using %s/%s_\n", globalConfig.AI.Provider, globalConfig.AI.Model)
 	if err != nil {
-		return "", err
+		return err
 	}
-
-	return diff, nil
+	_, _ = color.New(color.FgMagenta).Fprintln(os.Stderr, usage.String())
+	return nil
 }
 
-// processStdinInput reads the diff content from stdin
-func processStdinInput() (string, error) {
-	if !hasStdinInput() {
-		return "", nil
+// executeStructuredReviews requests structured findings for every target and
+// writes them to w as JSON or, for the sarif format, as a SARIF log with one
+// run per target anchored to its diff. Several JSON reports form an array.
+func executeStructuredReviews(ctx context.Context, client ai.TextGenerator, targets []reviewDiff, lang, outputFormat string, w io.Writer) error {
+	runs := make([]review.SARIFRun, 0, len(targets))
+	for _, target := range targets {
+		if target.Commit.Hash != "" {
+			_, _ = fmt.Fprintf(os.Stderr, "reviewing commit %s %s\n", target.Commit.ShortHash(), target.Commit.Subject)
+		}
+
+		report, diff, err := structuredReview(ctx, client, target.Diff, lang)
+		if err != nil {
+			return err
+		}
+		report.Commit = target.Commit.Hash
+		runs = append(runs, review.SARIFRun{Report: report, Diff: diff})
 	}
 
-	allowed, err := checkFileSize(os.Stdin)
-	if err != nil {
-		return "", err
+	if outputFormat == FormatSARIF {
+		return review.WriteSARIFRuns(w, runs...)
 	}
-	if !allowed {
-		return "", errors.New("git diff input size exceeds limit")
+	if len(runs) == 1 {
+		return runs[0].Report.WriteJSON(w)
 	}
+	reports := make([]*review.Report, 0, len(runs))
+	for _, run := range runs {
+		reports = append(reports, run.Report)
+	}
+	return review.WriteJSONReports(w, reports)
+}
 
-	diff, err := processInput(os.Stdin)
+// structuredReview fits diff into the model context and reviews it into a
+// report. It also returns the diff that was actually reviewed.
+func structuredReview(ctx context.Context, client ai.TextGenerator, diff string, lang string) (*review.Report, string, error) {
+	// make sure the prompt fits the model context before sending it
+	fit, err := fitDiff(diff, prompt.CodeReviewJSONTmpl, map[string]any{prompt.OutputLang: lang}, globalConfig.Runtime.Review.ChunkTokens)
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
+	reportFit(fit, os.Stderr)
 
-	return diff, nil
+	opts := review.ChunkOptions{
+		Concurrency: globalConfig.Runtime.Review.Concurrency,
+		Lang:        lang,
+	}
+	report, err := structuredReport(ctx, client, fit.Diff, fit.Chunks, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	return report, fit.Diff, nil
 }
 
-// hasStdinInput checks if there is input from stdin.
-func hasStdinInput() bool {
-	fi, err := os.Stdin.Stat()
+// structuredReport reviews diff, or its chunks, into a report.
+func structuredReport(ctx context.Context, client ai.TextGenerator, diff string, chunks []review.Chunk, opts review.ChunkOptions) (*review.Report, error) {
+	var result *review.Result
+	var usage ai.TokenUsage
+	var err error
+	if len(chunks) > 1 {
+		opts.OnProgress = chunkProgress(os.Stderr)
+		result, usage, err = review.StructuredChunks(ctx, client, chunks, opts)
+	} else {
+		result, usage, err = review.Structured(ctx, client, diff, opts.Lang)
+	}
 	if err != nil {
-		cobra.CheckErr(err)
+		return nil, err
 	}
-
-	return (fi.Mode() & os.ModeCharDevice) == 0
+	return review.NewReport(globalConfig.AI.Provider, globalConfig.AI.Model, result, usage), nil
 }
 
-func processInput(r io.Reader) (string, error) {
-	diffContent, err := io.ReadAll(r)
-	return string(diffContent), err
-}
+// reviewInChunks reviews every chunk separately and returns the prompt of the
+// aggregation pass. With quiet set, progress goes to stderr instead of a spinner.
+func reviewInChunks(ctx context.Context, client ai.TextGenerator, chunks []review.Chunk, opts review.ChunkOptions, quiet bool) (string, error) {
+	var reviews []string
+	var usage ai.TokenUsage
+	var err error
 
-// checkFileSize checks if the file size is valid(less than 20MB).
-func checkFileSize(f *os.File) (bool, error) {
-	stat, err := f.Stat()
-	if err != nil {
-		return false, err
+	if quiet {
+		opts.OnProgress = chunkProgress(os.Stderr)
+		reviews, usage, err = review.ReviewChunks(ctx, client, chunks, opts)
+		if err != nil {
+			return "", err
+		}
+		_, _ = color.New(color.FgMagenta).Fprintln(os.Stderr, usage.String())
+	} else {
+		color.Cyan("The diff is too large for one request, reviewing it in %d chunks", len(chunks))
+
+		s := progress.NewSpinner(fmt.Sprintf("🤖 Analyzing code changes (0/%d)...", len(chunks)))
+		opts.OnProgress = func(done, total int) {
+			s.UpdateMessage(fmt.Sprintf("🤖 Analyzing code changes (%d/%d)...", done, total))
+		}
+		s.Start()
+		reviews, usage, err = review.ReviewChunks(ctx, client, chunks, opts)
+		if err != nil {
+			s.Error("Failed to analyze code changes")
+			return "", err
+		}
+		s.Success("Code analysis completed")
+		color.Magenta(usage.String())
+		color.Cyan("We are trying to merge the chunk reviews")
 	}
 
-	if stat.Size() >= int64(globalConfig.Git.MaxInputSize) {
-		return false, nil
+	aggregate, err := review.AggregatePrompt(chunks, reviews)
+	if err != nil {
+		return "", err
+	}
+	if err := checkPrompt(aggregate); err != nil {
+		return "", fmt.Errorf("merge chunk reviews: %w", err)
 	}
+	return aggregate, nil
+}
 
-	return true, nil
+// chunkProgress reports chunk completion as plain lines on w.
+func chunkProgress(w io.Writer) func(done, total int) {
+	return func(done, total int) {
+		_, _ = fmt.Fprintf(w, "reviewed chunk %d/%d\n", done, total)
+	}
 }
 
 // applyReviewOverrides applies command-line flags to the global configuration
@@ -323,9 +585,45 @@ func applyReviewOverrides() {
 	if stream {
 		globalConfig.Runtime.Review.Stream = true
 	}
+	if format != FormatText {
+		globalConfig.Runtime.Review.Format = format
+	}
+	if chunkTokens != 12000 {
+		globalConfig.Runtime.Review.ChunkTokens = chunkTokens
+	}
+	if concurrency != review.DefaultConcurrency {
+		globalConfig.Runtime.Review.Concurrency = concurrency
+	}
+	if baseRev != "" {
+		globalConfig.Runtime.Review.Base = baseRev
+	}
+	if headRev != "" {
+		globalConfig.Runtime.Review.Head = headRev
+	}
+	if revRange != "" {
+		globalConfig.Runtime.Review.Range = revRange
+	}
+	if perCommit {
+		globalConfig.Runtime.Review.PerCommit = true
+	}
+	if worktree {
+		globalConfig.Runtime.Review.Worktree = true
+	}
+	if untracked {
+		globalConfig.Runtime.Review.Untracked = true
+	}
+	if githubPR != "" {
+		globalConfig.Runtime.Review.GitHubPR = githubPR
+	}
+	if gitlabMR != "" {
+		globalConfig.Runtime.Review.GitLabMR = gitlabMR
+	}
 	if aiProviderFlag != "" {
 		globalConfig.AI.Provider = aiProviderFlag
 	}
+	if noCache {
+		globalConfig.Cache.Enabled = false
+	}
 	if aiModelFlag != "" {
 		globalConfig.AI.Model = aiModelFlag
 	}
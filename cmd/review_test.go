@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureStderr returns what fn writes to os.Stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "stderr")
+	require.NoError(t, err)
+	defer f.Close()
+
+	orig := os.Stderr
+	os.Stderr = f
+	defer func() { os.Stderr = orig }()
+	fn()
+
+	out, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestExecuteMarkdownReview_Usage(t *testing.T) {
+	globalConfig = config.NewDefault()
+	client := chatFunc(func(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+		if strings.Contains(req.Messages[0].Content, "Looks fine.") {
+			return reply("看起来不错。"), nil
+		}
+		return reply("Looks fine."), nil
+	})
+
+	var out bytes.Buffer
+	stderr := captureStderr(t, func() {
+		require.NoError(t, executeMarkdownReview(context.Background(), client, "review this", "English", "Review", &out))
+	})
+	assert.Contains(t, out.String(), "Looks fine.")
+	assert.Contains(t, stderr, ai.TokenUsage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12}.String())
+
+	out.Reset()
+	stderr = captureStderr(t, func() {
+		require.NoError(t, executeMarkdownReview(context.Background(), client, "review this", "Simplified Chinese", "Review", &out))
+	})
+	assert.Contains(t, out.String(), "看起来不错。")
+	assert.Contains(t, stderr, ai.TokenUsage{PromptTokens: 20, CompletionTokens: 4, TotalTokens: 24}.String(), "the translation is counted")
+}
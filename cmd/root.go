@@ -21,6 +21,8 @@ var (
 	replacer         = strings.NewReplacer(".", "_", "-", "_")
 	aiProviderFlag   string
 	aiModelFlag      string
+	profileFlag      string
+	noCache          bool
 
 	defaultConfigDir  = ".config/reviewbot"
 	defaultConfigFile = "reviewbot.yaml"
@@ -44,10 +46,13 @@ func init() {
 	rootCmd.AddCommand(reviewCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(commitCmd)
+	rootCmd.AddCommand(cacheCmd)
 
 	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "config file path")
 	rootCmd.PersistentFlags().StringVar(&aiProviderFlag, "ai-provider", "", "AI provider to use for requests")
 	rootCmd.PersistentFlags().StringVar(&aiModelFlag, "ai-model", "", "AI model identifier to use")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "named AI profile from ai.profiles to use")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "do not read or write the response cache")
 
 	version.AddFlags(rootCmd.Flags())
 
@@ -77,6 +82,9 @@ func initConfig() error {
 		ConfigType:   "yaml",
 		EnvPrefix:    defaultEnvPrefix,
 		Replacer:     replacer,
+		Overrides: config.Overrides{
+			AI: config.AIOverrides{Profile: profileFlag},
+		},
 	})
 	if err != nil {
 		return err
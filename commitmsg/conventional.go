@@ -0,0 +1,149 @@
+package commitmsg
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/loveRyujin/ReviewBot/git"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+)
+
+// BreakingToken starts the footer describing a breaking change.
+const BreakingToken = "BREAKING-CHANGE"
+
+// ErrNotConventional is returned when a commit message does not follow the
+// Conventional Commits grammar.
+var ErrNotConventional = errors.New("commit message does not follow Conventional Commits")
+
+var (
+	// header is "type(scope)!: description"; scope and "!" are optional.
+	header = regexp.MustCompile(`^([A-Za-z][\w-]*)(\(([^()\r\n]*)\))?(!)?: (\S.*)$`)
+	// footer is "Token: value" or "Token #value"; only BREAKING CHANGE may
+	// contain a space.
+	footer = regexp.MustCompile(`^(BREAKING CHANGE|[\w-]+)(?:: | #)\S`)
+	// breakingLine marks a breaking change in the diff summary, such as
+	// "- BREAKING: Remove the --fast flag" or "BREAKING CHANGE: ...".
+	breakingLine = regexp.MustCompile(`(?i)^\s*(?:[-*]\s*)?\**breaking(?:[ -]change)?\**\s*:\**\s*(.+)$`)
+	wordChars    = regexp.MustCompile(`[A-Za-z]+`)
+)
+
+// ParseLabel reads the commit type from the answer to the label prompt. The
+// answer may carry a scope, as in "feat(cli)", and stray punctuation; when
+// it is a sentence, the first known type in it is used.
+func ParseLabel(label string) (Message, error) {
+	s := strings.ToLower(firstLine(label))
+	s = strings.Trim(s, " \t`'\"*.:")
+	if sub := typeWithScope.FindStringSubmatch(s); sub != nil {
+		return Message{Type: sub[1], Scope: strings.TrimSpace(sub[2])}, nil
+	}
+	if slices.Contains(Types, s) {
+		return Message{Type: s}, nil
+	}
+	for _, word := range wordChars.FindAllString(s, -1) {
+		if slices.Contains(Types, word) {
+			return Message{Type: word}, nil
+		}
+	}
+	return Message{}, fmt.Errorf("%w: unknown type %q", ErrInvalid, firstLine(label))
+}
+
+// ParseHeader splits the first line of a Conventional Commits message. A
+// header marked with "!" is breaking and the title describes the change.
+func ParseHeader(line string) (Message, error) {
+	sub := header.FindStringSubmatch(strings.TrimSpace(line))
+	if sub == nil {
+		return Message{}, fmt.Errorf("%w: header must look like \"type(scope)!: title\"", ErrNotConventional)
+	}
+	m := Message{Type: sub[1], Scope: strings.TrimSpace(sub[3]), Title: strings.TrimSpace(sub[5])}
+	if sub[2] != "" && m.Scope == "" {
+		return Message{}, fmt.Errorf("%w: empty scope", ErrNotConventional)
+	}
+	if sub[4] != "" {
+		m.Breaking = []string{m.Title}
+	}
+	return m, nil
+}
+
+// InferScope returns the scope of the first rule matching each changed
+// path when all of them agree. Paths matched by no rule, or by rules with
+// different scopes, leave the scope empty.
+func InferScope(paths []string, rules []config.ScopeRule) string {
+	scope := ""
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		s := matchScope(p, rules)
+		if s == "" || (scope != "" && s != scope) {
+			return ""
+		}
+		scope = s
+	}
+	return scope
+}
+
+func matchScope(path string, rules []config.ScopeRule) string {
+	for _, r := range rules {
+		if git.MatchExclude(r.Pattern, path) {
+			return r.Scope
+		}
+	}
+	return ""
+}
+
+// SplitBreaking removes the lines marking breaking changes from a diff
+// summary and returns their descriptions separately.
+func SplitBreaking(summary string) (string, []string) {
+	var kept, breaking []string
+	for _, line := range strings.Split(summary, "\n") {
+		if sub := breakingLine.FindStringSubmatch(line); sub != nil {
+			if desc := strings.TrimSpace(strings.Trim(sub[1], "* ")); desc != "" {
+				breaking = append(breaking, desc)
+			}
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n")), breaking
+}
+
+// Validate checks text against the Conventional Commits grammar: a
+// "type(scope)!: description" header, a blank line before the body, and
+// breaking changes declared in the footer with an upper-case token.
+func Validate(text string) error {
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+	if _, err := ParseHeader(lines[0]); err != nil {
+		return err
+	}
+	if strings.TrimSpace(lines[0]) != lines[0] {
+		return fmt.Errorf("%w: header has leading or trailing whitespace", ErrNotConventional)
+	}
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		return fmt.Errorf("%w: a blank line must separate the header from the body", ErrNotConventional)
+	}
+
+	for i := 2; i < len(lines); i++ {
+		token, _, ok := strings.Cut(lines[i], ":")
+		if !ok || !isBreakingToken(token) {
+			continue
+		}
+		if token != "BREAKING CHANGE" && token != BreakingToken {
+			return fmt.Errorf("%w: line %d: write the breaking change token as %q", ErrNotConventional, i+1, BreakingToken)
+		}
+		if !footer.MatchString(lines[i]) {
+			return fmt.Errorf("%w: line %d: the breaking change needs a description", ErrNotConventional, i+1)
+		}
+		if prev := lines[i-1]; strings.TrimSpace(prev) != "" && !footer.MatchString(prev) {
+			return fmt.Errorf("%w: line %d: footers must follow a blank line", ErrNotConventional, i+1)
+		}
+	}
+	return nil
+}
+
+func isBreakingToken(token string) bool {
+	t := strings.ToUpper(strings.TrimSpace(token))
+	return t == "BREAKING CHANGE" || t == BreakingToken
+}
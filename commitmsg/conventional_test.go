@@ -0,0 +1,116 @@
+package commitmsg
+
+import (
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLabel(t *testing.T) {
+	tests := []struct {
+		label string
+		want  Message
+	}{
+		{label: "feat\n", want: Message{Type: "feat"}},
+		{label: "`Fix`.", want: Message{Type: "fix"}},
+		{label: "refactor(config)", want: Message{Type: "refactor", Scope: "config"}},
+		{label: "The best label is docs.", want: Message{Type: "docs"}},
+	}
+	for _, tt := range tests {
+		m, err := ParseLabel(tt.label)
+		require.NoError(t, err, tt.label)
+		assert.Equal(t, tt.want, m, tt.label)
+	}
+
+	_, err := ParseLabel("improvement")
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestParseHeader(t *testing.T) {
+	m, err := ParseHeader("feat(cli)!: Rename --fast")
+	require.NoError(t, err)
+	assert.Equal(t, Message{Type: "feat", Scope: "cli", Title: "Rename --fast", Breaking: []string{"Rename --fast"}}, m)
+
+	m, err = ParseHeader(" fix: Handle empty diffs ")
+	require.NoError(t, err)
+	assert.Equal(t, Message{Type: "fix", Title: "Handle empty diffs"}, m)
+
+	for _, line := range []string{"Handle empty diffs", "fix:Handle", "fix: ", "fix(): Handle", "fix(a)(b): Handle", "fix !: Handle"} {
+		_, err := ParseHeader(line)
+		assert.ErrorIs(t, err, ErrNotConventional, line)
+	}
+}
+
+func TestInferScope(t *testing.T) {
+	rules := []config.ScopeRule{
+		{Pattern: "cmd/", Scope: "cli"},
+		{Pattern: "**/*.md", Scope: "docs"},
+		{Pattern: "pkg/config/", Scope: "config"},
+		{Pattern: "config.example.yaml", Scope: "config"},
+	}
+
+	assert.Equal(t, "cli", InferScope([]string{"cmd/commit.go", "cmd/root.go"}, rules))
+	assert.Equal(t, "cli", InferScope([]string{"cmd/README.md"}, rules), "the first matching rule wins")
+	assert.Equal(t, "config", InferScope([]string{"pkg/config/config.go", "config.example.yaml"}, rules))
+	assert.Equal(t, "docs", InferScope([]string{"README.md", "docs/usage.md", ""}, rules))
+	assert.Empty(t, InferScope([]string{"cmd/commit.go", "README.md"}, rules), "scopes disagree")
+	assert.Empty(t, InferScope([]string{"cmd/commit.go", "go.mod"}, rules), "unmatched path")
+	assert.Empty(t, InferScope([]string{"cmd/commit.go"}, nil))
+}
+
+func TestSplitBreaking(t *testing.T) {
+	body, breaking := SplitBreaking("- Add profiles\n- BREAKING: Remove the ai.openai section\n- **Breaking change:** Rename --fast\n- Mention breaking changes in the docs")
+	assert.Equal(t, "- Add profiles\n- Mention breaking changes in the docs", body)
+	assert.Equal(t, []string{"Remove the ai.openai section", "Rename --fast"}, breaking)
+
+	body, breaking = SplitBreaking("- Add profiles")
+	assert.Equal(t, "- Add profiles", body)
+	assert.Empty(t, breaking)
+}
+
+func TestParse_Breaking(t *testing.T) {
+	m, err := Parse(`{"type":"feat","title":"Rename --fast","body":["Rename the flag","BREAKING: --fast is now --single"],"breaking":"Scripts must use --single"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "- Rename the flag", m.Body)
+	assert.Equal(t, []string{"Scripts must use --single", "--fast is now --single"}, m.Breaking)
+	assert.Equal(t, "feat!: Rename --fast", m.Header())
+
+	m, err = Parse(`{"type":"fix","title":"Handle empty diffs","breaking":["", "None."]}`)
+	require.NoError(t, err)
+	assert.Empty(t, m.Breaking)
+}
+
+func TestValidate(t *testing.T) {
+	valid := []string{
+		"fix: Handle empty diffs",
+		"feat(cli)!: Rename --fast\n\n- Rename the flag\n\nBREAKING-CHANGE: --fast is now --single\n",
+		"feat!: Drop v1\n\nBREAKING CHANGE: v1 files are rejected\nRefs: #12",
+		"docs: Describe breaking changes\n\nExplain what a breaking change is: anything that needs users to act.",
+		"fix(配置): 修复空差异\n\n- 跳过空差异",
+	}
+	for _, text := range valid {
+		assert.NoError(t, Validate(text), text)
+	}
+
+	invalid := map[string]string{
+		"Handle empty diffs":                              "header must look like",
+		"fix: Handle empty diffs\nsecond line":            "blank line must separate",
+		"feat!: Drop v1\n\nbreaking change: v1 is gone":   `write the breaking change token as "BREAKING-CHANGE"`,
+		"feat!: Drop v1\n\nBREAKING-CHANGE:":              "needs a description",
+		"feat!: Drop v1\n\n- Drop\nBREAKING-CHANGE: gone": "footers must follow a blank line",
+		"修复: 空差异":                                         "header must look like",
+	}
+	for text, want := range invalid {
+		err := Validate(text)
+		assert.ErrorIs(t, err, ErrNotConventional, text)
+		assert.ErrorContains(t, err, want, text)
+	}
+}
+
+func TestMessage_Footer(t *testing.T) {
+	m := Message{Type: "feat", Title: "Drop v1", Breaking: []string{"v1 files are rejected", "v1 flags are gone"}}
+	assert.Equal(t, "BREAKING-CHANGE: v1 files are rejected\nBREAKING-CHANGE: v1 flags are gone", m.Footer())
+	assert.Empty(t, Message{Type: "fix"}.Footer())
+}
@@ -0,0 +1,178 @@
+// Package commitmsg models Conventional Commits messages, parses them from
+// model responses and validates the final text.
+package commitmsg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/loveRyujin/ReviewBot/pkg/jsonx"
+)
+
+// Types are the commit labels offered to the model.
+var Types = []string{"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "style", "test"}
+
+// ErrInvalid is returned when a response parses but does not describe a
+// usable commit message.
+var ErrInvalid = errors.New("commitmsg: invalid message")
+
+var typeWithScope = regexp.MustCompile(`^(\w+)\(([^)]*)\)$`)
+
+// Message is the structured answer expected from the model.
+type Message struct {
+	Type  string `json:"type"`
+	Scope string `json:"scope"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// Breaking describes the incompatible changes, one BREAKING-CHANGE
+	// footer each; any entry marks the header with "!".
+	Breaking []string `json:"breaking"`
+}
+
+// Prefix returns the part of the first line before the title, e.g.
+// "feat(cli)!" or "fix".
+func (m Message) Prefix() string {
+	prefix := m.Type
+	if m.Scope != "" {
+		prefix += "(" + m.Scope + ")"
+	}
+	if len(m.Breaking) > 0 {
+		prefix += "!"
+	}
+	return prefix
+}
+
+// Header returns the first line of the message.
+func (m Message) Header() string {
+	return m.Prefix() + ": " + m.Title
+}
+
+// Footer returns the BREAKING-CHANGE footers, one per line.
+func (m Message) Footer() string {
+	lines := make([]string, 0, len(m.Breaking))
+	for _, b := range m.Breaking {
+		lines = append(lines, BreakingToken+": "+b)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Parse extracts a Message from a model response. Like review.Parse it
+// tolerates code fences, surrounding prose and trailing commas; it also
+// accepts a body given as an array of lines and a scope given inside the
+// type, as in "feat(cli)". Breaking changes may be given as a string or an
+// array, or as "BREAKING:" lines of the body.
+func Parse(text string) (*Message, error) {
+	payload, err := jsonx.Extract(text)
+	if err != nil {
+		return nil, fmt.Errorf("commitmsg: %w", err)
+	}
+
+	var raw struct {
+		Type     string          `json:"type"`
+		Scope    string          `json:"scope"`
+		Title    string          `json:"title"`
+		Body     json.RawMessage `json:"body"`
+		Breaking json.RawMessage `json:"breaking"`
+	}
+	if err := jsonx.Unmarshal(payload, &raw); err != nil {
+		return nil, fmt.Errorf("commitmsg: decode response: %w", err)
+	}
+
+	m := &Message{
+		Type:  strings.ToLower(strings.TrimSpace(raw.Type)),
+		Scope: strings.TrimSpace(raw.Scope),
+		Title: firstLine(raw.Title),
+	}
+	if sub := typeWithScope.FindStringSubmatch(m.Type); sub != nil {
+		m.Type = sub[1]
+		if m.Scope == "" {
+			m.Scope = strings.TrimSpace(sub[2])
+		}
+	}
+	m.Title = strings.TrimSuffix(m.Title, ".")
+	if m.Body, err = decodeBody(raw.Body); err != nil {
+		return nil, fmt.Errorf("commitmsg: decode body: %w", err)
+	}
+	if m.Breaking, err = decodeBreaking(raw.Breaking); err != nil {
+		return nil, fmt.Errorf("commitmsg: decode breaking: %w", err)
+	}
+	body, breaking := SplitBreaking(m.Body)
+	m.Body = body
+	m.Breaking = append(m.Breaking, breaking...)
+
+	if !slices.Contains(Types, m.Type) {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalid, raw.Type)
+	}
+	if m.Title == "" {
+		return nil, fmt.Errorf("%w: empty title", ErrInvalid)
+	}
+	return m, nil
+}
+
+// decodeBody accepts the body as a string or as an array of lines, which
+// become a bullet point list.
+func decodeBody(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s), nil
+	}
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "- ") {
+			line = "- " + strings.TrimPrefix(line, "-")
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(line)
+	}
+	return b.String(), nil
+}
+
+// decodeBreaking accepts breaking changes as a string or an array of
+// strings; empty entries and the usual "none" answers are dropped.
+func decodeBreaking(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var list []string
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		list = []string{s}
+	} else if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, err
+	}
+	var breaking []string
+	for _, b := range list {
+		b = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(b), "-"))
+		switch strings.ToLower(strings.TrimSuffix(b, ".")) {
+		case "", "none", "n/a", "no":
+			continue
+		}
+		breaking = append(breaking, b)
+	}
+	return breaking, nil
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
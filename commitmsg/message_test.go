@@ -0,0 +1,68 @@
+package commitmsg
+
+import (
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/pkg/jsonx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want Message
+	}{
+		{
+			name: "plain object",
+			text: `{"type":"feat","scope":"cli","title":"Add fast commit mode","body":"- Generate the message in one request"}`,
+			want: Message{Type: "feat", Scope: "cli", Title: "Add fast commit mode", Body: "- Generate the message in one request"},
+		},
+		{
+			name: "code fence and prose",
+			text: "Here is the commit message:\n```json\n{\"type\": \"Fix\", \"scope\": \"\", \"title\": \"Handle empty diffs.\", \"body\": \"- Skip empty diffs\"}\n```\nLet me know!",
+			want: Message{Type: "fix", Title: "Handle empty diffs", Body: "- Skip empty diffs"},
+		},
+		{
+			name: "trailing comma and body lines",
+			text: `{"type":"docs","title":"Document profiles","body":["Explain profiles","- Show config use",""],}`,
+			want: Message{Type: "docs", Title: "Document profiles", Body: "- Explain profiles\n- Show config use"},
+		},
+		{
+			name: "scope inside type",
+			text: `{"type":"refactor(config)","title":"Split the loader\nsecond line"}`,
+			want: Message{Type: "refactor", Scope: "config", Title: "Split the loader"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(tt.text)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *m)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse("feat: Add fast commit mode")
+	assert.ErrorIs(t, err, jsonx.ErrNoJSON)
+
+	_, err = Parse(`{"type":"feature","title":"Add it"}`)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, err = Parse(`{"type":"feat","title":"  "}`)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, err = Parse(`{"type":"feat","title":"Add it","body":{"x":1}}`)
+	assert.ErrorContains(t, err, "decode body")
+
+	_, err = Parse(`{"type":"feat","title":`)
+	assert.Error(t, err)
+}
+
+func TestMessage_Prefix(t *testing.T) {
+	assert.Equal(t, "feat(cli)", Message{Type: "feat", Scope: "cli"}.Prefix())
+	assert.Equal(t, "fix", Message{Type: "fix"}.Prefix())
+	assert.Equal(t, "feat(cli)!", Message{Type: "feat", Scope: "cli", Breaking: []string{"x"}}.Prefix())
+}
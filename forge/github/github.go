@@ -0,0 +1,303 @@
+// Package github reviews GitHub pull requests through the REST API.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/loveRyujin/ReviewBot/git"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/loveRyujin/ReviewBot/proxy"
+	"github.com/loveRyujin/ReviewBot/review"
+)
+
+// DefaultBaseURL is the API root of github.com. GitHub Enterprise Server
+// serves the API under https://<host>/api/v3.
+const DefaultBaseURL = "https://api.github.com"
+
+const apiVersion = "2022-11-28"
+
+// Client talks to the GitHub REST API.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// PullRequestRef identifies a pull request as owner/repo#number.
+type PullRequestRef struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+var pullRequestRefRe = regexp.MustCompile(`^([\w.-]+)/([\w.-]+)#(\d+)$`)
+
+// ParsePullRequestRef parses a reference of the form owner/repo#123.
+func ParsePullRequestRef(s string) (PullRequestRef, error) {
+	m := pullRequestRefRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return PullRequestRef{}, fmt.Errorf("invalid pull request %q, expected owner/repo#number", s)
+	}
+	number, err := strconv.Atoi(m[3])
+	if err != nil || number <= 0 {
+		return PullRequestRef{}, fmt.Errorf("invalid pull request number in %q", s)
+	}
+	return PullRequestRef{Owner: m[1], Repo: m[2], Number: number}, nil
+}
+
+func (r PullRequestRef) String() string {
+	return fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.Number)
+}
+
+func (r PullRequestRef) path() string {
+	return fmt.Sprintf("/repos/%s/%s/pulls/%d", r.Owner, r.Repo, r.Number)
+}
+
+// PullRequest holds the pull request fields ReviewBot needs.
+type PullRequest struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+// ReviewComment is an inline comment anchored to a position in the diff of
+// a file: the number of lines below its first hunk header.
+type ReviewComment struct {
+	Path     string `json:"path"`
+	Position int    `json:"position"`
+	Body     string `json:"body"`
+}
+
+// ReviewRequest is the payload of a new pull request review.
+type ReviewRequest struct {
+	CommitID string          `json:"commit_id,omitempty"`
+	Body     string          `json:"body"`
+	Event    string          `json:"event"`
+	Comments []ReviewComment `json:"comments,omitempty"`
+}
+
+// Review is a published pull request review.
+type Review struct {
+	ID      int64  `json:"id"`
+	HTMLURL string `json:"html_url"`
+}
+
+// APIError is returned when GitHub responds with an error status.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("github: status %d: %s", e.StatusCode, e.Message)
+}
+
+// PullRequest fetches the metadata of a pull request.
+func (c *Client) PullRequest(ctx context.Context, ref PullRequestRef) (*PullRequest, error) {
+	resp, err := c.do(ctx, http.MethodGet, ref.path(), "application/vnd.github+json", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var pr PullRequest
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return nil, fmt.Errorf("github: decode pull request: %w", err)
+	}
+	return &pr, nil
+}
+
+// PullRequestDiff fetches the unified diff of a pull request. At most
+// maxSize bytes are read; larger diffs are rejected.
+func (c *Client) PullRequestDiff(ctx context.Context, ref PullRequestRef, maxSize int) (string, error) {
+	resp, err := c.do(ctx, http.MethodGet, ref.path(), "application/vnd.github.diff", nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	diff, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxSize)))
+	if err != nil {
+		return "", fmt.Errorf("github: read diff: %w", err)
+	}
+	if len(diff) >= maxSize {
+		return "", fmt.Errorf("github: diff of %s exceeds the input size limit", ref)
+	}
+	return string(diff), nil
+}
+
+// CreateReview publishes a review on a pull request.
+func (c *Client) CreateReview(ctx context.Context, ref PullRequestRef, req *ReviewRequest) (*Review, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(ctx, http.MethodPost, ref.path()+"/reviews", "application/vnd.github+json", payload)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var result Review
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("github: decode review: %w", err)
+	}
+	return &result, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path, accept string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", apiVersion)
+	req.Header.Set("User-Agent", "ReviewBot")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		return nil, decodeError(resp)
+	}
+	return resp, nil
+}
+
+// decodeError converts a non-2xx response into an *APIError.
+func decodeError(resp *http.Response) error {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var payload struct {
+		Message string `json:"message"`
+		Errors  []any  `json:"errors"`
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil || payload.Message == "" {
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(raw))}
+	}
+	msg := payload.Message
+	if len(payload.Errors) > 0 {
+		details, _ := json.Marshal(payload.Errors)
+		msg += ": " + string(details)
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: msg}
+}
+
+// NewReviewRequest turns a report into a review of the given commit: every
+// finding that can be placed on the diff becomes an inline comment, the rest
+// is listed in the summary body.
+func NewReviewRequest(report *review.Report, diff, commitID string) *ReviewRequest {
+	placed, unplaced := review.ParseHunks(diff).Place(report.Findings)
+	positions := DiffPositions(diff)
+
+	req := &ReviewRequest{CommitID: commitID, Event: "COMMENT"}
+	for _, p := range placed {
+		position, ok := positions[p.Finding.File][p.Lines.End]
+		if !ok {
+			unplaced = append(unplaced, p.Finding)
+			continue
+		}
+		req.Comments = append(req.Comments, ReviewComment{
+			Path:     p.Finding.File,
+			Position: position,
+			Body:     p.Markdown(),
+		})
+	}
+	req.Body = report.SummaryMarkdown(unplaced)
+	return req
+}
+
+// DiffPositions maps every new-side line of a diff to its review comment
+// position, per file. The position counts the lines below the first hunk
+// header of a file, including later hunk headers.
+func DiffPositions(diff string) map[string]map[int]int {
+	positions := make(map[string]map[int]int)
+
+	files, _ := git.ParseDiff(diff)
+	for _, f := range files {
+		if f.NewName == "" {
+			continue
+		}
+		lines := make(map[int]int)
+		position := 0
+		for i, h := range f.Hunks {
+			if i > 0 {
+				position++
+			}
+			for _, l := range h.Lines {
+				position++
+				if l.NewLine > 0 {
+					lines[l.NewLine] = position
+				}
+				if l.NoNewline {
+					position++
+				}
+			}
+		}
+		positions[f.NewName] = lines
+	}
+	return positions
+}
+
+// Config holds the GitHub API settings.
+type Config struct {
+	BaseURL string
+	Token   string
+}
+
+// FromConfig maps the github configuration section onto a client configuration.
+func FromConfig(cfg config.GitHubConfig) *Config {
+	return &Config{
+		BaseURL: cfg.BaseURL,
+		Token:   cfg.Token,
+	}
+}
+
+func (cfg *Config) New(proxyCfg *proxy.Config) (*Client, error) {
+	httpClient, err := proxyCfg.New()
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+		token:      cfg.Token,
+	}, nil
+}
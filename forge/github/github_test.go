@@ -0,0 +1,163 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/proxy"
+	"github.com/loveRyujin/ReviewBot/review"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const prDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@ package main
+ import "fmt"
++import "os"
+
+ func main() {
+@@ -10,2 +11,3 @@ func main() {
+ 	fmt.Println("hi")
++	os.Exit(1)
+ }
+diff --git a/gone.go b/gone.go
+deleted file mode 100644
+--- a/gone.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package gone
+`
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := (&Config{BaseURL: server.URL + "/api/v3/", Token: "secret"}).New(&proxy.Config{})
+	require.NoError(t, err)
+	return client
+}
+
+func TestParsePullRequestRef(t *testing.T) {
+	ref, err := ParsePullRequestRef("loveRyujin/ReviewBot#123")
+	require.NoError(t, err)
+	assert.Equal(t, PullRequestRef{Owner: "loveRyujin", Repo: "ReviewBot", Number: 123}, ref)
+	assert.Equal(t, "loveRyujin/ReviewBot#123", ref.String())
+
+	for _, bad := range []string{"", "owner/repo", "owner#1", "owner/repo#0", "owner/repo#x", "a/b/c#1"} {
+		_, err := ParsePullRequestRef(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestConfig_New(t *testing.T) {
+	client, err := (&Config{}).New(&proxy.Config{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBaseURL, client.baseURL)
+}
+
+func TestClient_PullRequestAndDiff(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v3/repos/o/r/pulls/7", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, apiVersion, r.Header.Get("X-GitHub-Api-Version"))
+
+		if r.Header.Get("Accept") == "application/vnd.github.diff" {
+			_, _ = w.Write([]byte(prDiff))
+			return
+		}
+		assert.Equal(t, "application/vnd.github+json", r.Header.Get("Accept"))
+		_, _ = w.Write([]byte(`{"number":7,"title":"Fix","html_url":"https://github.com/o/r/pull/7","head":{"sha":"abc123"}}`))
+	})
+	ref := PullRequestRef{Owner: "o", Repo: "r", Number: 7}
+
+	pr, err := client.PullRequest(context.Background(), ref)
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", pr.Head.SHA)
+	assert.Equal(t, "Fix", pr.Title)
+
+	diff, err := client.PullRequestDiff(context.Background(), ref, 1024*1024)
+	require.NoError(t, err)
+	assert.Equal(t, prDiff, diff)
+
+	_, err = client.PullRequestDiff(context.Background(), ref, 100)
+	assert.ErrorContains(t, err, "exceeds the input size limit")
+}
+
+func TestClient_CreateReview(t *testing.T) {
+	var got ReviewRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v3/repos/o/r/pulls/7/reviews", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"id":42,"html_url":"https://github.com/o/r/pull/7#pullrequestreview-42"}`))
+	})
+
+	req := &ReviewRequest{CommitID: "abc123", Body: "summary", Event: "COMMENT", Comments: []ReviewComment{{Path: "main.go", Position: 2, Body: "nit"}}}
+	result, err := client.CreateReview(context.Background(), PullRequestRef{Owner: "o", Repo: "r", Number: 7}, req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), result.ID)
+	assert.Equal(t, *req, got)
+}
+
+func TestClient_APIError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"message":"Validation Failed","errors":["position is invalid"]}`))
+	})
+
+	_, err := client.CreateReview(context.Background(), PullRequestRef{Owner: "o", Repo: "r", Number: 7}, &ReviewRequest{})
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.Equal(t, `Validation Failed: ["position is invalid"]`, apiErr.Message)
+}
+
+func TestDiffPositions(t *testing.T) {
+	positions := DiffPositions(prDiff)
+
+	// line 1 is right below the first hunk header, the second header is position 5
+	assert.Equal(t, map[int]int{1: 1, 2: 2, 3: 3, 4: 4, 11: 6, 12: 7, 13: 8}, positions["main.go"])
+	assert.NotContains(t, positions, "gone.go")
+}
+
+func TestNewReviewRequest(t *testing.T) {
+	report := review.NewReport("openai", "gpt-4o", &review.Result{
+		Summary: "Looks fine overall.",
+		Findings: []review.Finding{
+			{File: "main.go", StartLine: 12, EndLine: 12, Severity: review.SeverityHigh, Category: review.CategoryBug, Title: "Exit code", Message: "Exiting with 1 hides success.", Suggestion: "Return instead."},
+			{File: "main.go", StartLine: 30, EndLine: 31, Severity: review.SeverityLow, Category: review.CategoryStyle, Title: "Naming", Message: "Rename main."},
+			{File: "other.go", StartLine: 3, Severity: review.SeverityMedium, Category: review.CategoryOther, Title: "Unrelated", Message: "Outside the PR."},
+		},
+	}, ai.TokenUsage{})
+
+	req := NewReviewRequest(report, prDiff, "abc123")
+	assert.Equal(t, "abc123", req.CommitID)
+	assert.Equal(t, "COMMENT", req.Event)
+	require.Len(t, req.Comments, 2)
+
+	assert.Equal(t, ReviewComment{
+		Path:     "main.go",
+		Position: 7,
+		Body:     "**[HIGH] Bug: Exit code**\n\nExiting with 1 hides success.\n\n**Suggestion:** Return instead.",
+	}, req.Comments[0])
+
+	// snapped to the closest hunk and flagged as moved
+	assert.Equal(t, 8, req.Comments[1].Position)
+	assert.Contains(t, req.Comments[1].Body, "_Reported for lines 30-31, outside the changed lines._")
+
+	assert.True(t, strings.HasPrefix(req.Body, "## ReviewBot\n\nLooks fine overall.\n\n**Findings:** 1 high, 1 medium, 1 low\n"))
+	assert.Contains(t, req.Body, "- **[MEDIUM] Unrelated** `other.go` (line 3): Outside the PR.\n")
+	assert.Contains(t, req.Body, "_Reviewed by ReviewBot using openai/gpt-4o_")
+}
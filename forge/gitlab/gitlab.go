@@ -0,0 +1,410 @@
+// Package gitlab reviews GitLab merge requests through the REST API.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/loveRyujin/ReviewBot/git"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/loveRyujin/ReviewBot/proxy"
+	"github.com/loveRyujin/ReviewBot/review"
+)
+
+// DefaultBaseURL is the API root of gitlab.com. Self-hosted instances serve
+// the API under https://<host>/api/v4.
+const DefaultBaseURL = "https://gitlab.com/api/v4"
+
+// Client talks to the GitLab REST API.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// MergeRequestRef identifies a merge request as project!iid, where project
+// is the full path of the project (group/subgroup/project) or its numeric id.
+type MergeRequestRef struct {
+	Project string
+	IID     int
+}
+
+var mergeRequestRefRe = regexp.MustCompile(`^(\d+|[\w.-]+(?:/[\w.-]+)+)!(\d+)$`)
+
+// ParseMergeRequestRef parses a reference of the form group/project!123.
+func ParseMergeRequestRef(s string) (MergeRequestRef, error) {
+	m := mergeRequestRefRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return MergeRequestRef{}, fmt.Errorf("invalid merge request %q, expected group/project!iid", s)
+	}
+	iid, err := strconv.Atoi(m[2])
+	if err != nil || iid <= 0 {
+		return MergeRequestRef{}, fmt.Errorf("invalid merge request iid in %q", s)
+	}
+	return MergeRequestRef{Project: m[1], IID: iid}, nil
+}
+
+func (r MergeRequestRef) String() string {
+	return fmt.Sprintf("%s!%d", r.Project, r.IID)
+}
+
+func (r MergeRequestRef) path() string {
+	return fmt.Sprintf("/projects/%s/merge_requests/%d", url.PathEscape(r.Project), r.IID)
+}
+
+// DiffRefs are the commits a merge request diff was computed from. They
+// anchor discussion positions.
+type DiffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	StartSHA string `json:"start_sha"`
+	HeadSHA  string `json:"head_sha"`
+}
+
+// Change is the diff of one file of a merge request. Diff holds the hunks
+// only, without the file headers.
+type Change struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	AMode       string `json:"a_mode"`
+	BMode       string `json:"b_mode"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+	Diff        string `json:"diff"`
+}
+
+// MergeRequest holds the merge request fields ReviewBot needs.
+type MergeRequest struct {
+	IID      int      `json:"iid"`
+	Title    string   `json:"title"`
+	WebURL   string   `json:"web_url"`
+	DiffRefs DiffRefs `json:"diff_refs"`
+	Changes  []Change `json:"changes"`
+	// Overflow is set when GitLab left out changes of a large merge request.
+	Overflow bool `json:"overflow"`
+}
+
+// Position anchors a discussion to a line of the merge request diff.
+// Added lines only carry NewLine, unchanged lines both line numbers.
+type Position struct {
+	PositionType string `json:"position_type"`
+	BaseSHA      string `json:"base_sha"`
+	StartSHA     string `json:"start_sha"`
+	HeadSHA      string `json:"head_sha"`
+	OldPath      string `json:"old_path"`
+	NewPath      string `json:"new_path"`
+	OldLine      int    `json:"old_line,omitempty"`
+	NewLine      int    `json:"new_line,omitempty"`
+}
+
+// Discussion is the payload of a new merge request discussion thread.
+type Discussion struct {
+	Body     string    `json:"body"`
+	Position *Position `json:"position,omitempty"`
+}
+
+// Note is the payload of a new merge request comment.
+type Note struct {
+	Body string `json:"body"`
+}
+
+// APIError is returned when GitLab responds with an error status.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("gitlab: status %d: %s", e.StatusCode, e.Message)
+}
+
+// MergeRequestChanges fetches a merge request together with the diff of
+// every changed file. At most maxSize bytes of the response are read; larger
+// merge requests are rejected.
+func (c *Client) MergeRequestChanges(ctx context.Context, ref MergeRequestRef, maxSize int) (*MergeRequest, error) {
+	resp, err := c.do(ctx, http.MethodGet, ref.path()+"/changes", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxSize)))
+	if err != nil {
+		return nil, fmt.Errorf("gitlab: read merge request: %w", err)
+	}
+	if len(body) >= maxSize {
+		return nil, fmt.Errorf("gitlab: changes of %s exceed the input size limit", ref)
+	}
+
+	var mr MergeRequest
+	if err := json.Unmarshal(body, &mr); err != nil {
+		return nil, fmt.Errorf("gitlab: decode merge request: %w", err)
+	}
+	return &mr, nil
+}
+
+// CreateDiscussion starts a discussion thread on a merge request.
+func (c *Client) CreateDiscussion(ctx context.Context, ref MergeRequestRef, d *Discussion) error {
+	return c.post(ctx, ref.path()+"/discussions", d)
+}
+
+// CreateNote adds a comment to a merge request.
+func (c *Client) CreateNote(ctx context.Context, ref MergeRequestRef, n *Note) error {
+	return c.post(ctx, ref.path()+"/notes", n)
+}
+
+// PublishReview posts a discussion for every finding that can be placed on
+// the diff and a summary note listing the rest. Findings whose position
+// GitLab rejects are moved to the summary as well. It returns the number of
+// discussions created.
+func (c *Client) PublishReview(ctx context.Context, ref MergeRequestRef, mr *MergeRequest, report *review.Report) (int, error) {
+	discussions, unplaced := NewDiscussions(report, mr)
+
+	created := 0
+	for _, d := range discussions {
+		err := c.CreateDiscussion(ctx, ref, &d.Discussion)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+			unplaced = append(unplaced, d.Finding)
+			continue
+		}
+		if err != nil {
+			return created, err
+		}
+		created++
+	}
+
+	if err := c.CreateNote(ctx, ref, &Note{Body: report.SummaryMarkdown(unplaced)}); err != nil {
+		return created, err
+	}
+	return created, nil
+}
+
+func (c *Client) post(ctx context.Context, path string, v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodPost, path, payload)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+func (c *Client) do(ctx context.Context, method, path string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "ReviewBot")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		return nil, decodeError(resp)
+	}
+	return resp, nil
+}
+
+// decodeError converts a non-2xx response into an *APIError. GitLab reports
+// errors as {"message": ...} or {"error": ...}, where message may be a
+// string or an object of field errors.
+func decodeError(resp *http.Response) error {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var payload struct {
+		Message json.RawMessage `json:"message"`
+		Error   string          `json:"error"`
+	}
+	msg := strings.TrimSpace(string(raw))
+	if err := json.Unmarshal(raw, &payload); err == nil {
+		var text string
+		switch {
+		case payload.Error != "":
+			msg = payload.Error
+		case json.Unmarshal(payload.Message, &text) == nil && text != "":
+			msg = text
+		case len(payload.Message) > 0:
+			msg = string(payload.Message)
+		}
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: msg}
+}
+
+// Diff renders the changes of the merge request as a unified diff in git's
+// format.
+func (mr *MergeRequest) Diff() string {
+	files := make([]*git.FileDiff, 0, len(mr.Changes))
+	for _, c := range mr.Changes {
+		files = append(files, c.fileDiff())
+	}
+	return git.FormatDiff(files)
+}
+
+// fileDiff converts a change into a file diff. GitLab leaves out the file
+// headers, so the hunks are parsed below synthetic ones.
+func (c Change) fileDiff() *git.FileDiff {
+	f := &git.FileDiff{OldName: c.OldPath, NewName: c.NewPath, Status: git.StatusModified}
+	switch {
+	case c.NewFile:
+		f.Status, f.OldName, f.NewMode = git.StatusAdded, "", c.BMode
+	case c.DeletedFile:
+		f.Status, f.NewName, f.OldMode = git.StatusDeleted, "", c.AMode
+	case c.RenamedFile:
+		f.Status = git.StatusRenamed
+	}
+	if f.Status != git.StatusAdded && f.Status != git.StatusDeleted && c.AMode != c.BMode {
+		f.OldMode, f.NewMode = c.AMode, c.BMode
+	}
+
+	switch {
+	case strings.HasPrefix(c.Diff, "Binary files "):
+		f.Binary = true
+	case strings.HasPrefix(c.Diff, "@@"):
+		body := c.Diff
+		if !strings.HasSuffix(body, "\n") {
+			body += "\n"
+		}
+		// the hunks are well-formed even if GitLab truncated the last one,
+		// keep whatever parsed
+		parsed, _ := git.ParseDiff("--- a/file\n+++ b/file\n" + body)
+		if len(parsed) == 1 {
+			f.Hunks = parsed[0].Hunks
+		}
+	}
+	return f
+}
+
+// DiscussionFinding pairs a discussion with the finding it reports.
+type DiscussionFinding struct {
+	Discussion Discussion
+	Finding    review.Finding
+}
+
+// NewDiscussions turns the findings of a report into discussions anchored
+// to the merge request diff. Findings that cannot be placed are returned
+// for the summary note.
+func NewDiscussions(report *review.Report, mr *MergeRequest) ([]DiscussionFinding, []review.Finding) {
+	diff := mr.Diff()
+	placed, unplaced := review.ParseHunks(diff).Place(report.Findings)
+	lines := diffLines(diff)
+
+	var discussions []DiscussionFinding
+	for _, p := range placed {
+		l, ok := lines[p.Finding.File][p.Lines.End]
+		if !ok {
+			unplaced = append(unplaced, p.Finding)
+			continue
+		}
+		pos := &Position{
+			PositionType: "text",
+			BaseSHA:      mr.DiffRefs.BaseSHA,
+			StartSHA:     mr.DiffRefs.StartSHA,
+			HeadSHA:      mr.DiffRefs.HeadSHA,
+			OldPath:      l.oldPath,
+			NewPath:      p.Finding.File,
+			OldLine:      l.oldLine,
+			NewLine:      l.newLine,
+		}
+		discussions = append(discussions, DiscussionFinding{
+			Discussion: Discussion{Body: p.Markdown(), Position: pos},
+			Finding:    p.Finding,
+		})
+	}
+	return discussions, unplaced
+}
+
+// diffLine is a new-side line of a diff with its old-side counterpart, if
+// the line is unchanged.
+type diffLine struct {
+	oldPath string
+	oldLine int
+	newLine int
+}
+
+// diffLines indexes the new-side lines of a diff by file and line number.
+func diffLines(diff string) map[string]map[int]diffLine {
+	index := make(map[string]map[int]diffLine)
+
+	files, _ := git.ParseDiff(diff)
+	for _, f := range files {
+		if f.NewName == "" {
+			continue
+		}
+		oldPath := f.OldName
+		if oldPath == "" {
+			oldPath = f.NewName
+		}
+		lines := make(map[int]diffLine)
+		for _, h := range f.Hunks {
+			for _, l := range h.Lines {
+				if l.NewLine > 0 {
+					lines[l.NewLine] = diffLine{oldPath: oldPath, oldLine: l.OldLine, newLine: l.NewLine}
+				}
+			}
+		}
+		index[f.NewName] = lines
+	}
+	return index
+}
+
+// Config holds the GitLab API settings.
+type Config struct {
+	BaseURL string
+	Token   string
+}
+
+// FromConfig maps the gitlab configuration section onto a client configuration.
+func FromConfig(cfg config.GitLabConfig) *Config {
+	return &Config{
+		BaseURL: cfg.BaseURL,
+		Token:   cfg.Token,
+	}
+}
+
+func (cfg *Config) New(proxyCfg *proxy.Config) (*Client, error) {
+	httpClient, err := proxyCfg.New()
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+		token:      cfg.Token,
+	}, nil
+}
@@ -0,0 +1,234 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/git"
+	"github.com/loveRyujin/ReviewBot/proxy"
+	"github.com/loveRyujin/ReviewBot/review"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const changesJSON = `{
+  "iid": 7,
+  "title": "Exit early",
+  "web_url": "https://gitlab.test/g/p/-/merge_requests/7",
+  "diff_refs": {"base_sha": "base1", "start_sha": "start1", "head_sha": "head1"},
+  "changes": [
+    {"old_path": "main.go", "new_path": "main.go", "a_mode": "100644", "b_mode": "100644",
+     "diff": "@@ -1,3 +1,4 @@ package main\n import \"fmt\"\n+import \"os\"\n \n func main() {\n@@ -10,2 +11,3 @@ func main() {\n \tfmt.Println(\"hi\")\n+\tos.Exit(1)\n }\n"},
+    {"old_path": "run.sh", "new_path": "scripts/run.sh", "a_mode": "100644", "b_mode": "100755", "renamed_file": true,
+     "diff": "@@ -1 +1 @@\n-echo hi\n\\ No newline at end of file\n+echo hello\n"},
+    {"old_path": "logo.png", "new_path": "logo.png", "a_mode": "0", "b_mode": "100644", "new_file": true, "diff": ""},
+    {"old_path": "gone.go", "new_path": "gone.go", "a_mode": "100644", "b_mode": "0", "deleted_file": true,
+     "diff": "@@ -1 +0,0 @@\n-package gone\n"}
+  ]
+}`
+
+const changesDiff = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@ package main
+ import "fmt"
++import "os"
+ 
+ func main() {
+@@ -10,2 +11,3 @@ func main() {
+ 	fmt.Println("hi")
++	os.Exit(1)
+ }
+diff --git a/run.sh b/scripts/run.sh
+old mode 100644
+new mode 100755
+rename from run.sh
+rename to scripts/run.sh
+--- a/run.sh
++++ b/scripts/run.sh
+@@ -1 +1 @@
+-echo hi
+\ No newline at end of file
++echo hello
+diff --git a/logo.png b/logo.png
+new file mode 100644
+diff --git a/gone.go b/gone.go
+deleted file mode 100644
+--- a/gone.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package gone
+`
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := (&Config{BaseURL: server.URL + "/api/v4/", Token: "secret"}).New(&proxy.Config{})
+	require.NoError(t, err)
+	return client
+}
+
+func decodeChanges(t *testing.T) *MergeRequest {
+	t.Helper()
+
+	var mr MergeRequest
+	require.NoError(t, json.Unmarshal([]byte(changesJSON), &mr))
+	return &mr
+}
+
+func TestParseMergeRequestRef(t *testing.T) {
+	ref, err := ParseMergeRequestRef("group/sub/project!12")
+	require.NoError(t, err)
+	assert.Equal(t, MergeRequestRef{Project: "group/sub/project", IID: 12}, ref)
+	assert.Equal(t, "group/sub/project!12", ref.String())
+	assert.Equal(t, "/projects/group%2Fsub%2Fproject/merge_requests/12", ref.path())
+
+	ref, err = ParseMergeRequestRef("42!3")
+	require.NoError(t, err)
+	assert.Equal(t, MergeRequestRef{Project: "42", IID: 3}, ref)
+
+	for _, bad := range []string{"", "project!1", "group/project", "group/project#1", "group/project!0", "group/project!x"} {
+		_, err := ParseMergeRequestRef(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestConfig_New(t *testing.T) {
+	client, err := (&Config{}).New(&proxy.Config{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBaseURL, client.baseURL)
+}
+
+func TestClient_MergeRequestChanges(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v4/projects/g%2Fp/merge_requests/7/changes", r.URL.EscapedPath())
+		assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+		_, _ = w.Write([]byte(changesJSON))
+	})
+
+	ref := MergeRequestRef{Project: "g/p", IID: 7}
+	mr, err := client.MergeRequestChanges(context.Background(), ref, 1024*1024)
+	require.NoError(t, err)
+	assert.Equal(t, "Exit early", mr.Title)
+	assert.Equal(t, DiffRefs{BaseSHA: "base1", StartSHA: "start1", HeadSHA: "head1"}, mr.DiffRefs)
+	assert.Len(t, mr.Changes, 4)
+
+	_, err = client.MergeRequestChanges(context.Background(), ref, 100)
+	assert.ErrorContains(t, err, "exceed the input size limit")
+}
+
+func TestMergeRequest_Diff(t *testing.T) {
+	diff := decodeChanges(t).Diff()
+	assert.Equal(t, changesDiff, diff)
+
+	files, err := git.ParseDiff(diff)
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+	assert.Equal(t, git.StatusRenamed, files[1].Status)
+	assert.True(t, files[1].Hunks[0].Lines[0].NoNewline)
+	assert.Equal(t, git.StatusAdded, files[2].Status)
+	assert.Equal(t, git.StatusDeleted, files[3].Status)
+}
+
+func TestClient_APIError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"404 Project Not Found"}`))
+	})
+
+	_, err := client.MergeRequestChanges(context.Background(), MergeRequestRef{Project: "g/p", IID: 7}, 1024*1024)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "404 Project Not Found", apiErr.Message)
+
+	// field errors come as an object
+	err = decodeError(&http.Response{
+		StatusCode: http.StatusBadRequest,
+		Body:       io.NopCloser(strings.NewReader(`{"message":{"note":["can't be blank"]}}`)),
+	})
+	assert.EqualError(t, err, `gitlab: status 400: {"note":["can't be blank"]}`)
+}
+
+func newReport() *review.Report {
+	return review.NewReport("openai", "gpt-4o", &review.Result{
+		Summary: "Looks fine overall.",
+		Findings: []review.Finding{
+			{File: "main.go", StartLine: 12, EndLine: 12, Severity: review.SeverityHigh, Category: review.CategoryBug, Title: "Exit code", Message: "Exiting with 1 hides success."},
+			{File: "main.go", StartLine: 13, EndLine: 13, Severity: review.SeverityLow, Category: review.CategoryStyle, Title: "Brace", Message: "Context line."},
+			{File: "scripts/run.sh", StartLine: 1, EndLine: 1, Severity: review.SeverityMedium, Category: review.CategoryOther, Title: "Greeting", Message: "Say hi."},
+			{File: "other.go", StartLine: 3, EndLine: 3, Severity: review.SeverityLow, Category: review.CategoryOther, Title: "Unrelated", Message: "Outside the MR."},
+		},
+	}, ai.TokenUsage{})
+}
+
+func TestNewDiscussions(t *testing.T) {
+	discussions, unplaced := NewDiscussions(newReport(), decodeChanges(t))
+	require.Len(t, discussions, 3)
+	require.Len(t, unplaced, 1)
+	assert.Equal(t, "other.go", unplaced[0].File)
+
+	refs := Position{PositionType: "text", BaseSHA: "base1", StartSHA: "start1", HeadSHA: "head1"}
+
+	added := refs
+	added.OldPath, added.NewPath, added.NewLine = "main.go", "main.go", 12
+	assert.Equal(t, &added, discussions[0].Discussion.Position)
+	assert.Equal(t, "**[HIGH] Bug: Exit code**\n\nExiting with 1 hides success.", discussions[0].Discussion.Body)
+
+	// unchanged lines need both sides
+	context := refs
+	context.OldPath, context.NewPath, context.OldLine, context.NewLine = "main.go", "main.go", 11, 13
+	assert.Equal(t, &context, discussions[1].Discussion.Position)
+
+	renamed := refs
+	renamed.OldPath, renamed.NewPath, renamed.NewLine = "run.sh", "scripts/run.sh", 1
+	assert.Equal(t, &renamed, discussions[2].Discussion.Position)
+}
+
+func TestClient_PublishReview(t *testing.T) {
+	var discussions []Discussion
+	var note Note
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/g%2Fp/merge_requests/7/discussions":
+			var d Discussion
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&d))
+			if d.Position.NewPath == "scripts/run.sh" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"message":"400 Bad request - Note {:line_code=>[\"can't be blank\"]}"}`))
+				return
+			}
+			discussions = append(discussions, d)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"abc"}`))
+		case "/api/v4/projects/g%2Fp/merge_requests/7/notes":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&note))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":1}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.EscapedPath())
+		}
+	})
+
+	created, err := client.PublishReview(context.Background(), MergeRequestRef{Project: "g/p", IID: 7}, decodeChanges(t), newReport())
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+	assert.Len(t, discussions, 2)
+
+	assert.True(t, strings.HasPrefix(note.Body, "## ReviewBot\n\nLooks fine overall.\n"))
+	// the rejected discussion falls back to the summary
+	assert.Contains(t, note.Body, "**[MEDIUM] Greeting** `scripts/run.sh`")
+	assert.Contains(t, note.Body, "**[LOW] Unrelated** `other.go`")
+}
@@ -0,0 +1,595 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileStatus describes how a file changed in a diff.
+type FileStatus string
+
+const (
+	StatusModified FileStatus = "modified"
+	StatusAdded    FileStatus = "added"
+	StatusDeleted  FileStatus = "deleted"
+	StatusRenamed  FileStatus = "renamed"
+	StatusCopied   FileStatus = "copied"
+)
+
+// LineKind is the prefix of a line inside a hunk.
+type LineKind byte
+
+const (
+	LineContext LineKind = ' '
+	LineAdded   LineKind = '+'
+	LineDeleted LineKind = '-'
+)
+
+// FileDiff is the diff of a single file.
+type FileDiff struct {
+	// OldName and NewName are the paths without the a/ and b/ prefixes.
+	// OldName is empty for added files and NewName for deleted ones.
+	OldName string
+	NewName string
+	Status  FileStatus
+	// OldMode and NewMode are octal file modes such as 100644, when known.
+	OldMode string
+	NewMode string
+	// Similarity is the similarity index in percent of renames and copies.
+	Similarity int
+	// OldIndex and NewIndex are the abbreviated blob ids of the index line.
+	OldIndex string
+	NewIndex string
+	Binary   bool
+	Hunks    []*Hunk
+}
+
+// Hunk is one "@@" section of a file diff.
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	// Section is the text after the closing "@@", usually the enclosing function.
+	Section string
+	Lines   []Line
+}
+
+// Line is a single line of a hunk with its position in the old and new
+// version of the file. OldLine is zero for added lines and NewLine for
+// deleted ones.
+type Line struct {
+	Kind    LineKind
+	Text    string
+	OldLine int
+	NewLine int
+	// NoNewline marks the last line of a file that does not end with a newline.
+	NoNewline bool
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// Name returns the path of the file after the change, or before it for
+// deleted files.
+func (f *FileDiff) Name() string {
+	if f.NewName != "" {
+		return f.NewName
+	}
+	return f.OldName
+}
+
+// ParseDiff parses a unified diff as printed by git diff, git show, git
+// format-patch or diff -u. Text outside file diffs, such as commit messages,
+// is skipped. On error the files parsed so far are returned as well, the
+// last one possibly incomplete.
+func ParseDiff(diff string) ([]*FileDiff, error) {
+	p := &diffParser{lines: strings.Split(diff, "\n")}
+	if n := len(p.lines); p.lines[n-1] == "" {
+		p.lines = p.lines[:n-1]
+	}
+
+	for p.i < len(p.lines) {
+		line := p.lines[p.i]
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			if err := p.parseGitFile(); err != nil {
+				return p.files, err
+			}
+		case strings.HasPrefix(line, "diff --cc "), strings.HasPrefix(line, "diff --combined "):
+			return p.files, p.errorf("combined diffs are not supported")
+		case p.isPlainHeader():
+			f := &FileDiff{Status: StatusModified}
+			p.files = append(p.files, f)
+			if err := p.parseBody(f); err != nil {
+				return p.files, err
+			}
+		default:
+			p.i++
+		}
+	}
+	return p.files, nil
+}
+
+type diffParser struct {
+	lines []string
+	i     int
+	files []*FileDiff
+}
+
+// isPlainHeader reports whether a file diff without a "diff --git" line
+// starts at the current line. Requiring the first hunk header keeps "---"
+// lines of commit messages from being taken for files.
+func (p *diffParser) isPlainHeader() bool {
+	return p.i+2 < len(p.lines) &&
+		strings.HasPrefix(p.lines[p.i], "--- ") &&
+		strings.HasPrefix(p.lines[p.i+1], "+++ ") &&
+		strings.HasPrefix(p.lines[p.i+2], "@@ ")
+}
+
+func (p *diffParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("parse diff: line %d: %s", p.i+1, fmt.Sprintf(format, args...))
+}
+
+// parseGitFile parses a file starting at its "diff --git" line.
+func (p *diffParser) parseGitFile() error {
+	f := &FileDiff{Status: StatusModified}
+	p.files = append(p.files, f)
+	f.OldName, f.NewName = splitGitNames(strings.TrimPrefix(p.lines[p.i], "diff --git "))
+	p.i++
+
+	var err error
+	for ; p.i < len(p.lines); p.i++ {
+		line := p.lines[p.i]
+		switch {
+		case strings.HasPrefix(line, "old mode "):
+			f.OldMode = strings.TrimPrefix(line, "old mode ")
+		case strings.HasPrefix(line, "new mode "):
+			f.NewMode = strings.TrimPrefix(line, "new mode ")
+		case strings.HasPrefix(line, "deleted file mode "):
+			f.Status, f.OldMode = StatusDeleted, strings.TrimPrefix(line, "deleted file mode ")
+		case strings.HasPrefix(line, "new file mode "):
+			f.Status, f.NewMode = StatusAdded, strings.TrimPrefix(line, "new file mode ")
+		case strings.HasPrefix(line, "rename from "):
+			f.Status = StatusRenamed
+			f.OldName, err = unquoteName(strings.TrimPrefix(line, "rename from "))
+		case strings.HasPrefix(line, "rename to "):
+			f.Status = StatusRenamed
+			f.NewName, err = unquoteName(strings.TrimPrefix(line, "rename to "))
+		case strings.HasPrefix(line, "copy from "):
+			f.Status = StatusCopied
+			f.OldName, err = unquoteName(strings.TrimPrefix(line, "copy from "))
+		case strings.HasPrefix(line, "copy to "):
+			f.Status = StatusCopied
+			f.NewName, err = unquoteName(strings.TrimPrefix(line, "copy to "))
+		case strings.HasPrefix(line, "similarity index "):
+			f.Similarity, err = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"))
+		case strings.HasPrefix(line, "dissimilarity index "):
+		case strings.HasPrefix(line, "index "):
+			ids, mode, _ := strings.Cut(strings.TrimPrefix(line, "index "), " ")
+			f.OldIndex, f.NewIndex, _ = strings.Cut(ids, "..")
+			if mode != "" {
+				f.OldMode, f.NewMode = mode, mode
+			}
+		case strings.HasPrefix(line, "Binary files ") && strings.HasSuffix(line, " differ"):
+			f.Binary = true
+		case line == "GIT binary patch":
+			f.Binary = true
+			// skip the base85 payload up to the next file
+			for p.i+1 < len(p.lines) && !strings.HasPrefix(p.lines[p.i+1], "diff ") {
+				p.i++
+			}
+		case strings.HasPrefix(line, "--- "):
+			return p.parseBody(f)
+		default:
+			return p.finishFile(f)
+		}
+		if err != nil {
+			return p.errorf("%q: %v", line, err)
+		}
+	}
+	return p.finishFile(f)
+}
+
+// parseBody parses the "---"/"+++" lines of a file and its hunks.
+func (p *diffParser) parseBody(f *FileDiff) error {
+	if p.i+1 >= len(p.lines) || !strings.HasPrefix(p.lines[p.i+1], "+++ ") {
+		return p.errorf("missing +++ line")
+	}
+
+	oldName, err := headerName(strings.TrimPrefix(p.lines[p.i], "--- "), "a/")
+	if err != nil {
+		return p.errorf("%v", err)
+	}
+	p.i++
+	newName, err := headerName(strings.TrimPrefix(p.lines[p.i], "+++ "), "b/")
+	if err != nil {
+		return p.errorf("%v", err)
+	}
+	p.i++
+
+	switch {
+	case oldName == "" && newName == "":
+		return p.errorf("both file names are /dev/null")
+	case oldName == "":
+		f.Status, f.NewName = StatusAdded, newName
+	case newName == "":
+		f.Status, f.OldName = StatusDeleted, oldName
+	default:
+		f.OldName, f.NewName = oldName, newName
+	}
+
+	for p.i < len(p.lines) && strings.HasPrefix(p.lines[p.i], "@@ ") {
+		h, err := p.parseHunk()
+		if h != nil {
+			f.Hunks = append(f.Hunks, h)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return p.finishFile(f)
+}
+
+// finishFile normalizes the names of a parsed file.
+func (p *diffParser) finishFile(f *FileDiff) error {
+	switch f.Status {
+	case StatusAdded:
+		if f.NewName == "" {
+			f.NewName = f.OldName
+		}
+		f.OldName = ""
+	case StatusDeleted:
+		if f.OldName == "" {
+			f.OldName = f.NewName
+		}
+		f.NewName = ""
+	}
+	if f.Name() == "" {
+		return p.errorf("cannot determine the file name")
+	}
+	return nil
+}
+
+// parseHunk parses a hunk, consuming exactly the number of lines announced by
+// its header. A truncated hunk is returned together with the error.
+func (p *diffParser) parseHunk() (*Hunk, error) {
+	m := hunkHeaderRe.FindStringSubmatch(p.lines[p.i])
+	if m == nil {
+		return nil, p.errorf("malformed hunk header %q", p.lines[p.i])
+	}
+	h := &Hunk{
+		OldStart: atoiDefault(m[1], 0),
+		OldLines: atoiDefault(m[2], 1),
+		NewStart: atoiDefault(m[3], 0),
+		NewLines: atoiDefault(m[4], 1),
+		Section:  m[5],
+	}
+	// line numbers start at 1, an empty side names the line before it
+	if h.OldStart < 0 || h.OldLines < 0 || h.NewStart < 0 || h.NewLines < 0 ||
+		(h.OldStart == 0 && h.OldLines > 0) || (h.NewStart == 0 && h.NewLines > 0) {
+		return nil, p.errorf("malformed hunk header %q", p.lines[p.i])
+	}
+	p.i++
+
+	oldLine, newLine := h.OldStart, h.NewStart
+	oldLeft, newLeft := h.OldLines, h.NewLines
+	for oldLeft > 0 || newLeft > 0 {
+		if p.i >= len(p.lines) {
+			return h, p.errorf("hunk is truncated, %d old and %d new lines missing", oldLeft, newLeft)
+		}
+		line := p.lines[p.i]
+
+		// some tools strip the trailing space of empty context lines
+		kind, text := LineContext, ""
+		if line != "" {
+			kind, text = LineKind(line[0]), line[1:]
+		}
+		switch {
+		case kind == LineContext && oldLeft > 0 && newLeft > 0:
+			h.Lines = append(h.Lines, Line{Kind: kind, Text: text, OldLine: oldLine, NewLine: newLine})
+			oldLine, newLine, oldLeft, newLeft = oldLine+1, newLine+1, oldLeft-1, newLeft-1
+		case kind == LineDeleted && oldLeft > 0:
+			h.Lines = append(h.Lines, Line{Kind: kind, Text: text, OldLine: oldLine})
+			oldLine, oldLeft = oldLine+1, oldLeft-1
+		case kind == LineAdded && newLeft > 0:
+			h.Lines = append(h.Lines, Line{Kind: kind, Text: text, NewLine: newLine})
+			newLine, newLeft = newLine+1, newLeft-1
+		case kind == '\\' && len(h.Lines) > 0:
+			h.Lines[len(h.Lines)-1].NoNewline = true
+		default:
+			return h, p.errorf("unexpected line %q in hunk, %d old and %d new lines left", line, oldLeft, newLeft)
+		}
+		p.i++
+	}
+	if p.i < len(p.lines) && strings.HasPrefix(p.lines[p.i], "\\") && len(h.Lines) > 0 {
+		h.Lines[len(h.Lines)-1].NoNewline = true
+		p.i++
+	}
+	return h, nil
+}
+
+// String renders the file diff in git's format.
+func (f *FileDiff) String() string {
+	var b strings.Builder
+	b.WriteString(f.Header())
+	for _, h := range f.Hunks {
+		b.WriteString(h.String())
+	}
+	return b.String()
+}
+
+// Header renders the file diff up to its first hunk. Repeated before a
+// subset of the hunks, it still forms a valid patch.
+func (f *FileDiff) Header() string {
+	var b strings.Builder
+
+	oldName, newName := f.OldName, f.NewName
+	if oldName == "" {
+		oldName = newName
+	}
+	if newName == "" {
+		newName = oldName
+	}
+	fmt.Fprintf(&b, "diff --git %s %s\n", quoteName("a/"+oldName), quoteName("b/"+newName))
+
+	indexMode := ""
+	switch {
+	case f.Status == StatusAdded:
+		fmt.Fprintf(&b, "new file mode %s\n", defaultMode(f.NewMode))
+	case f.Status == StatusDeleted:
+		fmt.Fprintf(&b, "deleted file mode %s\n", defaultMode(f.OldMode))
+	case f.OldMode != "" && f.OldMode == f.NewMode && f.OldIndex != "":
+		indexMode = " " + f.OldMode
+	case f.OldMode != "" || f.NewMode != "":
+		fmt.Fprintf(&b, "old mode %s\nnew mode %s\n", defaultMode(f.OldMode), defaultMode(f.NewMode))
+	}
+	if f.Status == StatusRenamed || f.Status == StatusCopied {
+		verb := "rename"
+		if f.Status == StatusCopied {
+			verb = "copy"
+		}
+		if f.Similarity > 0 {
+			fmt.Fprintf(&b, "similarity index %d%%\n", f.Similarity)
+		}
+		fmt.Fprintf(&b, "%s from %s\n%s to %s\n", verb, quoteName(f.OldName), verb, quoteName(f.NewName))
+	}
+	if f.OldIndex != "" || f.NewIndex != "" {
+		fmt.Fprintf(&b, "index %s..%s%s\n", f.OldIndex, f.NewIndex, indexMode)
+	}
+
+	oldPath, newPath := "a/"+oldName, "b/"+newName
+	switch f.Status {
+	case StatusAdded:
+		oldPath = "/dev/null"
+	case StatusDeleted:
+		newPath = "/dev/null"
+	}
+	if f.Binary {
+		fmt.Fprintf(&b, "Binary files %s and %s differ\n", quoteName(oldPath), quoteName(newPath))
+	}
+	if len(f.Hunks) > 0 {
+		fmt.Fprintf(&b, "--- %s\n+++ %s\n", headerPath(oldPath), headerPath(newPath))
+	}
+	return b.String()
+}
+
+// String renders the hunk header and lines.
+func (h *Hunk) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+	if h.Section != "" {
+		b.WriteString(" " + h.Section)
+	}
+	b.WriteByte('\n')
+	for _, l := range h.Lines {
+		b.WriteByte(byte(l.Kind))
+		b.WriteString(l.Text)
+		b.WriteByte('\n')
+		if l.NoNewline {
+			b.WriteString("\\ No newline at end of file\n")
+		}
+	}
+	return b.String()
+}
+
+// FormatDiff renders parsed file diffs back into a single unified diff.
+func FormatDiff(files []*FileDiff) string {
+	var b strings.Builder
+	for _, f := range files {
+		b.WriteString(f.String())
+	}
+	return b.String()
+}
+
+// hunkRange formats one side of a hunk header; git omits a count of one.
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return strconv.Itoa(start)
+	}
+	return strconv.Itoa(start) + "," + strconv.Itoa(count)
+}
+
+func defaultMode(mode string) string {
+	if mode == "" {
+		return "100644"
+	}
+	return mode
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// headerName extracts the path of a "---" or "+++" line. A trailing
+// timestamp, as written by diff -u, is dropped and /dev/null yields "".
+func headerName(s, prefix string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		s, _, _ = strings.Cut(s, "\t")
+	}
+	name, err := unquoteName(s)
+	if err != nil || name == "/dev/null" {
+		return "", err
+	}
+	return strings.TrimPrefix(name, prefix), nil
+}
+
+// headerPath renders a "---" or "+++" path. Like git, a tab terminates
+// names containing spaces so that patch does not read them as a timestamp.
+func headerPath(name string) string {
+	quoted := quoteName(name)
+	if quoted == name && strings.Contains(name, " ") {
+		return name + "\t"
+	}
+	return quoted
+}
+
+// splitGitNames splits the two paths of a "diff --git" line. Unquoted paths
+// with spaces are ambiguous unless both sides name the same file; renames
+// and copies carry their names on separate lines anyway.
+func splitGitNames(s string) (oldName, newName string) {
+	if strings.HasPrefix(s, `"`) {
+		end := quotedEnd(s)
+		if end < 0 {
+			return "", ""
+		}
+		oldName, _ = unquoteName(s[:end])
+		rest := strings.TrimPrefix(s[end:], " ")
+		newName, _ = unquoteName(rest)
+		return strings.TrimPrefix(oldName, "a/"), strings.TrimPrefix(newName, "b/")
+	}
+
+	if i := strings.Index(s, ` "`); i >= 0 {
+		newName, _ = unquoteName(s[i+1:])
+		return strings.TrimPrefix(s[:i], "a/"), strings.TrimPrefix(newName, "b/")
+	}
+	if n := len(s); n%2 == 1 && s[n/2] == ' ' {
+		a, b := strings.TrimPrefix(s[:n/2], "a/"), strings.TrimPrefix(s[n/2+1:], "b/")
+		if a == b {
+			return a, b
+		}
+	}
+	if i := strings.Index(s, " b/"); i >= 0 {
+		return strings.TrimPrefix(s[:i], "a/"), s[i+3:]
+	}
+	return "", ""
+}
+
+// quotedEnd returns the index just past the closing quote of a C-style
+// quoted string at the start of s, or -1.
+func quotedEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// unquoteName decodes a path that git quoted because it contains special
+// characters. Unquoted paths are returned as is.
+func unquoteName(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return s, nil
+	}
+	if len(s) < 2 || quotedEnd(s) != len(s) {
+		return "", fmt.Errorf("malformed quoted path %s", s)
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch c = s[i]; c {
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case '"', '\\':
+			b.WriteByte(c)
+		default:
+			if i+3 > len(s)-1 {
+				return "", fmt.Errorf("malformed quoted path %s", s)
+			}
+			n, err := strconv.ParseUint(s[i:i+3], 8, 8)
+			if err != nil {
+				return "", fmt.Errorf("malformed quoted path %s", s)
+			}
+			b.WriteByte(byte(n))
+			i += 2
+		}
+	}
+	return b.String(), nil
+}
+
+// quoteName quotes a path the way git does with core.quotePath enabled:
+// control characters, quotes, backslashes and non-ASCII bytes are escaped.
+func quoteName(s string) string {
+	needsQuote := false
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c >= 0x7f || c == '"' || c == '\\' {
+			needsQuote = true
+			break
+		}
+	}
+	if !needsQuote {
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\v':
+			b.WriteString(`\v`)
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			if c < 0x20 || c >= 0x7f {
+				fmt.Fprintf(&b, `\%03o`, c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,387 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseDiffGitOutput parses git's output for every kind of change and
+// renders it back byte for byte.
+func TestParseDiffGitOutput(t *testing.T) {
+	setupRepo(t)
+
+	long := strings.Repeat("line\n", 20)
+	writeFiles(t, map[string]string{
+		"main.go":     "package main\n\nfunc a() {}\n" + long + "func b() {}\n",
+		"old.txt":     "one\ntwo\nthree\nfour\nfive\n",
+		"gone.txt":    "bye\n",
+		"script.sh":   "echo hi\n",
+		"source.go":   "package source\n\n" + long,
+		"image.bin":   "\x00\x01\x02",
+		"noeol.txt":   "last",
+		"with space":  "a\n",
+		"ünïcode.txt": "u\n",
+	})
+	gitRun(t, "add", "-A")
+	gitRun(t, "commit", "-m", "initial commit")
+
+	writeFiles(t, map[string]string{
+		"main.go":     "package main\n\nfunc a() { return }\n" + long + "func b() { return }\n",
+		"new.txt":     "fresh\n",
+		"image.bin":   "\x00\x03",
+		"noeol.txt":   "last\nmore",
+		"with space":  "b\n",
+		"ünïcode.txt": "v\n",
+		"copy.go":     "package source\n\n" + long,
+	})
+	gitRun(t, "mv", "old.txt", "renamed.txt")
+	if err := os.WriteFile("renamed.txt", []byte("one\ntwo\nthree\nfour\nfive\nsix\n"), 0o644); err != nil {
+		t.Fatalf("write renamed.txt: %v", err)
+	}
+	if err := os.Chmod("script.sh", 0o755); err != nil {
+		t.Fatalf("chmod script.sh: %v", err)
+	}
+	if err := os.Remove("gone.txt"); err != nil {
+		t.Fatalf("remove gone.txt: %v", err)
+	}
+	gitRun(t, "add", "-A")
+
+	out := gitRun(t, "diff", "--staged", "-M", "-C", "--find-copies-harder")
+	files, err := ParseDiff(out)
+	if err != nil {
+		t.Fatalf("ParseDiff: %v", err)
+	}
+
+	byName := map[string]*FileDiff{}
+	for _, f := range files {
+		byName[f.Name()] = f
+	}
+	wantStatus := map[string]FileStatus{
+		"main.go":     StatusModified,
+		"new.txt":     StatusAdded,
+		"gone.txt":    StatusDeleted,
+		"renamed.txt": StatusRenamed,
+		"copy.go":     StatusCopied,
+		"script.sh":   StatusModified,
+		"image.bin":   StatusModified,
+		"noeol.txt":   StatusModified,
+		"with space":  StatusModified,
+		"ünïcode.txt": StatusModified,
+	}
+	if len(files) != len(wantStatus) {
+		t.Fatalf("got %d files, want %d:\n%s", len(files), len(wantStatus), out)
+	}
+	for name, status := range wantStatus {
+		f, ok := byName[name]
+		if !ok {
+			t.Errorf("missing file %q", name)
+			continue
+		}
+		if f.Status != status {
+			t.Errorf("%s: status = %s, want %s", name, f.Status, status)
+		}
+	}
+
+	if f := byName["renamed.txt"]; f.OldName != "old.txt" || f.Similarity == 0 {
+		t.Errorf("rename: old name %q, similarity %d", f.OldName, f.Similarity)
+	}
+	if f := byName["copy.go"]; f.OldName != "source.go" || f.Similarity != 100 {
+		t.Errorf("copy: old name %q, similarity %d", f.OldName, f.Similarity)
+	}
+	if f := byName["script.sh"]; f.OldMode != "100644" || f.NewMode != "100755" || len(f.Hunks) != 0 {
+		t.Errorf("mode change: %q -> %q with %d hunks", f.OldMode, f.NewMode, len(f.Hunks))
+	}
+	if f := byName["image.bin"]; !f.Binary || len(f.Hunks) != 0 {
+		t.Errorf("binary: binary=%v with %d hunks", f.Binary, len(f.Hunks))
+	}
+	if f := byName["gone.txt"]; f.NewName != "" || f.OldName != "gone.txt" {
+		t.Errorf("deleted: names %q -> %q", f.OldName, f.NewName)
+	}
+	if f := byName["new.txt"]; f.OldName != "" || f.NewMode != "100644" {
+		t.Errorf("added: old name %q, mode %q", f.OldName, f.NewMode)
+	}
+
+	main := byName["main.go"]
+	if len(main.Hunks) != 2 {
+		t.Fatalf("main.go: got %d hunks, want 2", len(main.Hunks))
+	}
+	var added []Line
+	for _, l := range main.Hunks[1].Lines {
+		if l.Kind == LineAdded {
+			added = append(added, l)
+		}
+	}
+	if len(added) != 1 || added[0].Text != "func b() { return }" || added[0].NewLine != 24 || added[0].OldLine != 0 {
+		t.Errorf("main.go: unexpected added lines %+v", added)
+	}
+	partial, err := ParseDiff(main.Header() + main.Hunks[1].String())
+	if err != nil || len(partial) != 1 || partial[0].Name() != "main.go" || len(partial[0].Hunks) != 1 {
+		t.Errorf("main.go: header and second hunk do not parse as a patch: %v", err)
+	}
+
+	noeol := byName["noeol.txt"].Hunks[0].Lines
+	if !noeol[0].NoNewline || noeol[0].Kind != LineDeleted || !noeol[len(noeol)-1].NoNewline {
+		t.Errorf("noeol.txt: missing no-newline markers in %+v", noeol)
+	}
+
+	if got := strings.TrimSuffix(FormatDiff(files), "\n"); got != out {
+		t.Fatalf("FormatDiff does not reproduce git output\ngot:\n%s\nwant:\n%s", got, out)
+	}
+}
+
+// TestParseDiffPlain covers diff -u output and format-patch mails.
+func TestParseDiffPlain(t *testing.T) {
+	diff := `From 1234 Mon Sep 17 00:00:00 2001
+Subject: [PATCH] Fix things
+
+--- bar
++++ baz
+---
+ lib.c | 2 +-
+
+diff -u lib.c.orig lib.c
+--- lib.c.orig	2024-01-01 10:00:00.000000000 +0000
++++ lib.c	2024-01-02 10:00:00.000000000 +0000
+@@ -1,3 +1,3 @@ int main()
+ int a;
+--- removed dashes
++++ added pluses
+
+--
+2.40.0
+`
+	files, err := ParseDiff(diff)
+	if err != nil {
+		t.Fatalf("ParseDiff: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d files, want 1", len(files))
+	}
+
+	f := files[0]
+	if f.OldName != "lib.c.orig" || f.NewName != "lib.c" || f.Status != StatusModified {
+		t.Fatalf("unexpected file %+v", f)
+	}
+	h := f.Hunks[0]
+	if h.Section != "int main()" || len(h.Lines) != 4 {
+		t.Fatalf("unexpected hunk %+v", h)
+	}
+	if l := h.Lines[1]; l.Kind != LineDeleted || l.Text != "-- removed dashes" || l.OldLine != 2 {
+		t.Errorf("line 2 = %+v", l)
+	}
+	if l := h.Lines[2]; l.Kind != LineAdded || l.Text != "++ added pluses" || l.NewLine != 2 {
+		t.Errorf("line 3 = %+v", l)
+	}
+	if l := h.Lines[3]; l.Kind != LineContext || l.Text != "" || l.OldLine != 3 || l.NewLine != 3 {
+		t.Errorf("line 4 = %+v", l)
+	}
+}
+
+// TestParseDiffErrors checks malformed input and partial results.
+func TestParseDiffErrors(t *testing.T) {
+	tests := map[string]struct {
+		diff  string
+		files int
+	}{
+		"truncated hunk": {
+			diff:  "--- a/x\n+++ b/x\n@@ -1,3 +1,3 @@\n a\n-b\n",
+			files: 1,
+		},
+		"unexpected line": {
+			diff:  "diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\n-b\n",
+			files: 1,
+		},
+		"malformed header": {
+			diff:  "diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -a +b @@\n",
+			files: 1,
+		},
+		"combined diff": {
+			diff: "diff --cc x\nindex 1,2..3\n",
+		},
+		"missing plus line": {
+			diff:  "diff --git a/x b/x\n--- a/x\n@@ -1 +1 @@\n",
+			files: 1,
+		},
+		"bad quoting": {
+			diff:  "diff --git a/x b/x\nrename from \"x\\q\"\n",
+			files: 1,
+		},
+	}
+	for name, tt := range tests {
+		files, err := ParseDiff(tt.diff)
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		if len(files) != tt.files {
+			t.Errorf("%s: got %d partial files, want %d", name, len(files), tt.files)
+		}
+	}
+
+	files, _ := ParseDiff("--- a/x\n+++ b/x\n@@ -1,3 +1,3 @@\n a\n-b\n")
+	if len(files[0].Hunks) != 1 || len(files[0].Hunks[0].Lines) != 2 {
+		t.Errorf("truncated hunk should keep the parsed lines, got %+v", files[0].Hunks)
+	}
+}
+
+// TestQuoteName checks the quoting round trip of special paths.
+func TestQuoteName(t *testing.T) {
+	for _, name := range []string{"plain.go", "with space", "tab\there", `quote"d`, `back\slash`, "ünï", "\x01\x7f"} {
+		quoted := quoteName(name)
+		got, err := unquoteName(quoted)
+		if err != nil || got != name {
+			t.Errorf("unquoteName(quoteName(%q)) = %q, %v", name, got, err)
+		}
+	}
+	if got := quoteName("ü"); got != `"\303\274"` {
+		t.Errorf("quoteName(ü) = %s", got)
+	}
+}
+
+// FuzzParseDiff checks that parsing never panics and that rendering a parsed
+// diff is stable: the rendered text parses back into the same rendering.
+func FuzzParseDiff(f *testing.F) {
+	f.Add("diff --git a/x b/x\nindex 1..2 100644\n--- a/x\n+++ b/x\n@@ -1,2 +1,2 @@ f\n a\n-b\n+c\n\\ No newline at end of file\n")
+	f.Add("diff --git a/a b/b\nsimilarity index 90%\nrename from a\nrename to b\n")
+	f.Add("diff --git a/s b/s\nold mode 100644\nnew mode 100755\n")
+	f.Add("diff --git a/i b/i\nnew file mode 100644\nBinary files /dev/null and b/i differ\n")
+	f.Add("diff --git \"a/\\303\\274\" \"b/\\303\\274\"\ndeleted file mode 100644\n--- \"a/\\303\\274\"\n+++ /dev/null\n@@ -1 +0,0 @@\n-x\n")
+	f.Add("--- x.orig\t2024-01-01\n+++ x\n@@ -0,0 +1 @@\n+new\n")
+	f.Add("Subject: hi\n---\n diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\n+b\n-- \n2.40\n")
+
+	f.Fuzz(func(t *testing.T, diff string) {
+		files, err := ParseDiff(diff)
+		if err != nil {
+			return
+		}
+		checkLineNumbers(t, files)
+
+		rendered := FormatDiff(files)
+		reparsed, err := ParseDiff(rendered)
+		if err != nil {
+			t.Fatalf("rendered diff does not parse: %v\n%s", err, rendered)
+		}
+		if again := FormatDiff(reparsed); again != rendered {
+			t.Fatalf("rendering is not stable\nfirst:\n%s\nsecond:\n%s", rendered, again)
+		}
+	})
+}
+
+// FuzzParseDiffGit diffs two random file versions with git and checks that
+// the parsed line numbers point at the right lines of both versions.
+func FuzzParseDiffGit(f *testing.F) {
+	if _, err := exec.LookPath("git"); err != nil {
+		f.Skip("git is not installed")
+	}
+	f.Add("a\nb\nc\n", "a\nB\nc\nd\n")
+	f.Add("", "new\n")
+	f.Add("gone\n", "")
+	f.Add("no newline", "no newline\n")
+	f.Add("x\r\ny\r\n", "x\r\nz\r\n")
+	f.Add("--- a\n+++ b\n@@ -1 +1 @@\n", "diff --git a/x b/x\n")
+	f.Add("bin\x00ary", "bin\x00ary2")
+
+	f.Fuzz(func(t *testing.T, oldText, newText string) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "old"), []byte(oldText), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "new"), []byte(newText), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := exec.Command("git", "diff", "--no-index", "--no-color", "--no-ext-diff", "old", "new")
+		cmd.Dir = dir
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+			t.Fatalf("git diff: %v", err)
+		}
+		out := stdout.String()
+
+		files, err := ParseDiff(out)
+		if err != nil {
+			t.Fatalf("ParseDiff: %v\n%s", err, out)
+		}
+		if oldText == newText {
+			if len(files) != 0 {
+				t.Fatalf("identical files produced %d file diffs", len(files))
+			}
+			return
+		}
+		if len(files) != 1 {
+			t.Fatalf("got %d files, want 1:\n%s", len(files), out)
+		}
+		if got := FormatDiff(files); got != out {
+			t.Fatalf("FormatDiff does not reproduce git output\ngot:\n%q\nwant:\n%q", got, out)
+		}
+		if files[0].Binary {
+			return
+		}
+
+		oldLines, newLines := splitContent(oldText), splitContent(newText)
+		for _, h := range files[0].Hunks {
+			for _, l := range h.Lines {
+				if l.OldLine > 0 && (l.OldLine > len(oldLines) || oldLines[l.OldLine-1] != l.Text) {
+					t.Fatalf("old line %d is %q in the diff", l.OldLine, l.Text)
+				}
+				if l.NewLine > 0 && (l.NewLine > len(newLines) || newLines[l.NewLine-1] != l.Text) {
+					t.Fatalf("new line %d is %q in the diff", l.NewLine, l.Text)
+				}
+			}
+		}
+	})
+}
+
+// checkLineNumbers verifies the line counters of every hunk.
+func checkLineNumbers(t *testing.T, files []*FileDiff) {
+	t.Helper()
+
+	for _, f := range files {
+		for _, h := range f.Hunks {
+			var oldCount, newCount int
+			for _, l := range h.Lines {
+				if l.OldLine != 0 {
+					if l.OldLine != h.OldStart+oldCount {
+						t.Fatalf("%s: old line %d, want %d", f.Name(), l.OldLine, h.OldStart+oldCount)
+					}
+					oldCount++
+				}
+				if l.NewLine != 0 {
+					if l.NewLine != h.NewStart+newCount {
+						t.Fatalf("%s: new line %d, want %d", f.Name(), l.NewLine, h.NewStart+newCount)
+					}
+					newCount++
+				}
+			}
+			if oldCount != h.OldLines || newCount != h.NewLines {
+				t.Fatalf("%s: hunk has %d/%d lines, header says %d/%d", f.Name(), oldCount, newCount, h.OldLines, h.NewLines)
+			}
+		}
+	}
+}
+
+// splitContent splits file content into lines the way a diff numbers them.
+func splitContent(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// writeFiles writes every file of the map, relative to the working directory.
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os/exec"
+	"path"
 	"strconv"
 	"strings"
 )
@@ -147,14 +148,18 @@ func (cmd *Command) diffNameArgs() []string {
 	return args
 }
 
-// diffFilesArgs builds git diff arguments with ReviewBot defaults.
-func (cmd *Command) diffFilesArgs() []string {
-	args := []string{
-		"diff",
+// diffOptionArgs returns the diff flags shared by every diff mode.
+func (cmd *Command) diffOptionArgs() []string {
+	return []string{
 		"--ignore-all-space",
 		"--diff-algorithm=minimal",
 		"--unified=" + strconv.Itoa(cmd.diffUnified),
 	}
+}
+
+// diffFilesArgs builds git diff arguments with ReviewBot defaults.
+func (cmd *Command) diffFilesArgs() []string {
+	args := append([]string{"diff"}, cmd.diffOptionArgs()...)
 
 	if cmd.isAmend {
 		args = append(args, "HEAD^", "HEAD")
@@ -209,6 +214,99 @@ func (cmd *Command) DiffFiles() (string, error) {
 	return run(cmd.diffFilesArgs()...)
 }
 
+// DiffRange returns the changes on head since it diverged from base, that
+// is the diff between their merge base and head ("git diff base...head").
+// An empty head means HEAD.
+func (cmd *Command) DiffRange(base, head string) (string, error) {
+	if base == "" {
+		return "", errors.New("base revision is required")
+	}
+	if head == "" {
+		head = "HEAD"
+	}
+
+	args := append([]string{"diff"}, cmd.diffOptionArgs()...)
+	args = append(args, base+"..."+head, "--")
+	args = append(args, cmd.excludedFiles()...)
+
+	diff, err := run(args...)
+	if err != nil {
+		return "", err
+	}
+	if diff == "" {
+		return "", fmt.Errorf("no changes between %s and %s", base, head)
+	}
+	return diff, nil
+}
+
+// CommitInfo identifies a commit of a range.
+type CommitInfo struct {
+	Hash    string
+	Subject string
+}
+
+// ShortHash returns the abbreviated commit hash.
+func (c CommitInfo) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// RangeCommits lists the non-merge commits reachable from head but not from
+// base, oldest first. An empty head means HEAD.
+func (cmd *Command) RangeCommits(base, head string) ([]CommitInfo, error) {
+	if base == "" {
+		return nil, errors.New("base revision is required")
+	}
+	if head == "" {
+		head = "HEAD"
+	}
+
+	out, err := run("log", "--reverse", "--no-merges", "--format=%H%x1f%s", base+".."+head)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, fmt.Errorf("no commits between %s and %s", base, head)
+	}
+
+	var commits []CommitInfo
+	for _, line := range strings.Split(out, "\n") {
+		hash, subject, _ := strings.Cut(line, "\x1f")
+		commits = append(commits, CommitInfo{Hash: hash, Subject: subject})
+	}
+	return commits, nil
+}
+
+// DiffCommit returns the changes introduced by a single commit. It returns
+// an empty diff when the commit only touches excluded files.
+func (cmd *Command) DiffCommit(rev string) (string, error) {
+	args := append([]string{"show", "--format="}, cmd.diffOptionArgs()...)
+	args = append(args, rev, "--")
+	args = append(args, cmd.excludedFiles()...)
+	return run(args...)
+}
+
+// ParseRange splits a revision range of the form "base..head" or
+// "base...head" into its ends. Both forms select the merge-base diff; an
+// empty head means HEAD.
+func ParseRange(spec string) (base, head string, err error) {
+	spec = strings.TrimSpace(spec)
+	sep := "..."
+	if !strings.Contains(spec, sep) {
+		sep = ".."
+	}
+	base, head, found := strings.Cut(spec, sep)
+	if !found || base == "" {
+		return "", "", fmt.Errorf("invalid revision range %q, expected base..head", spec)
+	}
+	if head == "" {
+		head = "HEAD"
+	}
+	return base, head, nil
+}
+
 // Add stages the provided paths using git add. Paths must be non-empty.
 func (cmd *Command) Add(paths ...string) (string, error) {
 	if len(paths) == 0 {
@@ -236,3 +334,36 @@ func (cfg *Config) New() *Command {
 
 	return command
 }
+
+// DefaultExcludes returns a copy of the patterns that are always excluded from diffs.
+func DefaultExcludes() []string {
+	return append([]string(nil), excludeFromDiff...)
+}
+
+// MatchExclude reports whether name matches an exclusion pattern. Patterns
+// follow the subset of pathspec globs used in the exclude lists: a leading
+// "**/" matches at any depth and a trailing "/" matches everything below a
+// directory. Other patterns are anchored at the repository root.
+func MatchExclude(pattern, name string) bool {
+	dir := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	anyDepth := strings.HasPrefix(pattern, "**/")
+	pattern = strings.TrimPrefix(pattern, "**/")
+
+	segs := strings.Split(name, "/")
+	for start := 0; start < len(segs); start++ {
+		if start > 0 && !anyDepth {
+			break
+		}
+		for end := start + 1; end <= len(segs); end++ {
+			// a directory pattern must match a parent of the file, a file pattern the file itself
+			if dir == (end == len(segs)) {
+				continue
+			}
+			if ok, _ := path.Match(pattern, strings.Join(segs[start:end], "/")); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -125,3 +125,172 @@ func gitRun(t *testing.T, args ...string) string {
 	}
 	return out
 }
+
+// TestMatchExclude covers the glob forms used by the exclude lists.
+func TestMatchExclude(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"go.sum", "go.sum", true},
+		{"go.sum", "sub/go.sum", false},
+		{"**/*.pyc", "a/b/c.pyc", true},
+		{"**/*.pyc", "c.pyc", true},
+		{"**/node_modules/", "web/node_modules/react/index.js", true},
+		{"**/node_modules/", "node_modules", false},
+		{"**/dist/", "dist/app.js", true},
+		{"docs/*.md", "docs/a.md", true},
+		{"docs/*.md", "docs/sub/a.md", false},
+		{"**/*.log", "app.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchExclude(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchExclude(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestCommandDiffRange verifies the merge-base diff skips upstream and excluded changes.
+func TestCommandDiffRange(t *testing.T) {
+	setupBranches(t)
+
+	cmd := (&Config{DiffUnified: 3, ExcludedList: []string{"go.sum"}}).New()
+	diff, err := cmd.DiffRange("main", "feature")
+	if err != nil {
+		t.Fatalf("DiffRange: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if !strings.Contains(diff, name) {
+			t.Errorf("diff should include %s, got: %q", name, diff)
+		}
+	}
+	for _, name := range []string{"main.txt", "go.sum"} {
+		if strings.Contains(diff, name) {
+			t.Errorf("diff should not include %s, got: %q", name, diff)
+		}
+	}
+
+	if _, err := cmd.DiffRange("feature", "feature"); err == nil {
+		t.Fatalf("expected error for an empty range")
+	}
+}
+
+// TestCommandRangeCommits lists the branch commits oldest first.
+func TestCommandRangeCommits(t *testing.T) {
+	setupBranches(t)
+
+	cmd := (&Config{DiffUnified: 3}).New()
+	commits, err := cmd.RangeCommits("main", "")
+	if err != nil {
+		t.Fatalf("RangeCommits: %v", err)
+	}
+
+	var subjects []string
+	for _, c := range commits {
+		if len(c.ShortHash()) != 7 {
+			t.Errorf("unexpected short hash %q", c.ShortHash())
+		}
+		subjects = append(subjects, c.Subject)
+	}
+	want := "add a|bump deps|add b"
+	if got := strings.Join(subjects, "|"); got != want {
+		t.Fatalf("subjects = %q, want %q", got, want)
+	}
+
+	if _, err := cmd.RangeCommits("HEAD", "HEAD"); err == nil {
+		t.Fatalf("expected error for a range without commits")
+	}
+}
+
+// TestCommandDiffCommit checks single-commit diffs and exclusions.
+func TestCommandDiffCommit(t *testing.T) {
+	setupBranches(t)
+
+	cmd := (&Config{DiffUnified: 3, ExcludedList: []string{"go.sum"}}).New()
+	commits, err := cmd.RangeCommits("main", "feature")
+	if err != nil {
+		t.Fatalf("RangeCommits: %v", err)
+	}
+
+	diff, err := cmd.DiffCommit(commits[0].Hash)
+	if err != nil {
+		t.Fatalf("DiffCommit: %v", err)
+	}
+	if !strings.Contains(diff, "a.txt") || strings.Contains(diff, "b.txt") {
+		t.Fatalf("diff should only include a.txt, got: %q", diff)
+	}
+
+	diff, err = cmd.DiffCommit(commits[1].Hash)
+	if err != nil {
+		t.Fatalf("DiffCommit: %v", err)
+	}
+	if diff != "" {
+		t.Fatalf("excluded-only commit should have an empty diff, got: %q", diff)
+	}
+
+	// the root commit has no parent to diff against
+	root := gitRun(t, "rev-list", "--max-parents=0", "HEAD")
+	if diff, err := cmd.DiffCommit(root); err != nil || !strings.Contains(diff, "base.txt") {
+		t.Fatalf("DiffCommit(root) = %q, %v", diff, err)
+	}
+}
+
+// TestParseRange covers the two- and three-dot forms.
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		spec       string
+		base, head string
+		wantErr    bool
+	}{
+		{spec: "main..feature", base: "main", head: "feature"},
+		{spec: "main...feature", base: "main", head: "feature"},
+		{spec: "origin/main..", base: "origin/main", head: "HEAD"},
+		{spec: " v1.0...HEAD~2 ", base: "v1.0", head: "HEAD~2"},
+		{spec: "main", wantErr: true},
+		{spec: "..feature", wantErr: true},
+	}
+	for _, tt := range tests {
+		base, head, err := ParseRange(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseRange(%q) expected error", tt.spec)
+			}
+			continue
+		}
+		if err != nil || base != tt.base || head != tt.head {
+			t.Errorf("ParseRange(%q) = %q, %q, %v, want %q, %q", tt.spec, base, head, err, tt.base, tt.head)
+		}
+	}
+}
+
+// setupBranches creates a repository with a feature branch of three commits
+// on top of main, which has moved on by one commit since.
+func setupBranches(t *testing.T) {
+	t.Helper()
+
+	setupRepo(t)
+	commitFile(t, "base.txt", "base", "initial commit")
+	gitRun(t, "branch", "-M", "main")
+
+	gitRun(t, "checkout", "-b", "feature")
+	commitFile(t, "a.txt", "a", "add a")
+	commitFile(t, "go.sum", "sum", "bump deps")
+	commitFile(t, "b.txt", "b", "add b")
+
+	gitRun(t, "checkout", "main")
+	commitFile(t, "main.txt", "main", "upstream change")
+	gitRun(t, "checkout", "feature")
+}
+
+// commitFile writes and commits a single file.
+func commitFile(t *testing.T, name, content, message string) {
+	t.Helper()
+
+	if err := os.WriteFile(name, []byte(content+"\n"), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	gitRun(t, "add", name)
+	gitRun(t, "commit", "-m", message)
+}
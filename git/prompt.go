@@ -10,6 +10,7 @@ const (
 	CommitMessagePrefix  = "commit_message_prefix"
 	CommitMessageTitle   = "commit_message_title"
 	CommitMessageSummary = "commit_message_summary"
+	CommitMessageFooter  = "commit_message_footer"
 )
 
 //go:embed template/*
@@ -0,0 +1,202 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// binarySniffLen is the number of leading bytes git inspects for NUL bytes
+// when it decides whether a file is binary.
+const binarySniffLen = 8000
+
+// ErrInputTooLarge is returned when a diff reaches the input size limit.
+var ErrInputTooLarge = errors.New("git diff input size exceeds limit")
+
+// DiffWorktree returns the unstaged changes of the working tree. With
+// untracked set, files unknown to git that are not ignored are appended as
+// new-file diffs. Exclusions apply to both. A diff reaching maxSize bytes
+// fails with ErrInputTooLarge, and untracked files stop being read once the
+// limit is reached; zero disables the limit.
+func (cmd *Command) DiffWorktree(untracked bool, maxSize int) (string, error) {
+	args := append([]string{"diff"}, cmd.diffOptionArgs()...)
+	args = append(args, "--")
+	args = append(args, cmd.excludedFiles()...)
+
+	diff, err := run(args...)
+	if err != nil {
+		return "", err
+	}
+
+	if maxSize > 0 && len(diff) >= maxSize {
+		return "", ErrInputTooLarge
+	}
+	parts := []string{}
+	size := len(diff)
+	if diff != "" {
+		parts = append(parts, diff)
+	}
+
+	if untracked {
+		files, err := cmd.UntrackedFiles()
+		if err != nil {
+			return "", err
+		}
+		root, err := run("rev-parse", "--show-toplevel")
+		if err != nil {
+			return "", err
+		}
+		for _, name := range files {
+			limit := 0
+			if maxSize > 0 {
+				limit = maxSize - size
+			}
+			fileDiff, err := newFileDiff(root, name, limit)
+			if err != nil {
+				return "", err
+			}
+			// parts are joined with a newline
+			size += len(fileDiff) + 1
+			if maxSize > 0 && size >= maxSize {
+				return "", ErrInputTooLarge
+			}
+			parts = append(parts, fileDiff)
+		}
+	}
+
+	if len(parts) == 0 {
+		if untracked {
+			return "", errors.New("no unstaged changes or untracked files to review")
+		}
+		return "", errors.New("no unstaged changes to review, use --untracked to include new files")
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// UntrackedFiles lists the files unknown to git that are not ignored, relative
+// to the repository root.
+func (cmd *Command) UntrackedFiles() ([]string, error) {
+	args := []string{"ls-files", "--others", "--exclude-standard", "--full-name", "-z", "--", ":(top)"}
+	args = append(args, cmd.excludedFiles()...)
+
+	out, err := run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// newFileDiff renders an untracked file as the diff git would show once it is
+// added: a single hunk of added lines, or a binary marker when the file holds
+// NUL bytes. Files of limit bytes or more are not read to the end and fail
+// with ErrInputTooLarge; zero disables the limit.
+func newFileDiff(root, name string, limit int) (string, error) {
+	full := filepath.Join(root, filepath.FromSlash(name))
+	info, err := os.Lstat(full)
+	if err != nil {
+		return "", err
+	}
+
+	mode := "100644"
+	var content []byte
+	if info.Mode()&os.ModeSymlink != 0 {
+		mode = "120000"
+		target, err := os.Readlink(full)
+		if err != nil {
+			return "", err
+		}
+		content = []byte(target)
+	} else {
+		if info.Mode()&0o111 != 0 {
+			mode = "100755"
+		}
+		var binary bool
+		content, binary, err = readText(full, limit)
+		if err != nil {
+			return "", err
+		}
+		if binary {
+			return fmt.Sprintf("diff --git a/%s b/%s\nnew file mode %s\nBinary files /dev/null and b/%s differ", name, name, mode, name), nil
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\nnew file mode %s", name, name, mode)
+	if len(content) == 0 {
+		return b.String(), nil
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	fmt.Fprintf(&b, "\n--- /dev/null\n+++ b/%s\n@@ -0,0 +1", name)
+	if len(lines) != 1 {
+		fmt.Fprintf(&b, ",%d", len(lines))
+	}
+	b.WriteString(" @@")
+	for _, line := range lines {
+		b.WriteString("\n+")
+		b.WriteString(strings.TrimSuffix(line, "\n"))
+	}
+	if !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		b.WriteString("\n\\ No newline at end of file")
+	}
+	return b.String(), nil
+}
+
+// readText reads a file unless its first bytes mark it as binary, in which
+// case only binary is reported. At most limit bytes are read, a file
+// reaching them fails with ErrInputTooLarge; zero disables the limit.
+func readText(name string, limit int) (content []byte, binary bool, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	head := make([]byte, binarySniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, false, err
+	}
+	head = head[:n]
+	if isBinary(head) {
+		return nil, true, nil
+	}
+
+	var r io.Reader = f
+	if limit > 0 {
+		if len(head) >= limit {
+			return nil, false, ErrInputTooLarge
+		}
+		r = io.LimitReader(f, int64(limit-len(head)))
+	}
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		return nil, false, err
+	}
+	content = append(head, rest...)
+	if limit > 0 && len(content) >= limit {
+		return nil, false, ErrInputTooLarge
+	}
+	return content, false, nil
+}
+
+// isBinary applies git's heuristic: data containing a NUL byte is binary.
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data, 0) >= 0
+}
@@ -0,0 +1,147 @@
+package git
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestCommandDiffWorktree verifies unstaged changes are returned without staged ones.
+func TestCommandDiffWorktree(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "foo.txt", "hello", "initial commit")
+	commitFile(t, "go.sum", "sum", "add go.sum")
+
+	if err := os.WriteFile("foo.txt", []byte("hello\nworld\n"), 0o644); err != nil {
+		t.Fatalf("write foo.txt: %v", err)
+	}
+	if err := os.WriteFile("go.sum", []byte("changed\n"), 0o644); err != nil {
+		t.Fatalf("write go.sum: %v", err)
+	}
+	if err := os.WriteFile("staged.txt", []byte("staged\n"), 0o644); err != nil {
+		t.Fatalf("write staged.txt: %v", err)
+	}
+	gitRun(t, "add", "staged.txt")
+
+	cmd := (&Config{DiffUnified: 3, ExcludedList: []string{"go.sum"}}).New()
+	diff, err := cmd.DiffWorktree(false, 0)
+	if err != nil {
+		t.Fatalf("DiffWorktree: %v", err)
+	}
+	if !strings.Contains(diff, "+world") {
+		t.Errorf("diff should include the unstaged change, got: %q", diff)
+	}
+	if strings.Contains(diff, "staged.txt") || strings.Contains(diff, "go.sum") {
+		t.Errorf("diff should skip staged and excluded files, got: %q", diff)
+	}
+}
+
+// TestCommandDiffWorktreeUntracked checks synthetic diffs for new files.
+func TestCommandDiffWorktreeUntracked(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "foo.txt", "hello", "initial commit")
+
+	files := map[string]string{
+		".gitignore":     "ignored.txt\n",
+		"ignored.txt":    "ignored\n",
+		"dir/new.go":     "package dir\n\nfunc A() {}\n",
+		"noeol.txt":      "one\ntwo",
+		"empty.txt":      "",
+		"image.png":      "\x89PNG\x00\x01",
+		"logs/debug.log": "excluded by default\n",
+	}
+	for name, content := range files {
+		if dir, _, ok := strings.Cut(name, "/"); ok {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				t.Fatalf("mkdir %s: %v", dir, err)
+			}
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	cmd := (&Config{DiffUnified: 3}).New()
+	if _, err := cmd.DiffWorktree(false, 0); err == nil {
+		t.Fatalf("expected error without unstaged changes")
+	}
+
+	diff, err := cmd.DiffWorktree(true, 0)
+	if err != nil {
+		t.Fatalf("DiffWorktree: %v", err)
+	}
+
+	wants := []string{
+		"diff --git a/dir/new.go b/dir/new.go\nnew file mode 100644\n--- /dev/null\n+++ b/dir/new.go\n@@ -0,0 +1,3 @@\n+package dir\n+\n+func A() {}",
+		"+++ b/noeol.txt\n@@ -0,0 +1,2 @@\n+one\n+two\n\\ No newline at end of file",
+		"diff --git a/empty.txt b/empty.txt\nnew file mode 100644",
+		"Binary files /dev/null and b/image.png differ",
+	}
+	for _, want := range wants {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff should contain %q, got: %q", want, diff)
+		}
+	}
+	for _, skip := range []string{"ignored.txt\n+", "b/ignored.txt", "debug.log"} {
+		if strings.Contains(diff, skip) {
+			t.Errorf("diff should not contain %q, got: %q", skip, diff)
+		}
+	}
+}
+
+// TestNewFileDiffMatchesGit compares a synthetic diff with git's own output.
+func TestNewFileDiffMatchesGit(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "foo.txt", "hello", "initial commit")
+
+	if err := os.WriteFile("run.sh", []byte("#!/bin/sh\necho hi\n"), 0o755); err != nil {
+		t.Fatalf("write run.sh: %v", err)
+	}
+	root := gitRun(t, "rev-parse", "--show-toplevel")
+	synthetic, err := newFileDiff(root, "run.sh", 0)
+	if err != nil {
+		t.Fatalf("newFileDiff: %v", err)
+	}
+
+	gitRun(t, "add", "run.sh")
+	actual := gitRun(t, "diff", "--staged", "run.sh")
+	// git additionally prints the blob ids
+	var lines []string
+	for _, line := range strings.Split(actual, "\n") {
+		if !strings.HasPrefix(line, "index ") {
+			lines = append(lines, line)
+		}
+	}
+	if want := strings.Join(lines, "\n"); synthetic != want {
+		t.Fatalf("synthetic diff:\n%s\nwant:\n%s", synthetic, want)
+	}
+}
+
+// TestCommandDiffWorktreeMaxSize checks the limit covers untracked files.
+func TestCommandDiffWorktreeMaxSize(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "foo.txt", "hello", "initial commit")
+
+	if err := os.WriteFile("foo.txt", []byte("hello\nworld\n"), 0o644); err != nil {
+		t.Fatalf("write foo.txt: %v", err)
+	}
+	if err := os.WriteFile("big.txt", []byte(strings.Repeat("line\n", 20000)), 0o644); err != nil {
+		t.Fatalf("write big.txt: %v", err)
+	}
+
+	cmd := (&Config{DiffUnified: 3}).New()
+	if _, err := cmd.DiffWorktree(false, 1024); err != nil {
+		t.Fatalf("DiffWorktree: %v", err)
+	}
+	if _, err := cmd.DiffWorktree(true, 1024); err != ErrInputTooLarge {
+		t.Fatalf("expected ErrInputTooLarge with untracked files, got %v", err)
+	}
+	if _, err := cmd.DiffWorktree(false, 10); err != ErrInputTooLarge {
+		t.Fatalf("expected ErrInputTooLarge for the tracked diff, got %v", err)
+	}
+
+	root := gitRun(t, "rev-parse", "--show-toplevel")
+	if _, err := newFileDiff(root, "big.txt", 1024); err != ErrInputTooLarge {
+		t.Fatalf("expected ErrInputTooLarge from newFileDiff, got %v", err)
+	}
+}
@@ -0,0 +1,330 @@
+package anthropic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/proxy"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultBaseURL = "https://api.anthropic.com"
+	apiVersion     = "2023-06-01"
+)
+
+var _ ai.TextGenerator = (*Client)(nil)
+
+type Client struct {
+	httpClient  *http.Client
+	endpoint    string
+	apiKey      string
+	model       string
+	maxTokens   int
+	temperature float32
+	topP        float32
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type messagesRequest struct {
+	Model       string    `json:"model"`
+	MaxTokens   int       `json:"max_tokens"`
+	System      string    `json:"system,omitempty"`
+	Messages    []message `json:"messages"`
+	Temperature *float32  `json:"temperature,omitempty"`
+	TopP        *float32  `json:"top_p,omitempty"`
+	Stop        []string  `json:"stop_sequences,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
+}
+
+type contentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type usage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+type messagesResponse struct {
+	ID         string         `json:"id"`
+	Model      string         `json:"model"`
+	Content    []contentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      usage          `json:"usage"`
+}
+
+// streamEvent is the union of the SSE payloads emitted by the Messages API.
+type streamEvent struct {
+	Type    string            `json:"type"`
+	Message *messagesResponse `json:"message,omitempty"`
+	Delta   *struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta,omitempty"`
+	Usage *usage    `json:"usage,omitempty"`
+	Error *apiError `json:"error,omitempty"`
+}
+
+type apiError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// APIError is returned when the Messages API responds with an error payload.
+type APIError struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("anthropic: %s: %s", e.Type, e.Message)
+	}
+	return fmt.Sprintf("anthropic: status %d: %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+func (c *Client) ChatCompletion(ctx context.Context, text string) (*ai.Response, error) {
+	return c.Chat(ctx, ai.NewRequest(text))
+}
+
+// StreamChatCompletion streams the chat completion response.
+func (c *Client) StreamChatCompletion(ctx context.Context, text string, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	return c.StreamChat(ctx, ai.NewRequest(text), handler)
+}
+
+func (c *Client) Chat(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+	resp, err := c.do(ctx, c.newRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var result messagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("anthropic: decode response: %w", err)
+	}
+
+	var sb strings.Builder
+	for _, block := range result.Content {
+		if block.Type == "text" {
+			sb.WriteString(block.Text)
+		}
+	}
+
+	return &ai.Response{
+		Text:       sb.String(),
+		TokenUsage: toTokenUsage(result.Usage),
+	}, nil
+}
+
+// StreamChat streams the response to a multi-message request over SSE and
+// returns the usage accumulated from message_start and message_delta events.
+func (c *Client) StreamChat(ctx context.Context, req *ai.Request, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	resp, err := c.do(ctx, c.newRequest(req, true))
+	if err != nil {
+		return ai.TokenUsage{}, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var u usage
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" {
+			continue
+		}
+
+		var event streamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return ai.TokenUsage{}, fmt.Errorf("anthropic: decode stream event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				u = event.Message.Usage
+			}
+		case "content_block_delta":
+			if event.Delta != nil && event.Delta.Type == "text_delta" {
+				if err := handler(event.Delta.Text); err != nil {
+					return ai.TokenUsage{}, err
+				}
+			}
+		case "message_delta":
+			if event.Usage != nil {
+				u.OutputTokens = event.Usage.OutputTokens
+			}
+		case "error":
+			if event.Error != nil {
+				return ai.TokenUsage{}, &APIError{Type: event.Error.Type, Message: event.Error.Message}
+			}
+			return ai.TokenUsage{}, &APIError{Type: "error", Message: data}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ai.TokenUsage{}, err
+	}
+
+	return toTokenUsage(u), nil
+}
+
+func (c *Client) newRequest(req *ai.Request, stream bool) *messagesRequest {
+	conversation := req.Conversation()
+	messages := make([]message, 0, len(conversation))
+	for _, m := range conversation {
+		role := "user"
+		if m.Role == ai.RoleAssistant {
+			role = "assistant"
+		}
+		messages = append(messages, message{Role: role, Content: m.Content})
+	}
+
+	body := &messagesRequest{
+		Model:     c.model,
+		MaxTokens: req.MaxTokensOr(c.maxTokens),
+		System:    req.SystemPrompt(),
+		Messages:  messages,
+		Stop:      req.Stop,
+		Stream:    stream,
+	}
+	// The Messages API rejects some models when both sampling knobs are set,
+	// so top_p is only forwarded when it narrows the distribution.
+	temperature := req.TemperatureOr(c.temperature)
+	body.Temperature = &temperature
+	if topP := req.TopPOr(c.topP); topP > 0 && topP < 1 {
+		body.TopP = &topP
+	}
+	return body
+}
+
+func (c *Client) do(ctx context.Context, body *messagesRequest) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", apiVersion)
+	if body.Stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		return nil, decodeError(resp)
+	}
+
+	return resp, nil
+}
+
+// decodeError converts a non-2xx response into an *APIError.
+func decodeError(resp *http.Response) error {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var payload struct {
+		Error apiError `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil || payload.Error.Message == "" {
+		return &APIError{
+			StatusCode: resp.StatusCode,
+			Type:       http.StatusText(resp.StatusCode),
+			Message:    strings.TrimSpace(string(raw)),
+		}
+	}
+
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Type:       payload.Error.Type,
+		Message:    payload.Error.Message,
+	}
+}
+
+// toTokenUsage maps Messages API usage into ai.TokenUsage. Anthropic reports
+// cache reads and writes separately from input_tokens, so they are folded
+// back into the prompt total.
+func toTokenUsage(u usage) ai.TokenUsage {
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return ai.TokenUsage{
+		PromptTokens:     prompt,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      prompt + u.OutputTokens,
+		PromptTokensDetails: &openai.PromptTokensDetails{
+			CachedTokens: u.CacheReadInputTokens,
+		},
+		CacheWriteTokens: u.CacheCreationInputTokens,
+	}
+}
+
+type Config struct {
+	BaseURL     string
+	ApiKey      string
+	Model       string
+	MaxTokens   int
+	Temperature float32
+	TopP        float32
+}
+
+func (cfg *Config) New(proxyCfg *proxy.Config) (*Client, error) {
+	httpClient, err := proxyCfg.New()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		httpClient:  httpClient,
+		endpoint:    messagesEndpoint(cfg.BaseURL),
+		apiKey:      cfg.ApiKey,
+		model:       cfg.Model,
+		maxTokens:   cfg.MaxTokens,
+		temperature: cfg.Temperature,
+		topP:        cfg.TopP,
+	}, nil
+}
+
+// messagesEndpoint accepts base URLs both with and without the /v1 suffix.
+func messagesEndpoint(baseURL string) string {
+	base := strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if base == "" {
+		base = defaultBaseURL
+	}
+	if strings.HasSuffix(base, "/v1") {
+		return base + "/messages"
+	}
+	return base + "/v1/messages"
+}
@@ -0,0 +1,219 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg := Config{
+		BaseURL:     server.URL,
+		ApiKey:      "sk-ant-test",
+		Model:       "claude-sonnet-4-5",
+		MaxTokens:   512,
+		Temperature: 0.2,
+		TopP:        1.0,
+	}
+	client, err := cfg.New(&proxy.Config{})
+	require.NoError(t, err)
+	return client
+}
+
+func TestConfig_New(t *testing.T) {
+	tests := []struct {
+		name         string
+		baseURL      string
+		wantEndpoint string
+	}{
+		{
+			name:         "default base URL",
+			baseURL:      "",
+			wantEndpoint: "https://api.anthropic.com/v1/messages",
+		},
+		{
+			name:         "custom base URL without version",
+			baseURL:      "https://gateway.example.com/",
+			wantEndpoint: "https://gateway.example.com/v1/messages",
+		},
+		{
+			name:         "custom base URL with version",
+			baseURL:      "https://gateway.example.com/v1",
+			wantEndpoint: "https://gateway.example.com/v1/messages",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{BaseURL: tt.baseURL, ApiKey: "k", Model: "claude", MaxTokens: 10}
+			client, err := cfg.New(&proxy.Config{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEndpoint, client.endpoint)
+			assert.Equal(t, "claude", client.model)
+			assert.Equal(t, 10, client.maxTokens)
+		})
+	}
+}
+
+func TestClient_ChatCompletion(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "sk-ant-test", r.Header.Get("x-api-key"))
+		assert.Equal(t, apiVersion, r.Header.Get("anthropic-version"))
+
+		var req messagesRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "claude-sonnet-4-5", req.Model)
+		assert.Equal(t, 512, req.MaxTokens)
+		assert.Equal(t, ai.DefaultSystemPrompt, req.System)
+		assert.False(t, req.Stream)
+		assert.Nil(t, req.TopP)
+		require.Len(t, req.Messages, 1)
+		assert.Equal(t, "user", req.Messages[0].Role)
+		assert.Equal(t, "review this", req.Messages[0].Content)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{
+			"id": "msg_1",
+			"model": "claude-sonnet-4-5",
+			"content": [{"type": "text", "text": "Looks "}, {"type": "text", "text": "good"}],
+			"stop_reason": "end_turn",
+			"usage": {
+				"input_tokens": 10,
+				"output_tokens": 5,
+				"cache_creation_input_tokens": 3,
+				"cache_read_input_tokens": 7
+			}
+		}`)
+	})
+
+	resp, err := client.ChatCompletion(context.Background(), "review this")
+	require.NoError(t, err)
+	assert.Equal(t, "Looks good", resp.Text)
+	assert.Equal(t, 20, resp.TokenUsage.PromptTokens)
+	assert.Equal(t, 5, resp.TokenUsage.CompletionTokens)
+	assert.Equal(t, 25, resp.TokenUsage.TotalTokens)
+	require.NotNil(t, resp.TokenUsage.PromptTokensDetails)
+	assert.Equal(t, 7, resp.TokenUsage.PromptTokensDetails.CachedTokens)
+	assert.Equal(t, 3, resp.TokenUsage.CacheWriteTokens)
+}
+
+func TestClient_Chat(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req messagesRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "Be terse.\n\nReply in English.", req.System)
+		assert.Equal(t, 64, req.MaxTokens)
+		assert.Equal(t, []string{"END"}, req.Stop)
+		require.NotNil(t, req.Temperature)
+		assert.Equal(t, float32(0), *req.Temperature)
+		require.Len(t, req.Messages, 3)
+		assert.Equal(t, "user", req.Messages[0].Role)
+		assert.Equal(t, "assistant", req.Messages[1].Role)
+		assert.Equal(t, "user", req.Messages[2].Role)
+
+		_, _ = fmt.Fprint(w, `{"content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":1,"output_tokens":1}}`)
+	})
+
+	temperature := float32(0)
+	resp, err := client.Chat(context.Background(), &ai.Request{
+		System: "Be terse.",
+		Messages: []ai.Message{
+			{Role: ai.RoleSystem, Content: "Reply in English."},
+			{Role: ai.RoleUser, Content: "example diff"},
+			{Role: ai.RoleAssistant, Content: "example review"},
+			{Role: ai.RoleUser, Content: "real diff"},
+		},
+		MaxTokens:   64,
+		Temperature: &temperature,
+		Stop:        []string{"END"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Text)
+}
+
+func TestClient_ChatCompletionError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = fmt.Fprint(w, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`)
+	})
+
+	_, err := client.ChatCompletion(context.Background(), "hi")
+	require.Error(t, err)
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.Equal(t, "rate_limit_error", apiErr.Type)
+	assert.Equal(t, "slow down", apiErr.Message)
+}
+
+func TestClient_StreamChatCompletion(t *testing.T) {
+	events := []string{
+		`event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":12,"output_tokens":1,"cache_read_input_tokens":4}}}`,
+		`event: ping
+data: {"type":"ping"}`,
+		`event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`,
+		`event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" world"}}`,
+		`event: content_block_stop
+data: {"type":"content_block_stop","index":0}`,
+		`event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":9}}`,
+		`event: message_stop
+data: {"type":"message_stop"}`,
+	}
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req messagesRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(t, req.Stream)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			_, _ = fmt.Fprint(w, e+"\n\n")
+		}
+	})
+
+	var chunks []string
+	usage, err := client.StreamChatCompletion(context.Background(), "hi", func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hello", " world"}, chunks)
+	assert.Equal(t, 16, usage.PromptTokens)
+	assert.Equal(t, 9, usage.CompletionTokens)
+	assert.Equal(t, 25, usage.TotalTokens)
+	assert.Equal(t, 4, usage.PromptTokensDetails.CachedTokens)
+}
+
+func TestClient_StreamChatCompletionErrorEvent(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	})
+
+	_, err := client.StreamChatCompletion(context.Background(), "hi", func(string) error { return nil })
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "overloaded_error", apiErr.Type)
+}
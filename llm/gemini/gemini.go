@@ -5,7 +5,6 @@ import (
 	"errors"
 	"io"
 
-	"github.com/fatih/color"
 	"github.com/loveRyujin/ReviewBot/ai"
 	"github.com/loveRyujin/ReviewBot/proxy"
 	"github.com/sashabaranov/go-openai"
@@ -23,70 +22,95 @@ type Client struct {
 }
 
 func (c *Client) ChatCompletion(ctx context.Context, text string) (*ai.Response, error) {
-	config := &genai.GenerateContentConfig{
-		Temperature:       &c.temperature,
-		TopP:              &c.topP,
-		MaxOutputTokens:   int32(c.maxTokens),
-		SystemInstruction: genai.NewContentFromText("You are a helpful assistant.", genai.RoleUser),
-	}
+	return c.Chat(ctx, ai.NewRequest(text))
+}
+
+func (c *Client) StreamChatCompletion(ctx context.Context, text string, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	return c.StreamChat(ctx, ai.NewRequest(text), handler)
+}
+
+func (c *Client) Chat(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+	contents, config := c.newRequest(req)
 
-	resp, err := c.client.Models.GenerateContent(ctx, c.model, genai.Text(text), config)
+	resp, err := c.client.Models.GenerateContent(ctx, c.model, contents, config)
 	if err != nil {
 		return nil, err
 	}
 
 	result := &ai.Response{Text: resp.Text()}
 	if resp.UsageMetadata != nil {
-		result.TokenUsage.PromptTokens = int(resp.UsageMetadata.PromptTokenCount)
-		result.TokenUsage.CompletionTokens = int(resp.UsageMetadata.CandidatesTokenCount)
-		result.TokenUsage.TotalTokens = int(resp.UsageMetadata.TotalTokenCount)
-		result.TokenUsage.PromptTokensDetails = &openai.PromptTokensDetails{
-			CachedTokens: int(resp.UsageMetadata.CachedContentTokenCount),
-		}
+		result.TokenUsage = toTokenUsage(resp.UsageMetadata)
 	}
 
 	return result, nil
 }
 
-func (c *Client) StreamChatCompletion(ctx context.Context, text string, handler ai.ChunkHandler) error {
-	config := &genai.GenerateContentConfig{
-		Temperature:       &c.temperature,
-		TopP:              &c.topP,
-		MaxOutputTokens:   int32(c.maxTokens),
-		SystemInstruction: genai.NewContentFromText("You are a helpful assistant.", genai.RoleUser),
-	}
-
-	stream := c.client.Models.GenerateContentStream(ctx, c.model, genai.Text(text), config)
+// StreamChat streams generated content and returns the usage of the last chunk.
+func (c *Client) StreamChat(ctx context.Context, req *ai.Request, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	contents, config := c.newRequest(req)
 
-	color.Yellow("================Review Summary====================\n\n")
+	stream := c.client.Models.GenerateContentStream(ctx, c.model, contents, config)
 
-	tokenUsage := &ai.TokenUsage{}
+	tokenUsage := ai.TokenUsage{}
 	for chunk, err := range stream {
 		if err != nil {
 			if errors.Is(err, io.EOF) {
-				color.Yellow("\n==================================================")
 				break
 			}
-			return err
+			return ai.TokenUsage{}, err
 		}
 
-		part := chunk.Candidates[0].Content.Parts[0]
-		if err := handler(part.Text); err != nil {
-			return err
+		if text := chunk.Text(); text != "" {
+			if err := handler(text); err != nil {
+				return ai.TokenUsage{}, err
+			}
 		}
 
 		if chunk.UsageMetadata != nil {
-			tokenUsage.PromptTokens = int(chunk.UsageMetadata.PromptTokenCount)
-			tokenUsage.CompletionTokens = int(chunk.UsageMetadata.CandidatesTokenCount)
-			tokenUsage.TotalTokens = int(chunk.UsageMetadata.TotalTokenCount)
-			tokenUsage.PromptTokensDetails = &openai.PromptTokensDetails{
-				CachedTokens: int(chunk.UsageMetadata.CachedContentTokenCount),
-			}
+			tokenUsage = toTokenUsage(chunk.UsageMetadata)
 		}
 	}
-	color.Magenta(tokenUsage.String())
 
-	return nil
+	return tokenUsage, nil
+}
+
+// newRequest converts an ai.Request into Gemini contents and generation config,
+// falling back to the client's sampling defaults for unset options.
+func (c *Client) newRequest(req *ai.Request) ([]*genai.Content, *genai.GenerateContentConfig) {
+	temperature := req.TemperatureOr(c.temperature)
+	topP := req.TopPOr(c.topP)
+	config := &genai.GenerateContentConfig{
+		Temperature:     &temperature,
+		TopP:            &topP,
+		MaxOutputTokens: int32(req.MaxTokensOr(c.maxTokens)),
+		StopSequences:   req.Stop,
+	}
+	if system := req.SystemPrompt(); system != "" {
+		config.SystemInstruction = genai.NewContentFromText(system, genai.RoleUser)
+	}
+
+	conversation := req.Conversation()
+	contents := make([]*genai.Content, 0, len(conversation))
+	for _, m := range conversation {
+		role := genai.Role(genai.RoleUser)
+		if m.Role == ai.RoleAssistant {
+			role = genai.RoleModel
+		}
+		contents = append(contents, genai.NewContentFromText(m.Content, role))
+	}
+
+	return contents, config
+}
+
+func toTokenUsage(md *genai.GenerateContentResponseUsageMetadata) ai.TokenUsage {
+	return ai.TokenUsage{
+		PromptTokens:     int(md.PromptTokenCount),
+		CompletionTokens: int(md.CandidatesTokenCount),
+		TotalTokens:      int(md.TotalTokenCount),
+		PromptTokensDetails: &openai.PromptTokensDetails{
+			CachedTokens: int(md.CachedContentTokenCount),
+		},
+	}
 }
 
 type Config struct {
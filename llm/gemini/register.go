@@ -0,0 +1,25 @@
+package gemini
+
+import (
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/loveRyujin/ReviewBot/proxy"
+)
+
+func init() {
+	ai.Register(ai.Gemini.String(), func(cfg config.AIConfig, proxyCfg *proxy.Config) (ai.TextGenerator, error) {
+		return FromAIConfig(cfg).New(proxyCfg)
+	})
+}
+
+// FromAIConfig maps AI settings onto a Gemini client configuration.
+func FromAIConfig(cfg config.AIConfig) *Config {
+	return &Config{
+		BaseURL:     cfg.BaseURL,
+		ApiKey:      cfg.APIKey,
+		Model:       cfg.Model,
+		MaxTokens:   cfg.MaxTokens,
+		Temperature: cfg.Temperature,
+		TopP:        cfg.TopP,
+	}
+}
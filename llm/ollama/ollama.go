@@ -0,0 +1,261 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/proxy"
+)
+
+const defaultBaseURL = "http://localhost:11434"
+
+var _ ai.TextGenerator = (*Client)(nil)
+
+type Client struct {
+	httpClient  *http.Client
+	endpoint    string
+	model       string
+	maxTokens   int
+	temperature float32
+	topP        float32
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type options struct {
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Temperature *float32 `json:"temperature,omitempty"`
+	TopP        *float32 `json:"top_p,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+type chatRequest struct {
+	Model    string    `json:"model"`
+	Messages []message `json:"messages"`
+	Stream   bool      `json:"stream"`
+	Options  options   `json:"options"`
+}
+
+type chatResponse struct {
+	Model           string  `json:"model"`
+	Message         message `json:"message"`
+	Done            bool    `json:"done"`
+	DoneReason      string  `json:"done_reason"`
+	PromptEvalCount int     `json:"prompt_eval_count"`
+	EvalCount       int     `json:"eval_count"`
+	Error           string  `json:"error"`
+}
+
+// APIError is returned when the Ollama server responds with an error.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return "ollama: " + e.Message
+	}
+	return fmt.Sprintf("ollama: status %d: %s", e.StatusCode, e.Message)
+}
+
+func (c *Client) ChatCompletion(ctx context.Context, text string) (*ai.Response, error) {
+	return c.Chat(ctx, ai.NewRequest(text))
+}
+
+// StreamChatCompletion streams the chat completion response.
+func (c *Client) StreamChatCompletion(ctx context.Context, text string, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	return c.StreamChat(ctx, ai.NewRequest(text), handler)
+}
+
+func (c *Client) Chat(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+	resp, err := c.do(ctx, c.newRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var result chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("ollama: decode response: %w", err)
+	}
+	if result.Error != "" {
+		return nil, &APIError{Message: result.Error}
+	}
+
+	return &ai.Response{
+		Text:       result.Message.Content,
+		TokenUsage: toTokenUsage(result),
+	}, nil
+}
+
+// StreamChat streams the NDJSON response to a multi-message request and
+// returns the eval counts from the final chunk.
+func (c *Client) StreamChat(ctx context.Context, req *ai.Request, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	resp, err := c.do(ctx, c.newRequest(req, true))
+	if err != nil {
+		return ai.TokenUsage{}, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	tokenUsage := ai.TokenUsage{}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk chatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return ai.TokenUsage{}, fmt.Errorf("ollama: decode stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return ai.TokenUsage{}, &APIError{Message: chunk.Error}
+		}
+
+		if chunk.Message.Content != "" {
+			if err := handler(chunk.Message.Content); err != nil {
+				return ai.TokenUsage{}, err
+			}
+		}
+
+		if chunk.Done {
+			tokenUsage = toTokenUsage(chunk)
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ai.TokenUsage{}, err
+	}
+
+	return tokenUsage, nil
+}
+
+func (c *Client) newRequest(req *ai.Request, stream bool) *chatRequest {
+	messages := make([]message, 0, len(req.Messages)+1)
+	if system := req.SystemPrompt(); system != "" {
+		messages = append(messages, message{Role: "system", Content: system})
+	}
+	for _, m := range req.Conversation() {
+		role := "user"
+		if m.Role == ai.RoleAssistant {
+			role = "assistant"
+		}
+		messages = append(messages, message{Role: role, Content: m.Content})
+	}
+
+	temperature := req.TemperatureOr(c.temperature)
+	body := &chatRequest{
+		Model:    c.model,
+		Messages: messages,
+		Stream:   stream,
+		Options: options{
+			NumPredict:  req.MaxTokensOr(c.maxTokens),
+			Temperature: &temperature,
+			Stop:        req.Stop,
+		},
+	}
+	if topP := req.TopPOr(c.topP); topP > 0 {
+		body.Options.TopP = &topP
+	}
+	return body
+}
+
+func (c *Client) do(ctx context.Context, body *chatRequest) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		return nil, decodeError(resp)
+	}
+
+	return resp, nil
+}
+
+// decodeError converts a non-2xx response into an *APIError.
+func decodeError(resp *http.Response) error {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var payload struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil || payload.Error == "" {
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(raw))}
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: payload.Error}
+}
+
+func toTokenUsage(resp chatResponse) ai.TokenUsage {
+	return ai.TokenUsage{
+		PromptTokens:     resp.PromptEvalCount,
+		CompletionTokens: resp.EvalCount,
+		TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+	}
+}
+
+type Config struct {
+	BaseURL     string
+	Model       string
+	MaxTokens   int
+	Temperature float32
+	TopP        float32
+}
+
+func (cfg *Config) New(proxyCfg *proxy.Config) (*Client, error) {
+	httpClient, err := proxyCfg.New()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		httpClient:  httpClient,
+		endpoint:    chatEndpoint(cfg.BaseURL),
+		model:       cfg.Model,
+		maxTokens:   cfg.MaxTokens,
+		temperature: cfg.Temperature,
+		topP:        cfg.TopP,
+	}, nil
+}
+
+// chatEndpoint accepts base URLs both with and without the /api suffix.
+func chatEndpoint(baseURL string) string {
+	base := strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if base == "" {
+		base = defaultBaseURL
+	}
+	if strings.HasSuffix(base, "/api") {
+		return base + "/chat"
+	}
+	return base + "/api/chat"
+}
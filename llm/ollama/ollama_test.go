@@ -0,0 +1,125 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg := Config{
+		BaseURL:     server.URL,
+		Model:       "llama3.1",
+		MaxTokens:   256,
+		Temperature: 0.3,
+		TopP:        0.9,
+	}
+	client, err := cfg.New(&proxy.Config{})
+	require.NoError(t, err)
+	return client
+}
+
+func TestConfig_New(t *testing.T) {
+	tests := []struct {
+		name         string
+		baseURL      string
+		wantEndpoint string
+	}{
+		{name: "default base URL", baseURL: "", wantEndpoint: "http://localhost:11434/api/chat"},
+		{name: "custom host", baseURL: "http://gpu-box:11434/", wantEndpoint: "http://gpu-box:11434/api/chat"},
+		{name: "custom host with api suffix", baseURL: "http://gpu-box:11434/api", wantEndpoint: "http://gpu-box:11434/api/chat"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := (&Config{BaseURL: tt.baseURL, Model: "llama3.1"}).New(&proxy.Config{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEndpoint, client.endpoint)
+		})
+	}
+}
+
+func TestClient_ChatCompletion(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		var req chatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "llama3.1", req.Model)
+		assert.False(t, req.Stream)
+		assert.Equal(t, 256, req.Options.NumPredict)
+		require.Len(t, req.Messages, 2)
+		assert.Equal(t, "system", req.Messages[0].Role)
+		assert.Equal(t, "review this", req.Messages[1].Content)
+
+		_, _ = fmt.Fprint(w, `{"model":"llama3.1","message":{"role":"assistant","content":"LGTM"},"done":true,"prompt_eval_count":42,"eval_count":8}`)
+	})
+
+	resp, err := client.ChatCompletion(context.Background(), "review this")
+	require.NoError(t, err)
+	assert.Equal(t, "LGTM", resp.Text)
+	assert.Equal(t, 42, resp.TokenUsage.PromptTokens)
+	assert.Equal(t, 8, resp.TokenUsage.CompletionTokens)
+	assert.Equal(t, 50, resp.TokenUsage.TotalTokens)
+}
+
+func TestClient_ChatCompletionError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprint(w, `{"error":"model \"llama3.1\" not found, try pulling it first"}`)
+	})
+
+	_, err := client.ChatCompletion(context.Background(), "hi")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Message, "not found")
+}
+
+func TestClient_StreamChatCompletion(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(t, req.Stream)
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hel"},"done":false}`)
+		_, _ = fmt.Fprintln(w, `{"message":{"role":"assistant","content":"lo"},"done":false}`)
+		_, _ = fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":5,"eval_count":2}`)
+	})
+
+	var chunks []string
+	usage, err := client.StreamChatCompletion(context.Background(), "hi", func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hel", "lo"}, chunks)
+	assert.Equal(t, 5, usage.PromptTokens)
+	assert.Equal(t, 2, usage.CompletionTokens)
+	assert.Equal(t, 7, usage.TotalTokens)
+}
+
+func TestClient_StreamChatCompletionError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintln(w, `{"error":"out of memory"}`)
+	})
+
+	_, err := client.StreamChatCompletion(context.Background(), "hi", func(string) error { return nil })
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "out of memory", apiErr.Message)
+}
@@ -0,0 +1,25 @@
+package ollama
+
+import (
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/loveRyujin/ReviewBot/proxy"
+)
+
+func init() {
+	ai.Register(ai.Ollama.String(), func(cfg config.AIConfig, proxyCfg *proxy.Config) (ai.TextGenerator, error) {
+		return FromAIConfig(cfg).New(proxyCfg)
+	})
+}
+
+// FromAIConfig maps AI settings onto an Ollama client configuration.
+// Ollama does not authenticate, so the api_key is ignored.
+func FromAIConfig(cfg config.AIConfig) *Config {
+	return &Config{
+		BaseURL:     cfg.BaseURL,
+		Model:       cfg.Model,
+		MaxTokens:   cfg.MaxTokens,
+		Temperature: cfg.Temperature,
+		TopP:        cfg.TopP,
+	}
+}
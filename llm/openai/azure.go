@@ -0,0 +1,78 @@
+package openai
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/loveRyujin/ReviewBot/proxy"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// AzureAuthAPIKey authenticates with the "api-key" header.
+	AzureAuthAPIKey = "api_key"
+	// AzureAuthAD authenticates with an Azure AD (Entra ID) bearer token.
+	AzureAuthAD = "azure_ad"
+)
+
+var azureDeploymentSanitizer = regexp.MustCompile(`[.:]`)
+
+// AzureConfig configures a client for an Azure OpenAI resource.
+type AzureConfig struct {
+	// Endpoint is the resource URL, e.g. https://my-resource.openai.azure.com.
+	Endpoint   string
+	ApiKey     string
+	AuthType   string
+	APIVersion string
+	// Deployment is used for any model without an entry in Deployments.
+	Deployment string
+	// Deployments maps model names to Azure deployment names.
+	Deployments map[string]string
+
+	Model            string
+	MaxTokens        int
+	Temperature      float32
+	TopP             float32
+	PresencePenalty  float32
+	FrequencyPenalty float32
+}
+
+// deploymentFor resolves the deployment name that serves the given model.
+func (cfg *AzureConfig) deploymentFor(model string) string {
+	if name, ok := cfg.Deployments[model]; ok && name != "" {
+		return name
+	}
+	if cfg.Deployment != "" {
+		return cfg.Deployment
+	}
+	return azureDeploymentSanitizer.ReplaceAllString(model, "")
+}
+
+func (cfg *AzureConfig) New(proxyCfg *proxy.Config) (*Client, error) {
+	if cfg.Endpoint == "" {
+		return nil, errors.New("azure: endpoint (base_url) is required")
+	}
+
+	c := openai.DefaultAzureConfig(cfg.ApiKey, cfg.Endpoint)
+	if cfg.AuthType == AzureAuthAD {
+		c.APIType = openai.APITypeAzureAD
+	}
+	if cfg.APIVersion != "" {
+		c.APIVersion = cfg.APIVersion
+	}
+	c.AzureModelMapperFunc = cfg.deploymentFor
+
+	httpClient, _ := proxyCfg.New()
+	c.HTTPClient = httpClient
+
+	client := openai.NewClientWithConfig(c)
+	return &Client{
+		client:           client,
+		model:            cfg.Model,
+		maxTokens:        cfg.MaxTokens,
+		temperature:      cfg.Temperature,
+		topP:             cfg.TopP,
+		PresencePenalty:  cfg.PresencePenalty,
+		FrequencyPenalty: cfg.FrequencyPenalty,
+	}, nil
+}
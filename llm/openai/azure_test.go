@@ -0,0 +1,98 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const azureChatResponse = `{
+	"id": "chatcmpl-1",
+	"object": "chat.completion",
+	"choices": [{"index": 0, "message": {"role": "assistant", "content": "azure ok"}, "finish_reason": "stop"}],
+	"usage": {"prompt_tokens": 11, "completion_tokens": 3, "total_tokens": 14}
+}`
+
+func TestAzureConfig_New(t *testing.T) {
+	t.Run("endpoint is required", func(t *testing.T) {
+		_, err := (&AzureConfig{ApiKey: "k", Model: "gpt-4o"}).New(&proxy.Config{})
+		assert.Error(t, err)
+	})
+
+	t.Run("deployment resolution", func(t *testing.T) {
+		cfg := AzureConfig{
+			Deployment:  "fallback",
+			Deployments: map[string]string{"gpt-4o": "prod-gpt4o"},
+		}
+		assert.Equal(t, "prod-gpt4o", cfg.deploymentFor("gpt-4o"))
+		assert.Equal(t, "fallback", cfg.deploymentFor("gpt-4.1"))
+
+		cfg.Deployment = ""
+		assert.Equal(t, "gpt-41", cfg.deploymentFor("gpt-4.1"))
+	})
+}
+
+func TestAzureClient_ChatCompletion(t *testing.T) {
+	tests := []struct {
+		name       string
+		authType   string
+		apiVersion string
+		wantHeader string
+		wantValue  string
+		wantQuery  string
+	}{
+		{
+			name:       "api key auth",
+			authType:   AzureAuthAPIKey,
+			apiVersion: "2024-10-21",
+			wantHeader: "api-key",
+			wantValue:  "azure-secret",
+			wantQuery:  "2024-10-21",
+		},
+		{
+			name:       "azure ad auth",
+			authType:   AzureAuthAD,
+			apiVersion: "2025-01-01-preview",
+			wantHeader: "Authorization",
+			wantValue:  "Bearer azure-secret",
+			wantQuery:  "2025-01-01-preview",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/openai/deployments/prod-gpt4o/chat/completions", r.URL.Path)
+				assert.Equal(t, tt.wantQuery, r.URL.Query().Get("api-version"))
+				assert.Equal(t, tt.wantValue, r.Header.Get(tt.wantHeader))
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = fmt.Fprint(w, azureChatResponse)
+			}))
+			defer server.Close()
+
+			cfg := AzureConfig{
+				Endpoint:    server.URL,
+				ApiKey:      "azure-secret",
+				AuthType:    tt.authType,
+				APIVersion:  tt.apiVersion,
+				Deployments: map[string]string{"gpt-4o": "prod-gpt4o"},
+				Model:       "gpt-4o",
+				MaxTokens:   100,
+			}
+			client, err := cfg.New(&proxy.Config{})
+			require.NoError(t, err)
+
+			resp, err := client.ChatCompletion(context.Background(), "hello")
+			require.NoError(t, err)
+			assert.Equal(t, "azure ok", resp.Text)
+			assert.Equal(t, 14, resp.TokenUsage.TotalTokens)
+		})
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"io"
 
-	"github.com/fatih/color"
 	"github.com/loveRyujin/ReviewBot/ai"
 	"github.com/loveRyujin/ReviewBot/proxy"
 	"github.com/sashabaranov/go-openai"
@@ -29,7 +28,16 @@ type Response struct {
 }
 
 func (c *Client) ChatCompletion(ctx context.Context, text string) (*ai.Response, error) {
-	resp, err := c.chatCompletion(ctx, text)
+	return c.Chat(ctx, ai.NewRequest(text))
+}
+
+// StreamChatCompletion streams the chat completion response.
+func (c *Client) StreamChatCompletion(ctx context.Context, text string, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	return c.StreamChat(ctx, ai.NewRequest(text), handler)
+}
+
+func (c *Client) Chat(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+	resp, err := c.chatCompletion(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -40,35 +48,20 @@ func (c *Client) ChatCompletion(ctx context.Context, text string) (*ai.Response,
 			PromptTokens:            resp.TokenUsage.PromptTokens,
 			CompletionTokens:        resp.TokenUsage.CompletionTokens,
 			TotalTokens:             resp.TokenUsage.TotalTokens,
+			PromptTokensDetails:     resp.TokenUsage.PromptTokensDetails,
 			CompletionTokensDetails: resp.TokenUsage.CompletionTokensDetails,
 		},
 	}, nil
 }
 
-func (c *Client) chatCompletion(ctx context.Context, text string) (*Response, error) {
-	req := openai.ChatCompletionRequest{
-		Model:            c.model,
-		MaxTokens:        c.maxTokens,
-		Temperature:      c.temperature,
-		TopP:             c.topP,
-		PresencePenalty:  c.PresencePenalty,
-		FrequencyPenalty: c.FrequencyPenalty,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleAssistant,
-				Content: "You are a helpful assistant.",
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: text,
-			},
-		},
-	}
-
-	resp, err := c.client.CreateChatCompletion(ctx, req)
+func (c *Client) chatCompletion(ctx context.Context, req *ai.Request) (*Response, error) {
+	resp, err := c.client.CreateChatCompletion(ctx, c.newRequest(req))
 	if err != nil {
 		return nil, err
 	}
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("openai: response contained no choices")
+	}
 
 	return &Response{
 		Text:       resp.Choices[0].Message.Content,
@@ -76,55 +69,37 @@ func (c *Client) chatCompletion(ctx context.Context, text string) (*Response, er
 	}, nil
 }
 
-// StreamChatCompletion streams the chat completion response.
-func (c *Client) StreamChatCompletion(ctx context.Context, text string, handler ai.ChunkHandler) error {
-	req := openai.ChatCompletionRequest{
-		Model:            c.model,
-		MaxTokens:        c.maxTokens,
-		Temperature:      c.temperature,
-		TopP:             c.topP,
-		PresencePenalty:  c.PresencePenalty,
-		FrequencyPenalty: c.FrequencyPenalty,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleAssistant,
-				Content: "You are a helpful assistant.",
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: text,
-			},
-		},
-		Stream: true,
-		StreamOptions: &openai.StreamOptions{
-			IncludeUsage: true,
-		},
+// StreamChat streams the chat completion response for a multi-message request
+// and returns the usage reported in the final chunk.
+func (c *Client) StreamChat(ctx context.Context, req *ai.Request, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	streamReq := c.newRequest(req)
+	streamReq.Stream = true
+	streamReq.StreamOptions = &openai.StreamOptions{
+		IncludeUsage: true,
 	}
 
-	stream, err := c.client.CreateChatCompletionStream(ctx, req)
+	stream, err := c.client.CreateChatCompletionStream(ctx, streamReq)
 	if err != nil {
-		return err
+		return ai.TokenUsage{}, err
 	}
 	defer func() {
 		_ = stream.Close()
 	}()
 
-	color.Yellow("================Review Summary====================" + "\n\n")
-
 	tokenUsage := ai.TokenUsage{}
 	for {
 		resp, err := stream.Recv()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
-				color.Yellow("\n" + "==================================================")
 				break
 			}
-			return err
+			return ai.TokenUsage{}, err
 		}
 
-		chunk := resp.Choices[0].Delta.Content
-		if err := handler(chunk); err != nil {
-			return err
+		if len(resp.Choices) > 0 {
+			if err := handler(resp.Choices[0].Delta.Content); err != nil {
+				return ai.TokenUsage{}, err
+			}
 		}
 
 		if resp.Usage != nil {
@@ -135,9 +110,41 @@ func (c *Client) StreamChatCompletion(ctx context.Context, text string, handler
 			tokenUsage.CompletionTokensDetails = resp.Usage.CompletionTokensDetails
 		}
 	}
-	color.Magenta(tokenUsage.String())
 
-	return nil
+	return tokenUsage, nil
+}
+
+// newRequest converts an ai.Request into a chat completion request,
+// falling back to the client's sampling defaults for unset options.
+func (c *Client) newRequest(req *ai.Request) openai.ChatCompletionRequest {
+	messages := make([]openai.ChatCompletionMessage, 0, len(req.Messages)+1)
+	if system := req.SystemPrompt(); system != "" {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: system,
+		})
+	}
+	for _, m := range req.Conversation() {
+		role := openai.ChatMessageRoleUser
+		if m.Role == ai.RoleAssistant {
+			role = openai.ChatMessageRoleAssistant
+		}
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    role,
+			Content: m.Content,
+		})
+	}
+
+	return openai.ChatCompletionRequest{
+		Model:            c.model,
+		MaxTokens:        req.MaxTokensOr(c.maxTokens),
+		Temperature:      req.TemperatureOr(c.temperature),
+		TopP:             req.TopPOr(c.topP),
+		PresencePenalty:  c.PresencePenalty,
+		FrequencyPenalty: c.FrequencyPenalty,
+		Stop:             req.Stop,
+		Messages:         messages,
+	}
 }
 
 type Config struct {
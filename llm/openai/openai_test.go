@@ -1,9 +1,16 @@
 package openai
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/loveRyujin/ReviewBot/ai"
 	"github.com/loveRyujin/ReviewBot/proxy"
+	"github.com/sashabaranov/go-openai"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -100,3 +107,86 @@ func TestClient_Fields(t *testing.T) {
 	assert.Equal(t, float32(0.5), client.PresencePenalty)
 	assert.Equal(t, float32(0.3), client.FrequencyPenalty)
 }
+
+func TestClient_Chat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		require.Len(t, req.Messages, 3)
+		assert.Equal(t, openai.ChatMessageRoleSystem, req.Messages[0].Role)
+		assert.Equal(t, "Be terse.", req.Messages[0].Content)
+		assert.Equal(t, openai.ChatMessageRoleUser, req.Messages[1].Role)
+		assert.Equal(t, openai.ChatMessageRoleAssistant, req.Messages[2].Role)
+		assert.Equal(t, 32, req.MaxTokens)
+		assert.Equal(t, []string{"\n\n"}, req.Stop)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"done"}}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`)
+	}))
+	defer server.Close()
+
+	client, err := (&Config{BaseURL: server.URL, ApiKey: "sk-test", Model: "gpt-4", MaxTokens: 1000}).New(&proxy.Config{})
+	require.NoError(t, err)
+
+	resp, err := client.Chat(context.Background(), &ai.Request{
+		System: "Be terse.",
+		Messages: []ai.Message{
+			{Role: ai.RoleUser, Content: "hi"},
+			{Role: ai.RoleAssistant, Content: "hello"},
+		},
+		MaxTokens: 32,
+		Stop:      []string{"\n\n"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "done", resp.Text)
+	assert.Equal(t, 4, resp.TokenUsage.TotalTokens)
+}
+
+func TestClient_ChatCompletionUsesSystemRole(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		require.Len(t, req.Messages, 2)
+		assert.Equal(t, openai.ChatMessageRoleSystem, req.Messages[0].Role)
+		assert.Equal(t, ai.DefaultSystemPrompt, req.Messages[0].Content)
+		assert.Equal(t, "review", req.Messages[1].Content)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	}))
+	defer server.Close()
+
+	client, err := (&Config{BaseURL: server.URL, ApiKey: "sk-test", Model: "gpt-4"}).New(&proxy.Config{})
+	require.NoError(t, err)
+
+	resp, err := client.ChatCompletion(context.Background(), "review")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Text)
+}
+
+func TestClient_StreamChatCompletionReturnsUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+		_, _ = fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\" there\"}}]}\n\n")
+		_, _ = fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":7,\"completion_tokens\":2,\"total_tokens\":9}}\n\n")
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client, err := (&Config{BaseURL: server.URL, ApiKey: "sk-test", Model: "gpt-4"}).New(&proxy.Config{})
+	require.NoError(t, err)
+
+	var out string
+	usage, err := client.StreamChatCompletion(context.Background(), "hello", func(chunk string) error {
+		out += chunk
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi there", out)
+	assert.Equal(t, 7, usage.PromptTokens)
+	assert.Equal(t, 2, usage.CompletionTokens)
+	assert.Equal(t, 9, usage.TotalTokens)
+}
@@ -0,0 +1,54 @@
+package openai
+
+import (
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/pkg/config"
+	"github.com/loveRyujin/ReviewBot/proxy"
+)
+
+func init() {
+	ai.Register(ai.OpenAI.String(), newOpenAI)
+	ai.Register(ai.DeepSeek.String(), newOpenAI)
+	ai.Register(ai.Azure.String(), newAzure)
+}
+
+// FromAIConfig maps AI settings onto an OpenAI-compatible client configuration.
+// DeepSeek and other OpenAI-compatible services share the same mapping.
+func FromAIConfig(cfg config.AIConfig) *Config {
+	return &Config{
+		BaseURL:          cfg.BaseURL,
+		ApiKey:           cfg.APIKey,
+		Model:            cfg.Model,
+		MaxTokens:        cfg.MaxTokens,
+		Temperature:      cfg.Temperature,
+		TopP:             cfg.TopP,
+		PresencePenalty:  cfg.PresencePenalty,
+		FrequencyPenalty: cfg.FrequencyPenalty,
+	}
+}
+
+// AzureFromAIConfig maps AI settings onto an Azure OpenAI deployment.
+func AzureFromAIConfig(cfg config.AIConfig) *AzureConfig {
+	return &AzureConfig{
+		Endpoint:         cfg.BaseURL,
+		ApiKey:           cfg.APIKey,
+		AuthType:         cfg.Azure.AuthType,
+		APIVersion:       cfg.Azure.APIVersion,
+		Deployment:       cfg.Azure.Deployment,
+		Deployments:      cfg.Azure.Deployments,
+		Model:            cfg.Model,
+		MaxTokens:        cfg.MaxTokens,
+		Temperature:      cfg.Temperature,
+		TopP:             cfg.TopP,
+		PresencePenalty:  cfg.PresencePenalty,
+		FrequencyPenalty: cfg.FrequencyPenalty,
+	}
+}
+
+func newOpenAI(cfg config.AIConfig, proxyCfg *proxy.Config) (ai.TextGenerator, error) {
+	return FromAIConfig(cfg).New(proxyCfg)
+}
+
+func newAzure(cfg config.AIConfig, proxyCfg *proxy.Config) (ai.TextGenerator, error) {
+	return AzureFromAIConfig(cfg).New(proxyCfg)
+}
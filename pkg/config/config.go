@@ -1,6 +1,9 @@
 package config
 
 import (
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -13,8 +16,21 @@ const (
 	defaultTimeout      = 30 * time.Second
 	defaultProvider     = "openai"
 	defaultModel        = "gpt-3.5-turbo"
+	defaultChunkTokens  = 12000
+	defaultConcurrency  = 4
+	defaultGitHubURL    = "https://api.github.com"
+	defaultGitLabURL    = "https://gitlab.com/api/v4"
+	defaultCacheTTL     = 7 * 24 * time.Hour
+	defaultCacheSize    = 50 * 1024 * 1024
+	defaultMaxAttempts  = 3
+	defaultMaxDelay     = 30 * time.Second
 )
 
+// keylessProviders lists providers that run locally and need no api_key.
+var keylessProviders = map[string]struct{}{
+	"ollama": {},
+}
+
 var supportedLangs = map[string]struct{}{
 	"en":    {},
 	"zh-cn": {},
@@ -28,9 +44,37 @@ type Config struct {
 	AI      AIConfig      `mapstructure:"ai"`
 	Proxy   ProxyConfig   `mapstructure:"proxy"`
 	Prompt  PromptConfig  `mapstructure:"prompt"`
+	GitHub  GitHubConfig  `mapstructure:"github"`
+	GitLab  GitLabConfig  `mapstructure:"gitlab"`
+	Cache   CacheConfig   `mapstructure:"cache"`
 	Runtime RuntimeConfig `mapstructure:"runtime"`
 }
 
+// GitHubConfig holds the API settings used to review pull requests. BaseURL
+// is the API root, e.g. https://ghe.example.com/api/v3 for GitHub Enterprise.
+type GitHubConfig struct {
+	BaseURL string `mapstructure:"base_url"`
+	Token   string `mapstructure:"token"`
+}
+
+// GitLabConfig holds the API settings used to review merge requests. BaseURL
+// is the API root, e.g. https://gitlab.example.com/api/v4 for self-hosted
+// instances.
+type GitLabConfig struct {
+	BaseURL string `mapstructure:"base_url"`
+	Token   string `mapstructure:"token"`
+}
+
+// CacheConfig controls the on-disk cache of model responses. An empty Dir
+// stores the cache under the user config dir. A zero TTL or MaxSize
+// disables that limit.
+type CacheConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Dir     string        `mapstructure:"dir"`
+	TTL     time.Duration `mapstructure:"ttl"`
+	MaxSize int           `mapstructure:"max_size"`
+}
+
 // PromptConfig defines settings related to prompt templates.
 type PromptConfig struct {
 	Folder string `mapstructure:"folder"`
@@ -44,6 +88,16 @@ type GitConfig struct {
 	ExcludedList []string `mapstructure:"exclude_list"`
 	Amend        bool     `mapstructure:"amend"`
 	Lang         string   `mapstructure:"lang"`
+	// Scopes map changed paths to the scope of generated commit messages;
+	// the first matching rule of every path wins.
+	Scopes []ScopeRule `mapstructure:"scopes"`
+}
+
+// ScopeRule assigns Scope to the paths matching Pattern, a glob in the
+// syntax of exclude_list.
+type ScopeRule struct {
+	Pattern string `mapstructure:"pattern"`
+	Scope   string `mapstructure:"scope"`
 }
 
 // AIConfig describes AI provider settings.
@@ -57,6 +111,121 @@ type AIConfig struct {
 	TopP             float32 `mapstructure:"top_p"`
 	PresencePenalty  float32 `mapstructure:"presence_penalty"`
 	FrequencyPenalty float32 `mapstructure:"frequency_penalty"`
+	// ContextWindow overrides the built-in context size of the model in
+	// tokens; zero uses the table in pkg/token.
+	ContextWindow int         `mapstructure:"context_window"`
+	Azure         AzureConfig `mapstructure:"azure"`
+	Retry         RetryConfig `mapstructure:"retry"`
+	// Fallbacks are tried in order when the primary backend fails with a
+	// retryable error.
+	Fallbacks []FallbackConfig `mapstructure:"fallbacks"`
+	// Profile names the entry of Profiles that replaces the backend
+	// settings above; empty uses them as they are.
+	Profile  string                   `mapstructure:"profile"`
+	Profiles map[string]ProfileConfig `mapstructure:"profiles"`
+}
+
+// ProfileConfig is a named backend. It replaces the provider, model,
+// endpoint and credentials of the ai section as a whole, while sampling,
+// retry and fallback settings stay shared.
+type ProfileConfig struct {
+	Provider string      `mapstructure:"provider"`
+	APIKey   string      `mapstructure:"api_key"`
+	BaseURL  string      `mapstructure:"base_url"`
+	Model    string      `mapstructure:"model"`
+	Azure    AzureConfig `mapstructure:"azure"`
+}
+
+// ProfileNames returns the names of the configured profiles in order.
+func (a AIConfig) ProfileNames() []string {
+	names := make([]string, 0, len(a.Profiles))
+	for name := range a.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithProfile returns the settings with the backend of the named profile.
+// Names are case-insensitive, as config file keys are.
+func (a AIConfig) WithProfile(name string) (AIConfig, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	p, ok := a.Profiles[key]
+	if !ok {
+		if len(a.Profiles) == 0 {
+			return a, fmt.Errorf("%w %q: no profiles configured under ai.profiles", errUnknownProfile, name)
+		}
+		return a, fmt.Errorf("%w %q (available: %s)", errUnknownProfile, name, strings.Join(a.ProfileNames(), ", "))
+	}
+	a.Profile = key
+	a.Provider, a.APIKey, a.BaseURL, a.Model, a.Azure = p.Provider, p.APIKey, p.BaseURL, p.Model, p.Azure
+	return a, nil
+}
+
+// FallbackConfig is an alternative backend. An empty provider means the
+// primary one; base_url and api_key are only inherited from the primary
+// settings when the provider is the same.
+type FallbackConfig struct {
+	Provider string `mapstructure:"provider"`
+	Model    string `mapstructure:"model"`
+	BaseURL  string `mapstructure:"base_url"`
+	APIKey   string `mapstructure:"api_key"`
+}
+
+// Fallback returns the full settings of the i-th fallback: the primary
+// settings with the backend of the entry.
+func (a AIConfig) Fallback(i int) AIConfig {
+	f := a.Fallbacks[i]
+	cfg := a
+	cfg.Fallbacks = nil
+	if f.Provider != "" && !strings.EqualFold(strings.TrimSpace(f.Provider), strings.TrimSpace(a.Provider)) {
+		cfg.Provider, cfg.BaseURL, cfg.APIKey = f.Provider, "", ""
+		cfg.Azure = AzureConfig{}
+	}
+	cfg.Model = f.Model
+	if f.BaseURL != "" {
+		cfg.BaseURL = f.BaseURL
+	}
+	if f.APIKey != "" {
+		cfg.APIKey = f.APIKey
+	}
+	return cfg
+}
+
+// RetryConfig controls how failed model requests are retried. MaxAttempts
+// counts the first request, so 1 disables retries; MaxDelay caps the wait
+// between two attempts.
+type RetryConfig struct {
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	MaxDelay    time.Duration `mapstructure:"max_delay"`
+}
+
+// AzureConfig holds Azure OpenAI specific settings. The resource endpoint
+// is taken from ai.base_url and the credential from ai.api_key.
+type AzureConfig struct {
+	APIVersion  string            `mapstructure:"api_version"`
+	AuthType    string            `mapstructure:"auth_type"`
+	Deployment  string            `mapstructure:"deployment"`
+	Deployments map[string]string `mapstructure:"deployments"`
+}
+
+// ParseDeployments reads ai.azure.deployments written as a single string,
+// as in $REVIEWBOT_AI_AZURE_DEPLOYMENTS or "reviewbot config set": comma
+// separated model=deployment pairs, e.g. "gpt-4o=prod,gpt-4o-mini=cheap".
+func ParseDeployments(s string) (map[string]string, error) {
+	deployments := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		model, deployment, ok := strings.Cut(pair, "=")
+		model, deployment = strings.TrimSpace(model), strings.TrimSpace(deployment)
+		if !ok || model == "" || deployment == "" {
+			return nil, fmt.Errorf("deployments: %q is not model=deployment", strings.TrimSpace(pair))
+		}
+		deployments[model] = deployment
+	}
+	return deployments, nil
 }
 
 // ProxyConfig tracks proxy configuration fields.
@@ -81,12 +250,45 @@ type ReviewRuntime struct {
 	DiffFile   string `mapstructure:"diff_file"`
 	MaxInput   int    `mapstructure:"max_input_size"`
 	OutputLang string `mapstructure:"output_lang"`
+	Format     string `mapstructure:"format"`
+	// ChunkTokens is the token budget of one review request. Larger diffs are
+	// split per file and hunk; zero disables splitting.
+	ChunkTokens int `mapstructure:"chunk_tokens"`
+	// Concurrency bounds the number of chunks reviewed in parallel.
+	Concurrency int `mapstructure:"concurrency"`
+	// Base and Head select the merge-base diff of a branch in local mode,
+	// Range does the same from a "base..head" revision range.
+	Base  string `mapstructure:"base"`
+	Head  string `mapstructure:"head"`
+	Range string `mapstructure:"range"`
+	// PerCommit reviews every commit of the range on its own instead of the
+	// squashed diff.
+	PerCommit bool `mapstructure:"per_commit"`
+	// Worktree reviews unstaged changes instead of the staged ones, Untracked
+	// adds files unknown to git as new-file diffs.
+	Worktree  bool `mapstructure:"worktree"`
+	Untracked bool `mapstructure:"untracked"`
+	// GitHubPR is a pull request reference (owner/repo#123) to review and
+	// comment on.
+	GitHubPR string `mapstructure:"github_pr"`
+	// GitLabMR is a merge request reference (group/project!123) to review
+	// and comment on.
+	GitLabMR string `mapstructure:"gitlab_mr"`
 }
 
 // CommitRuntime captures commit command runtime flags.
 type CommitRuntime struct {
 	Preview    bool   `mapstructure:"preview"`
 	OutputLang string `mapstructure:"output_lang"`
+	// Candidates is the number of commit messages to choose from; zero and
+	// one commit the only one generated.
+	Candidates int `mapstructure:"candidates"`
+	// Fast generates the whole message in one request instead of
+	// summarizing the diff first.
+	Fast bool `mapstructure:"fast"`
+	// Breaking marks the commit as a breaking change even when the summary
+	// does not report one.
+	Breaking bool `mapstructure:"breaking"`
 }
 
 // NewDefault returns configuration populated with default values.
@@ -105,12 +307,32 @@ func NewDefault() *Config {
 			TopP:             1.0,
 			PresencePenalty:  0.5,
 			FrequencyPenalty: 0.5,
+			Retry: RetryConfig{
+				MaxAttempts: defaultMaxAttempts,
+				MaxDelay:    defaultMaxDelay,
+			},
 		},
 		Prompt: PromptConfig{},
+		GitHub: GitHubConfig{
+			BaseURL: defaultGitHubURL,
+		},
+		GitLab: GitLabConfig{
+			BaseURL: defaultGitLabURL,
+		},
+		Cache: CacheConfig{
+			Enabled: true,
+			TTL:     defaultCacheTTL,
+			MaxSize: defaultCacheSize,
+		},
 		Proxy: ProxyConfig{
 			Timeout: defaultTimeout,
 		},
-		Runtime: RuntimeConfig{},
+		Runtime: RuntimeConfig{
+			Review: ReviewRuntime{
+				ChunkTokens: defaultChunkTokens,
+				Concurrency: defaultConcurrency,
+			},
+		},
 	}
 }
 
@@ -127,8 +349,21 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("ai.top_p", 1.0)
 	v.SetDefault("ai.presence_penalty", 0.5)
 	v.SetDefault("ai.frequency_penalty", 0.5)
+	v.SetDefault("ai.retry.max_attempts", defaultMaxAttempts)
+	v.SetDefault("ai.retry.max_delay", defaultMaxDelay)
+	v.SetDefault("ai.profile", "")
 
 	v.SetDefault("proxy.timeout", defaultTimeout)
 
 	v.SetDefault("prompt.folder", "")
+
+	v.SetDefault("github.base_url", defaultGitHubURL)
+	v.SetDefault("gitlab.base_url", defaultGitLabURL)
+
+	v.SetDefault("cache.enabled", true)
+	v.SetDefault("cache.ttl", defaultCacheTTL)
+	v.SetDefault("cache.max_size", defaultCacheSize)
+
+	v.SetDefault("runtime.review.chunk_tokens", defaultChunkTokens)
+	v.SetDefault("runtime.review.concurrency", defaultConcurrency)
 }
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
@@ -23,12 +25,18 @@ type LoadOptions struct {
 
 // Overrides captures per-domain flag overrides merged on top of config.
 type Overrides struct {
+	AI     AIOverrides
 	Git    GitOverrides
 	Review ReviewOverrides
 	Commit CommitOverrides
 	Prompt PromptOverrides
 }
 
+// AIOverrides holds CLI overrides for AI settings.
+type AIOverrides struct {
+	Profile string
+}
+
 // GitOverrides holds CLI overrides for git settings.
 type GitOverrides struct {
 	DiffUnified  *int
@@ -41,11 +49,22 @@ type GitOverrides struct {
 
 // ReviewOverrides holds CLI overrides for review runtime options.
 type ReviewOverrides struct {
-	Mode       string
-	Stream     *bool
-	DiffFile   string
-	MaxInput   *int
-	OutputLang string
+	Mode        string
+	Stream      *bool
+	DiffFile    string
+	MaxInput    *int
+	OutputLang  string
+	Format      string
+	ChunkTokens *int
+	Concurrency *int
+	Base        string
+	Head        string
+	Range       string
+	PerCommit   *bool
+	Worktree    *bool
+	Untracked   *bool
+	GitHubPR    string
+	GitLabMR    string
 }
 
 // CommitOverrides holds CLI overrides for commit runtime options.
@@ -80,6 +99,11 @@ func Load(opts LoadOptions) (*Config, error) {
 	}
 	v.SetEnvKeyReplacer(opts.Replacer)
 	v.AutomaticEnv()
+	// AutomaticEnv only covers keys viper already knows of, and the Azure
+	// settings have no defaults
+	for _, key := range []string{"ai.azure.api_version", "ai.azure.auth_type", "ai.azure.deployment", "ai.azure.deployments"} {
+		_ = v.BindEnv(key)
+	}
 
 	if opts.ExplicitPath != "" {
 		v.SetConfigFile(opts.ExplicitPath)
@@ -96,12 +120,19 @@ func Load(opts LoadOptions) (*Config, error) {
 	}
 
 	cfg := NewDefault()
-	if err := v.Unmarshal(cfg); err != nil {
+	err := v.Unmarshal(cfg, DecodeHook())
+	if err != nil {
 		return nil, err
 	}
 
 	applyOverrides(cfg, opts.Overrides)
 
+	if cfg.AI.Profile != "" {
+		if cfg.AI, err = cfg.AI.WithProfile(cfg.AI.Profile); err != nil {
+			return nil, fmt.Errorf("ai: %w", err)
+		}
+	}
+
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
@@ -109,6 +140,21 @@ func Load(opts LoadOptions) (*Config, error) {
 	return cfg, nil
 }
 
+// DecodeHook extends viper's default decoding with ai.azure.deployments
+// given as a single "model=deployment,..." string.
+func DecodeHook() viper.DecoderConfigOption {
+	return viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		func(from, to reflect.Type, data any) (any, error) {
+			if from.Kind() != reflect.String || to != reflect.TypeOf(map[string]string{}) {
+				return data, nil
+			}
+			return ParseDeployments(data.(string))
+		},
+	))
+}
+
 // readConfig loads the config file when available, ignoring missing files.
 func readConfig(v *viper.Viper) error {
 	if err := v.ReadInConfig(); err != nil {
@@ -129,6 +175,10 @@ func readConfig(v *viper.Viper) error {
 
 // applyOverrides merges CLI overrides into the config snapshot.
 func applyOverrides(cfg *Config, ov Overrides) {
+	if ov.AI.Profile != "" {
+		cfg.AI.Profile = ov.AI.Profile
+	}
+
 	if ov.Git.DiffUnified != nil {
 		cfg.Git.DiffUnified = *ov.Git.DiffUnified
 	}
@@ -163,6 +213,39 @@ func applyOverrides(cfg *Config, ov Overrides) {
 	if ov.Review.OutputLang != "" {
 		cfg.Runtime.Review.OutputLang = ov.Review.OutputLang
 	}
+	if ov.Review.Format != "" {
+		cfg.Runtime.Review.Format = ov.Review.Format
+	}
+	if ov.Review.ChunkTokens != nil {
+		cfg.Runtime.Review.ChunkTokens = *ov.Review.ChunkTokens
+	}
+	if ov.Review.Concurrency != nil {
+		cfg.Runtime.Review.Concurrency = *ov.Review.Concurrency
+	}
+	if ov.Review.Base != "" {
+		cfg.Runtime.Review.Base = ov.Review.Base
+	}
+	if ov.Review.Head != "" {
+		cfg.Runtime.Review.Head = ov.Review.Head
+	}
+	if ov.Review.Range != "" {
+		cfg.Runtime.Review.Range = ov.Review.Range
+	}
+	if ov.Review.PerCommit != nil {
+		cfg.Runtime.Review.PerCommit = *ov.Review.PerCommit
+	}
+	if ov.Review.Worktree != nil {
+		cfg.Runtime.Review.Worktree = *ov.Review.Worktree
+	}
+	if ov.Review.Untracked != nil {
+		cfg.Runtime.Review.Untracked = *ov.Review.Untracked
+	}
+	if ov.Review.GitHubPR != "" {
+		cfg.Runtime.Review.GitHubPR = ov.Review.GitHubPR
+	}
+	if ov.Review.GitLabMR != "" {
+		cfg.Runtime.Review.GitLabMR = ov.Review.GitLabMR
+	}
 
 	if ov.Commit.Preview != nil {
 		cfg.Runtime.Commit.Preview = *ov.Commit.Preview
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const profilesYAML = `
+ai:
+  profile: local
+  profiles:
+    local:
+      provider: ollama
+      model: llama3.1
+    work:
+      provider: openai
+      model: gpt-4o
+      api_key: sk-work
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "reviewbot.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+	return file
+}
+
+func TestLoad_Profile(t *testing.T) {
+	file := writeConfig(t, profilesYAML)
+
+	cfg, err := Load(LoadOptions{ExplicitPath: file})
+	require.NoError(t, err)
+	assert.Equal(t, "local", cfg.AI.Profile)
+	assert.Equal(t, "ollama", cfg.AI.Provider)
+	assert.Equal(t, "llama3.1", cfg.AI.Model)
+
+	cfg, err = Load(LoadOptions{ExplicitPath: file, Overrides: Overrides{AI: AIOverrides{Profile: "work"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "openai", cfg.AI.Provider)
+	assert.Equal(t, "gpt-4o", cfg.AI.Model)
+	assert.Equal(t, "sk-work", cfg.AI.APIKey)
+
+	_, err = Load(LoadOptions{ExplicitPath: file, Overrides: Overrides{AI: AIOverrides{Profile: "home"}}})
+	assert.ErrorIs(t, err, errUnknownProfile)
+}
+
+func TestLoad_Scopes(t *testing.T) {
+	file := writeConfig(t, `
+ai:
+  provider: ollama
+git:
+  scopes:
+    - pattern: "cmd/"
+      scope: cli
+    - pattern: "**/*.md"
+      scope: docs
+`)
+
+	cfg, err := Load(LoadOptions{ExplicitPath: file})
+	require.NoError(t, err)
+	assert.Equal(t, []ScopeRule{{Pattern: "cmd/", Scope: "cli"}, {Pattern: "**/*.md", Scope: "docs"}}, cfg.Git.Scopes)
+}
+
+func TestLoad_AzureDeploymentsString(t *testing.T) {
+	t.Setenv("REVIEWBOT_AI_AZURE_DEPLOYMENTS", "gpt-4o=prod, gpt-4o-mini = cheap")
+	t.Setenv("REVIEWBOT_AI_AZURE_DEPLOYMENT", "default")
+	file := writeConfig(t, "ai:\n  provider: ollama\n")
+
+	cfg, err := Load(LoadOptions{ExplicitPath: file, EnvPrefix: "REVIEWBOT"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"gpt-4o": "prod", "gpt-4o-mini": "cheap"}, cfg.AI.Azure.Deployments)
+	assert.Equal(t, "default", cfg.AI.Azure.Deployment)
+
+	// the same string is accepted in the file
+	file = writeConfig(t, "ai:\n  provider: ollama\n  azure:\n    deployments: gpt-4o=eu\n")
+	cfg, err = Load(LoadOptions{ExplicitPath: file})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"gpt-4o": "eu"}, cfg.AI.Azure.Deployments)
+
+	t.Setenv("REVIEWBOT_AI_AZURE_DEPLOYMENTS", "gpt-4o")
+	_, err = Load(LoadOptions{ExplicitPath: file, EnvPrefix: "REVIEWBOT"})
+	assert.ErrorContains(t, err, `"gpt-4o" is not model=deployment`)
+}
+
+func TestParseDeployments(t *testing.T) {
+	got, err := ParseDeployments(" gpt-4o=prod,,o1=reasoning ")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"gpt-4o": "prod", "o1": "reasoning"}, got)
+
+	got, err = ParseDeployments("")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	for _, bad := range []string{"gpt-4o", "=prod", "gpt-4o="} {
+		_, err := ParseDeployments(bad)
+		assert.Error(t, err, bad)
+	}
+}
@@ -4,13 +4,18 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"path"
 	"strings"
+	"time"
 )
 
 var (
 	errInvalidLanguage = errors.New("invalid language")
 	errMissingProvider = errors.New("provider cannot be empty")
 	errMissingAPIKey   = errors.New("api_key cannot be empty")
+	errAzureEndpoint   = errors.New("base_url must be set to the Azure OpenAI resource endpoint")
+	errAzureAuthType   = errors.New("auth_type must be api_key or azure_ad")
+	errUnknownProfile  = errors.New("unknown profile")
 )
 
 // Validate runs domain-specific validation across all configuration scopes.
@@ -24,6 +29,15 @@ func (c *Config) Validate() error {
 	if err := c.Proxy.Validate(); err != nil {
 		return fmt.Errorf("proxy: %w", err)
 	}
+	if err := c.GitHub.Validate(); err != nil {
+		return fmt.Errorf("github: %w", err)
+	}
+	if err := c.GitLab.Validate(); err != nil {
+		return fmt.Errorf("gitlab: %w", err)
+	}
+	if err := c.Cache.Validate(); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
 	if err := c.Runtime.Validate(); err != nil {
 		return fmt.Errorf("runtime: %w", err)
 	}
@@ -43,6 +57,29 @@ func (g GitConfig) Validate() error {
 			return fmt.Errorf("%w: %s", errInvalidLanguage, g.Lang)
 		}
 	}
+	for i, r := range g.Scopes {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("scopes[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that a scope rule has a well-formed pattern and a scope
+// that fits between the parentheses of a commit header.
+func (r ScopeRule) Validate() error {
+	if strings.TrimSpace(r.Pattern) == "" {
+		return fmt.Errorf("pattern cannot be empty")
+	}
+	if _, err := path.Match(strings.TrimPrefix(strings.TrimSuffix(r.Pattern, "/"), "**/"), ""); err != nil {
+		return fmt.Errorf("pattern %q: %w", r.Pattern, err)
+	}
+	if strings.TrimSpace(r.Scope) == "" {
+		return fmt.Errorf("scope cannot be empty")
+	}
+	if strings.ContainsAny(r.Scope, "() \t\r\n") {
+		return fmt.Errorf("scope %q cannot contain parentheses or whitespace", r.Scope)
+	}
 	return nil
 }
 
@@ -51,7 +88,7 @@ func (a AIConfig) Validate() error {
 	if strings.TrimSpace(a.Provider) == "" {
 		return errMissingProvider
 	}
-	if strings.TrimSpace(a.APIKey) == "" {
+	if strings.TrimSpace(a.APIKey) == "" && a.requiresAPIKey() {
 		return errMissingAPIKey
 	}
 	if a.MaxTokens <= 0 {
@@ -63,9 +100,85 @@ func (a AIConfig) Validate() error {
 	if a.TopP < 0 || a.TopP > 1 {
 		return fmt.Errorf("top_p must be between 0 and 1")
 	}
+	if a.ContextWindow < 0 {
+		return fmt.Errorf("context_window must be >= 0")
+	}
+	if a.ContextWindow > 0 && a.MaxTokens >= a.ContextWindow {
+		return fmt.Errorf("max_tokens must be smaller than context_window")
+	}
+	if a.Retry.MaxAttempts < 0 {
+		return fmt.Errorf("retry.max_attempts must be >= 0")
+	}
+	if a.Retry.MaxDelay < 0 {
+		return fmt.Errorf("retry.max_delay must be >= 0")
+	}
+	if strings.EqualFold(strings.TrimSpace(a.Provider), "azure") {
+		if err := a.validateAzure(); err != nil {
+			return fmt.Errorf("azure: %w", err)
+		}
+	}
+	for i, f := range a.Fallbacks {
+		if strings.TrimSpace(f.Model) == "" {
+			return fmt.Errorf("fallbacks[%d]: model cannot be empty", i)
+		}
+		if err := a.Fallback(i).Validate(); err != nil {
+			return fmt.Errorf("fallbacks[%d]: %w", i, err)
+		}
+	}
+	for _, name := range a.ProfileNames() {
+		if err := a.validateProfile(name); err != nil {
+			return fmt.Errorf("profiles.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// validateProfile checks the settings a profile resolves to, so that a
+// broken profile is reported before it is switched to.
+func (a AIConfig) validateProfile(name string) error {
+	if strings.TrimSpace(a.Profiles[name].Model) == "" {
+		return errors.New("model cannot be empty")
+	}
+	p, err := a.WithProfile(name)
+	if err != nil {
+		return err
+	}
+	p.Profiles = nil
+	return p.Validate()
+}
+
+// validateAzure checks the settings required to address an Azure OpenAI deployment.
+func (a AIConfig) validateAzure() error {
+	if strings.TrimSpace(a.BaseURL) == "" {
+		return errAzureEndpoint
+	}
+	if _, err := url.ParseRequestURI(a.BaseURL); err != nil {
+		return fmt.Errorf("base_url invalid: %w", err)
+	}
+	switch a.Azure.AuthType {
+	case "", "api_key", "azure_ad":
+	default:
+		return errAzureAuthType
+	}
+	if a.Azure.APIVersion != "" {
+		if _, err := time.Parse("2006-01-02", strings.TrimSuffix(a.Azure.APIVersion, "-preview")); err != nil {
+			return fmt.Errorf("api_version must look like YYYY-MM-DD or YYYY-MM-DD-preview")
+		}
+	}
+	for model, deployment := range a.Azure.Deployments {
+		if strings.TrimSpace(deployment) == "" {
+			return fmt.Errorf("deployments: model %q maps to an empty deployment name", model)
+		}
+	}
 	return nil
 }
 
+// requiresAPIKey reports whether the configured provider authenticates with an api_key.
+func (a AIConfig) requiresAPIKey() bool {
+	_, keyless := keylessProviders[strings.ToLower(strings.TrimSpace(a.Provider))]
+	return !keyless
+}
+
 // Validate ensures proxy URLs and timeout constraints are valid.
 func (p ProxyConfig) Validate() error {
 	if p.ProxyURL != "" {
@@ -84,6 +197,37 @@ func (p ProxyConfig) Validate() error {
 	return nil
 }
 
+// Validate checks the GitHub API root.
+func (g GitHubConfig) Validate() error {
+	if g.BaseURL != "" {
+		if _, err := url.ParseRequestURI(g.BaseURL); err != nil {
+			return fmt.Errorf("base_url invalid: %w", err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the GitLab API root.
+func (g GitLabConfig) Validate() error {
+	if g.BaseURL != "" {
+		if _, err := url.ParseRequestURI(g.BaseURL); err != nil {
+			return fmt.Errorf("base_url invalid: %w", err)
+		}
+	}
+	return nil
+}
+
+// Validate ensures the cache limits are not negative.
+func (c CacheConfig) Validate() error {
+	if c.TTL < 0 {
+		return fmt.Errorf("ttl must be >= 0")
+	}
+	if c.MaxSize < 0 {
+		return fmt.Errorf("max_size must be >= 0")
+	}
+	return nil
+}
+
 // Validate runs validation for runtime sections.
 func (r RuntimeConfig) Validate() error {
 	if err := r.Review.Validate(); err != nil {
@@ -97,20 +241,67 @@ func (r RuntimeConfig) Validate() error {
 
 // Validate ensures review runtime flags carry supported values.
 func (r ReviewRuntime) Validate() error {
-	if r.Mode != "" {
-		switch r.Mode {
-		case "local", "external":
+	if r.MaxInput < 0 {
+		return fmt.Errorf("max_input_size must be >= 0")
+	}
+	if r.Format != "" {
+		switch r.Format {
+		case "text", "markdown", "json", "sarif":
 		default:
-			return fmt.Errorf("mode must be local or external")
+			return fmt.Errorf("format must be text, markdown, json or sarif")
 		}
 	}
-	if r.MaxInput < 0 {
-		return fmt.Errorf("max_input_size must be >= 0")
+	if r.ChunkTokens < 0 {
+		return fmt.Errorf("chunk_tokens must be >= 0")
+	}
+	if r.Concurrency < 0 {
+		return fmt.Errorf("concurrency must be >= 0")
+	}
+	if r.Range != "" && (r.Base != "" || r.Head != "") {
+		return fmt.Errorf("range cannot be combined with base or head")
+	}
+	if r.Head != "" && r.Base == "" {
+		return fmt.Errorf("head requires base")
+	}
+	if r.PerCommit && r.Range == "" && r.Base == "" {
+		return fmt.Errorf("per_commit requires range or base")
+	}
+	if r.external() && (r.Range != "" || r.Base != "") {
+		return fmt.Errorf("range and base are only supported in local mode")
+	}
+	if r.Worktree && (r.Range != "" || r.Base != "") {
+		return fmt.Errorf("worktree cannot be combined with range or base")
+	}
+	if r.Worktree && r.external() {
+		return fmt.Errorf("worktree is only supported in local mode")
+	}
+	if r.Untracked && !r.Worktree {
+		return fmt.Errorf("untracked requires worktree")
+	}
+	if r.GitHubPR != "" && (r.Worktree || r.Range != "" || r.Base != "" || r.external()) {
+		return fmt.Errorf("github_pr cannot be combined with other diff sources")
+	}
+	if r.GitLabMR != "" && (r.GitHubPR != "" || r.Worktree || r.Range != "" || r.Base != "" || r.external()) {
+		return fmt.Errorf("gitlab_mr cannot be combined with other diff sources")
 	}
 	return nil
 }
 
-// Validate is a placeholder for future commit runtime constraints.
+// external reports whether the diff is read from a source other than the
+// local repository; revisions and the worktree only apply to the latter.
+// Modes name registered diff sources, unknown ones are rejected when the
+// diff is read.
+func (r ReviewRuntime) external() bool {
+	return r.Mode != "" && r.Mode != "local"
+}
+
+// Validate checks commit runtime options.
 func (c CommitRuntime) Validate() error {
+	if c.Candidates < 0 {
+		return fmt.Errorf("candidates must be >= 0")
+	}
+	if c.Fast && c.Candidates > 1 {
+		return fmt.Errorf("fast cannot be combined with candidates")
+	}
 	return nil
 }
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAIConfig_Validate(t *testing.T) {
+	base := NewDefault().AI
+
+	tests := []struct {
+		name    string
+		mutate  func(a *AIConfig)
+		wantErr error
+	}{
+		{
+			name:    "missing api key",
+			mutate:  func(a *AIConfig) {},
+			wantErr: errMissingAPIKey,
+		},
+		{
+			name:   "api key present",
+			mutate: func(a *AIConfig) { a.APIKey = "sk-test" },
+		},
+		{
+			name:   "ollama needs no api key",
+			mutate: func(a *AIConfig) { a.Provider = "ollama" },
+		},
+		{
+			name:    "azure requires endpoint",
+			mutate:  func(a *AIConfig) { a.Provider = "azure"; a.APIKey = "k" },
+			wantErr: errAzureEndpoint,
+		},
+		{
+			name: "azure with deployment settings",
+			mutate: func(a *AIConfig) {
+				a.Provider = "azure"
+				a.APIKey = "k"
+				a.BaseURL = "https://res.openai.azure.com"
+				a.Azure = AzureConfig{APIVersion: "2024-10-21", AuthType: "azure_ad", Deployments: map[string]string{"gpt-4o": "prod"}}
+			},
+		},
+		{
+			name: "azure rejects unknown auth type",
+			mutate: func(a *AIConfig) {
+				a.Provider = "azure"
+				a.APIKey = "k"
+				a.BaseURL = "https://res.openai.azure.com"
+				a.Azure.AuthType = "oauth"
+			},
+			wantErr: errAzureAuthType,
+		},
+		{
+			name:    "missing provider",
+			mutate:  func(a *AIConfig) { a.Provider = " " },
+			wantErr: errMissingProvider,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			tt.mutate(&cfg)
+			err := cfg.Validate()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestReviewRuntime_Validate(t *testing.T) {
+	for _, format := range []string{"", "text", "markdown", "json", "sarif"} {
+		assert.NoError(t, ReviewRuntime{Format: format}.Validate(), format)
+	}
+	assert.Error(t, ReviewRuntime{Format: "html"}.Validate())
+	assert.Error(t, ReviewRuntime{MaxInput: -1}.Validate())
+	assert.Error(t, ReviewRuntime{ChunkTokens: -1}.Validate())
+	assert.Error(t, ReviewRuntime{Concurrency: -1}.Validate())
+
+	assert.NoError(t, ReviewRuntime{Base: "main"}.Validate())
+	assert.NoError(t, ReviewRuntime{Base: "main", Head: "feature", PerCommit: true}.Validate())
+	assert.NoError(t, ReviewRuntime{Range: "main..HEAD", PerCommit: true}.Validate())
+	assert.Error(t, ReviewRuntime{Range: "main..HEAD", Base: "main"}.Validate())
+	assert.Error(t, ReviewRuntime{Head: "feature"}.Validate())
+	assert.Error(t, ReviewRuntime{PerCommit: true}.Validate())
+	assert.Error(t, ReviewRuntime{Mode: "external", Base: "main"}.Validate())
+	assert.NoError(t, ReviewRuntime{Mode: "local", Base: "main"}.Validate())
+	assert.Error(t, ReviewRuntime{Mode: "mbox", Range: "main..HEAD"}.Validate())
+
+	assert.NoError(t, ReviewRuntime{Worktree: true, Untracked: true}.Validate())
+	assert.Error(t, ReviewRuntime{Worktree: true, Base: "main"}.Validate())
+	assert.Error(t, ReviewRuntime{Worktree: true, Mode: "external"}.Validate())
+	assert.Error(t, ReviewRuntime{Untracked: true}.Validate())
+
+	assert.NoError(t, ReviewRuntime{GitHubPR: "o/r#1"}.Validate())
+	assert.Error(t, ReviewRuntime{GitHubPR: "o/r#1", Worktree: true}.Validate())
+	assert.Error(t, ReviewRuntime{GitHubPR: "o/r#1", Base: "main"}.Validate())
+	assert.NoError(t, ReviewRuntime{GitLabMR: "g/p!1"}.Validate())
+	assert.Error(t, ReviewRuntime{GitLabMR: "g/p!1", GitHubPR: "o/r#1"}.Validate())
+	assert.Error(t, ReviewRuntime{GitLabMR: "g/p!1", Mode: "external"}.Validate())
+}
+
+func TestCacheConfig_Validate(t *testing.T) {
+	assert.NoError(t, NewDefault().Cache.Validate())
+	assert.NoError(t, CacheConfig{}.Validate())
+	assert.Error(t, CacheConfig{TTL: -time.Second}.Validate())
+	assert.Error(t, CacheConfig{MaxSize: -1}.Validate())
+}
+
+func TestAIConfig_ValidateRetry(t *testing.T) {
+	a := NewDefault().AI
+	a.APIKey = "sk-test"
+	a.Retry = RetryConfig{}
+	assert.NoError(t, a.Validate())
+
+	a.Retry.MaxAttempts = -1
+	assert.ErrorContains(t, a.Validate(), "retry.max_attempts")
+
+	a.Retry = RetryConfig{MaxDelay: -time.Second}
+	assert.ErrorContains(t, a.Validate(), "retry.max_delay")
+}
+
+func TestAIConfig_Fallback(t *testing.T) {
+	a := NewDefault().AI
+	a.APIKey = "sk-primary"
+	a.BaseURL = "https://proxy.example.com/v1"
+	a.Fallbacks = []FallbackConfig{
+		{Model: "gpt-4o-mini"},
+		{Provider: "ollama", Model: "llama3"},
+		{Provider: "anthropic", Model: "claude-sonnet-4-5", APIKey: "sk-ant"},
+	}
+
+	same := a.Fallback(0)
+	assert.Equal(t, a.Provider, same.Provider)
+	assert.Equal(t, "gpt-4o-mini", same.Model)
+	assert.Equal(t, "sk-primary", same.APIKey)
+	assert.Equal(t, a.BaseURL, same.BaseURL)
+	assert.Empty(t, same.Fallbacks)
+
+	other := a.Fallback(1)
+	assert.Equal(t, "ollama", other.Provider)
+	assert.Empty(t, other.APIKey, "credentials must not leak to another provider")
+	assert.Empty(t, other.BaseURL)
+	assert.Equal(t, a.MaxTokens, other.MaxTokens)
+
+	assert.Equal(t, "sk-ant", a.Fallback(2).APIKey)
+	assert.NoError(t, a.Validate())
+
+	a.Fallbacks = append(a.Fallbacks, FallbackConfig{Provider: "gemini", Model: "gemini-2.5-pro"})
+	err := a.Validate()
+	assert.ErrorIs(t, err, errMissingAPIKey)
+	assert.ErrorContains(t, err, "fallbacks[3]")
+
+	a.Fallbacks = []FallbackConfig{{Provider: "ollama"}}
+	assert.ErrorContains(t, a.Validate(), "fallbacks[0]: model cannot be empty")
+}
+
+func TestAIConfig_WithProfile(t *testing.T) {
+	a := NewDefault().AI
+	a.APIKey = "sk-default"
+	a.Profiles = map[string]ProfileConfig{
+		"work":     {Provider: "openai", Model: "gpt-4o", APIKey: "sk-work"},
+		"personal": {Provider: "deepseek", Model: "deepseek-chat", APIKey: "sk-ds", BaseURL: "https://api.deepseek.com"},
+		"local":    {Provider: "ollama", Model: "llama3.1"},
+	}
+	assert.Equal(t, []string{"local", "personal", "work"}, a.ProfileNames())
+
+	p, err := a.WithProfile("Personal")
+	assert.NoError(t, err)
+	assert.Equal(t, "personal", p.Profile)
+	assert.Equal(t, "deepseek", p.Provider)
+	assert.Equal(t, "deepseek-chat", p.Model)
+	assert.Equal(t, "sk-ds", p.APIKey)
+	assert.Equal(t, a.MaxTokens, p.MaxTokens, "sampling settings stay shared")
+
+	local, err := a.WithProfile("local")
+	assert.NoError(t, err)
+	assert.Empty(t, local.APIKey, "credentials of the ai section must not leak into a profile")
+
+	_, err = a.WithProfile("staging")
+	assert.ErrorIs(t, err, errUnknownProfile)
+	assert.ErrorContains(t, err, "available: local, personal, work")
+	assert.NoError(t, a.Validate())
+}
+
+func TestAIConfig_ValidateProfiles(t *testing.T) {
+	a := NewDefault().AI
+	a.Provider = "ollama"
+
+	a.Profiles = map[string]ProfileConfig{"gemini": {Provider: "gemini", Model: "gemini-2.5-pro"}}
+	err := a.Validate()
+	assert.ErrorIs(t, err, errMissingAPIKey)
+	assert.ErrorContains(t, err, "profiles.gemini")
+
+	a.Profiles = map[string]ProfileConfig{"local": {Provider: "ollama"}}
+	assert.ErrorContains(t, a.Validate(), "profiles.local: model cannot be empty")
+
+	a.Profiles = map[string]ProfileConfig{"azure": {Provider: "azure", Model: "gpt-4o", APIKey: "k"}}
+	assert.ErrorIs(t, a.Validate(), errAzureEndpoint)
+}
+
+func TestCommitRuntime_Validate(t *testing.T) {
+	assert.NoError(t, CommitRuntime{}.Validate())
+	assert.NoError(t, CommitRuntime{Candidates: 3}.Validate())
+	assert.Error(t, CommitRuntime{Candidates: -1}.Validate())
+	assert.NoError(t, CommitRuntime{Fast: true, Candidates: 1}.Validate())
+	assert.Error(t, CommitRuntime{Fast: true, Candidates: 2}.Validate())
+}
+
+func TestGitConfig_ValidateScopes(t *testing.T) {
+	g := NewDefault().Git
+	g.Scopes = []ScopeRule{{Pattern: "cmd/", Scope: "cli"}, {Pattern: "**/*.md", Scope: "docs"}}
+	assert.NoError(t, g.Validate())
+
+	g.Scopes = []ScopeRule{{Pattern: "cmd/", Scope: ""}}
+	assert.EqualError(t, g.Validate(), "scopes[0]: scope cannot be empty")
+
+	g.Scopes = []ScopeRule{{Pattern: "cmd/", Scope: "cli"}, {Pattern: "", Scope: "x"}}
+	assert.EqualError(t, g.Validate(), "scopes[1]: pattern cannot be empty")
+
+	g.Scopes = []ScopeRule{{Pattern: "[cmd", Scope: "cli"}}
+	assert.ErrorContains(t, g.Validate(), "syntax error in pattern")
+
+	g.Scopes = []ScopeRule{{Pattern: "cmd/", Scope: "my cli"}}
+	assert.ErrorContains(t, g.Validate(), "cannot contain parentheses or whitespace")
+}
@@ -0,0 +1,155 @@
+// Package editor lets the user edit text in their editor, the way git does
+// for commit messages.
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/loveRyujin/ReviewBot/pkg/command"
+)
+
+// fallback is used when none of the editor variables is set.
+const fallback = "vi"
+
+// Comment is appended below the text to edit; it is stripped again along
+// with every other line starting with '#'.
+const Comment = `# Please enter the commit message for your changes. Lines starting
+# with '#' will be ignored, and an empty message aborts the commit.
+`
+
+// envVars are consulted in order, as git does.
+var envVars = []string{"GIT_EDITOR", "VISUAL", "EDITOR"}
+
+// shellChars make git hand the editor to the shell rather than run it
+// directly.
+const shellChars = "|&;<>()$`\\\"' \t\n*?[#~=%"
+
+// Resolve returns the editor command line from $GIT_EDITOR, $VISUAL or
+// $EDITOR, falling back to vi.
+func Resolve() string {
+	for _, name := range envVars {
+		if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+			return v
+		}
+	}
+	return fallback
+}
+
+// Command returns the command opening file in editor. Like git, an editor
+// containing shell syntax, such as arguments or a quoted path with spaces,
+// is run by sh with the file as "$@"; a plain program name runs directly.
+// Windows has no sh, so the editor is split at spaces there.
+func Command(editor, file string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		args := strings.Fields(editor)
+		args[0] = strings.Trim(args[0], "\"'")
+		return exec.Command(args[0], append(args[1:], file)...)
+	}
+	if !strings.ContainsAny(editor, shellChars) {
+		return exec.Command(editor, file)
+	}
+	return exec.Command("sh", "-c", editor+` "$@"`, editor, file)
+}
+
+// Program returns the program the editor command line runs: its first
+// shell word with quotes and backslashes removed.
+func Program(editor string) string {
+	editor = strings.TrimSpace(editor)
+	if runtime.GOOS == "windows" {
+		return strings.Trim(strings.Fields(editor)[0], "\"'")
+	}
+
+	var word strings.Builder
+	var quote rune
+	escaped := false
+	for _, r := range editor {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ' ' || r == '\t' || r == '\n':
+			return word.String()
+		default:
+			word.WriteRune(r)
+		}
+	}
+	return word.String()
+}
+
+// Edit writes text and the help comment to a temp file, opens it in the
+// editor and returns the saved content with comments stripped.
+func Edit(text string) (string, error) {
+	editor := Resolve()
+	// words the shell would expand are left for the shell to resolve
+	if program := Program(editor); !strings.ContainsAny(program, "$`=~") && !command.IsCommandAvailable(program) {
+		return "", fmt.Errorf("editor %q not found, set $GIT_EDITOR, $VISUAL or $EDITOR", program)
+	}
+
+	f, err := os.CreateTemp("", "COMMIT_EDITMSG-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(strings.TrimRight(text, "\n") + "\n\n" + Comment); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	cmd := Command(editor, f.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("editor %s exited with status %d", editor, exitErr.ExitCode())
+		}
+		return "", err
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return Strip(string(edited)), nil
+}
+
+// Strip cleans up edited text like git's default cleanup mode: comment
+// lines and trailing whitespace are removed, runs of blank lines collapse
+// into one, and leading and trailing blank lines are dropped.
+func Strip(text string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,141 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEditor writes an executable shell script running script as the
+// editor; the file to edit is its last argument.
+func fakeEditor(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake editor is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "editor.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755))
+	return path
+}
+
+func clearEditorEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range envVars {
+		t.Setenv(name, "")
+	}
+}
+
+func TestEdit(t *testing.T) {
+	clearEditorEnv(t)
+	dir := t.TempDir()
+	seen := filepath.Join(dir, "seen")
+	t.Setenv("EDITOR", fakeEditor(t, `cp "$1" `+seen+`
+{ echo "feat: Edit commit messages"; echo; echo "# a comment"; echo "Body line   "; } > "$1"
+`))
+
+	got, err := Edit("fix: Generated title\n\nGenerated body")
+	require.NoError(t, err)
+	assert.Equal(t, "feat: Edit commit messages\n\nBody line", got)
+
+	original, err := os.ReadFile(seen)
+	require.NoError(t, err)
+	assert.Equal(t, "fix: Generated title\n\nGenerated body\n\n"+Comment, string(original))
+}
+
+func TestEdit_Precedence(t *testing.T) {
+	clearEditorEnv(t)
+	t.Setenv("EDITOR", fakeEditor(t, `echo editor > "$1"`))
+	t.Setenv("VISUAL", fakeEditor(t, `echo visual > "$1"`))
+	t.Setenv("GIT_EDITOR", fakeEditor(t, `echo git > "$1"`+"\n"))
+
+	got, err := Edit("msg")
+	require.NoError(t, err)
+	assert.Equal(t, "git", got)
+
+	t.Setenv("GIT_EDITOR", "")
+	got, err = Edit("msg")
+	require.NoError(t, err)
+	assert.Equal(t, "visual", got)
+}
+
+func TestEdit_EditorFails(t *testing.T) {
+	clearEditorEnv(t)
+	t.Setenv("EDITOR", fakeEditor(t, "exit 3\n"))
+
+	_, err := Edit("msg")
+	assert.ErrorContains(t, err, "exited with status 3")
+}
+
+func TestEdit_EditorArguments(t *testing.T) {
+	clearEditorEnv(t)
+	t.Setenv("EDITOR", fakeEditor(t, `echo "$1" > "$2"`)+" --wait")
+
+	got, err := Edit("msg")
+	require.NoError(t, err)
+	assert.Equal(t, "--wait", got)
+}
+
+func TestEdit_PathWithSpaces(t *testing.T) {
+	clearEditorEnv(t)
+	dir := filepath.Join(t.TempDir(), "My Editors")
+	require.NoError(t, os.Mkdir(dir, 0o755))
+	path := filepath.Join(dir, "editor.sh")
+	require.NoError(t, os.Rename(fakeEditor(t, `echo "$1" > "$2"`), path))
+
+	// quoted as for git, since the shell splits the value
+	t.Setenv("EDITOR", `"`+path+`" --wait`)
+	got, err := Edit("msg")
+	require.NoError(t, err)
+	assert.Equal(t, "--wait", got)
+}
+
+func TestEdit_Unavailable(t *testing.T) {
+	clearEditorEnv(t)
+	for _, editor := range []string{"no-such-editor-reviewbot", "no-such-editor-reviewbot --wait", `"/no such/editor" -w`} {
+		t.Setenv("EDITOR", editor)
+		_, err := Edit("msg")
+		assert.ErrorContains(t, err, "not found", editor)
+	}
+}
+
+func TestEdit_EditorExits127(t *testing.T) {
+	clearEditorEnv(t)
+	t.Setenv("EDITOR", fakeEditor(t, "exit 127\n")+" --wait")
+
+	_, err := Edit("msg")
+	assert.ErrorContains(t, err, "exited with status 127")
+}
+
+func TestProgram(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the editor is split at spaces on Windows")
+	}
+	tests := map[string]string{
+		"vim":                        "vim",
+		"  code --wait ":             "code",
+		`"/opt/My Editor/ed" --wait`: "/opt/My Editor/ed",
+		`'/opt/My Editor/ed'`:        "/opt/My Editor/ed",
+		`/opt/My\ Editor/ed -w`:      "/opt/My Editor/ed",
+	}
+	for editor, want := range tests {
+		assert.Equal(t, want, Program(editor), editor)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	clearEditorEnv(t)
+	assert.Equal(t, "vi", Resolve())
+
+	t.Setenv("VISUAL", "  code --wait ")
+	assert.Equal(t, "code --wait", Resolve())
+}
+
+func TestStrip(t *testing.T) {
+	assert.Equal(t, "title\n\nbody\n  indented", Strip("\n\ntitle  \n# note\n\n\n\nbody\n  indented\n\n# trailing\n"))
+	assert.Equal(t, "", Strip("# only comments\n\n"))
+	assert.Equal(t, "a\n\nb", Strip("a\r\n\r\nb\r\n"))
+}
@@ -8,6 +8,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/fatih/color"
+	"github.com/loveRyujin/ReviewBot/ai"
 )
 
 var (
@@ -171,11 +172,13 @@ func checkBox(label string, checked bool) string {
 func initModel() Model {
 	// Initialize text input fields for AI configuration
 	provider := textinput.New()
-	provider.Placeholder = "Provider"
+	provider.Placeholder = "Provider (" + strings.Join(ai.Providers(), ", ") + ")"
+	provider.SetSuggestions(ai.Providers())
+	provider.ShowSuggestions = true
 	provider.PromptStyle = focusedStyle
 	provider.TextStyle = focusedStyle
 	provider.CharLimit = 200
-	provider.Width = 20
+	provider.Width = 60
 	provider.Focus()
 
 	apiKey := textinput.New()
@@ -0,0 +1,87 @@
+// Package jsonx reads JSON values out of model responses, which often wrap
+// them in code fences or prose and leave trailing commas behind.
+package jsonx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ErrNoJSON is returned when a response contains no JSON value at all.
+var ErrNoJSON = errors.New("response does not contain JSON")
+
+var (
+	codeFence     = regexp.MustCompile("(?s)```(?:json|JSON)?\\s*(.*?)```")
+	trailingComma = regexp.MustCompile(`,\s*([}\]])`)
+)
+
+// Extract returns the first balanced JSON object or array in text,
+// preferring the contents of a fenced code block when present. A truncated
+// value is returned as is, for the decoder to report.
+func Extract(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if m := codeFence.FindStringSubmatch(text); m != nil {
+		text = strings.TrimSpace(m[1])
+	}
+
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return "", ErrNoJSON
+	}
+	if end := matchingBracket(text, start); end > start {
+		return text[start : end+1], nil
+	}
+	return strings.TrimSpace(text[start:]), nil
+}
+
+// Unmarshal decodes payload into v, retrying without trailing commas when
+// the payload does not decode as is.
+func Unmarshal(payload string, v any) error {
+	err := json.Unmarshal([]byte(payload), v)
+	if err == nil {
+		return nil
+	}
+	if repaired := trailingComma.ReplaceAllString(payload, "$1"); repaired != payload {
+		return json.Unmarshal([]byte(repaired), v)
+	}
+	return err
+}
+
+// matchingBracket finds the index closing the bracket at start, skipping
+// over string literals. It returns -1 when the value is truncated.
+func matchingBracket(text string, start int) int {
+	var stack bytes.Buffer
+	inString, escaped := false, false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			stack.WriteByte(c)
+		case '}', ']':
+			if stack.Len() == 0 {
+				return -1
+			}
+			stack.Truncate(stack.Len() - 1)
+			if stack.Len() == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,43 @@
+package jsonx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "bare object", text: ` {"a":1} `, want: `{"a":1}`},
+		{name: "code fence", text: "Sure:\n```json\n{\"a\": [1, 2]}\n```\nDone.", want: `{"a": [1, 2]}`},
+		{name: "prose around", text: `The answer is [1, {"b": "]"}] as asked.`, want: `[1, {"b": "]"}]`},
+		{name: "escaped quote", text: `{"a": "say \"}\""} trailing`, want: `{"a": "say \"}\""}`},
+		{name: "truncated", text: `{"a": [1, 2`, want: `{"a": [1, 2`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Extract(tt.text)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := Extract("no json here")
+	assert.ErrorIs(t, err, ErrNoJSON)
+}
+
+func TestUnmarshal(t *testing.T) {
+	var v struct {
+		A []int `json:"a"`
+	}
+	require.NoError(t, Unmarshal(`{"a": [1, 2,],}`, &v))
+	assert.Equal(t, []int{1, 2}, v.A)
+
+	assert.Error(t, Unmarshal(`{"a": [1, 2`, &v))
+	assert.Error(t, Unmarshal(`{"a": "x"}`, &v))
+}
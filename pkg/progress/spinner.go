@@ -36,8 +36,11 @@ func (s *Spinner) Stop() {
 	s.spinner.Stop()
 }
 
-// UpdateMessage updates the spinner message
+// UpdateMessage updates the spinner message. The spinner's goroutine reads
+// the prefix while drawing, so it is written under the spinner's lock.
 func (s *Spinner) UpdateMessage(message string) {
+	s.spinner.Lock()
+	defer s.spinner.Unlock()
 	s.message = message
 	s.spinner.Prefix = message + " "
 }
@@ -0,0 +1,28 @@
+//go:build linux
+
+package progress
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpinner_UpdateMessageWhileRunning(t *testing.T) {
+	s := NewSpinner("Working")
+	s.spinner.Writer = io.Discard
+	s.spinner.WriterFile = openTTY(t)
+	s.spinner.Delay = time.Millisecond
+
+	s.Start()
+	for i := range 50 {
+		time.Sleep(time.Millisecond)
+		s.UpdateMessage(fmt.Sprintf("Working on %d", i))
+	}
+	s.Stop()
+
+	assert.Equal(t, "Working on 49 ", s.spinner.Prefix)
+}
@@ -0,0 +1,78 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/fatih/color"
+)
+
+// Stages reports the progress of a pipeline with a fixed number of stages
+// on a single spinner, e.g. "2/3 generating title". Stages may run
+// concurrently; the spinner then lists all running ones.
+type Stages struct {
+	mu      sync.Mutex
+	out     io.Writer
+	total   int
+	running []string
+	spinner *spinner.Spinner
+}
+
+// NewStages creates a reporter for total stages writing to out.
+func NewStages(out io.Writer, total int) *Stages {
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond, spinner.WithWriter(out))
+	_ = s.Color("cyan")
+	return &Stages{out: out, total: total, spinner: s}
+}
+
+// Run executes operation as stage n while the spinner shows message, and
+// prints whether the stage succeeded once it returns.
+func (s *Stages) Run(n int, message string, operation func() error) error {
+	label := fmt.Sprintf("%d/%d %s", n, s.total, message)
+	s.start(label)
+	err := operation()
+	s.finish(label, err)
+	return err
+}
+
+func (s *Stages) start(label string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running = append(s.running, label)
+	s.setPrefix(strings.Join(s.running, ", ") + " ")
+	s.spinner.Start()
+}
+
+func (s *Stages) finish(label string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// stop to print the result on a line of its own
+	s.spinner.Stop()
+	if i := slices.Index(s.running, label); i >= 0 {
+		s.running = slices.Delete(s.running, i, i+1)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(s.out, "%s %s\n", color.New(color.FgRed).Sprint("✗"), label)
+	} else {
+		_, _ = fmt.Fprintf(s.out, "%s %s\n", color.New(color.FgGreen).Sprint("✓"), label)
+	}
+	if len(s.running) > 0 {
+		s.setPrefix(strings.Join(s.running, ", ") + " ")
+		s.spinner.Start()
+	}
+}
+
+// setPrefix updates the spinner text under the spinner's own lock, which
+// its goroutine holds while drawing.
+func (s *Stages) setPrefix(prefix string) {
+	s.spinner.Lock()
+	defer s.spinner.Unlock()
+	s.spinner.Prefix = prefix
+}
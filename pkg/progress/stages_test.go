@@ -0,0 +1,74 @@
+//go:build linux
+
+package progress
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// openTTY opens a pseudo-terminal, so that the spinner really runs; it
+// stays idle on anything that is not a terminal.
+func openTTY(t *testing.T) *os.File {
+	t.Helper()
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	t.Cleanup(func() { _ = ptmx.Close() })
+	require.NoError(t, unix.IoctlSetPointerInt(int(ptmx.Fd()), unix.TIOCSPTLCK, 0))
+	n, err := unix.IoctlGetInt(int(ptmx.Fd()), unix.TIOCGPTN)
+	require.NoError(t, err)
+	tty, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	t.Cleanup(func() { _ = tty.Close() })
+	return tty
+}
+
+func TestStages_OverlappingStages(t *testing.T) {
+	stages := NewStages(io.Discard, 2)
+	// the spinner checks this file for a terminal before it starts drawing
+	stages.spinner.WriterFile = openTTY(t)
+	stages.spinner.Delay = time.Millisecond
+
+	var (
+		wg   sync.WaitGroup
+		long error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		long = stages.Run(1, "long stage", func() error {
+			time.Sleep(time.Second)
+			return nil
+		})
+	}()
+
+	// the second stage starts and ends several times while the first one
+	// is being drawn
+	for range 20 {
+		time.Sleep(25 * time.Millisecond)
+		err := stages.Run(2, "short stage", func() error {
+			time.Sleep(10 * time.Millisecond)
+			return errors.New("failed")
+		})
+		assert.EqualError(t, err, "failed")
+	}
+	wg.Wait()
+
+	assert.NoError(t, long)
+	assert.Empty(t, stages.running)
+	assert.False(t, stages.spinner.Active())
+}
@@ -0,0 +1,133 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultContextWindow is assumed for models missing from the context table.
+const DefaultContextWindow = 8192
+
+// ErrNoPromptRoom is returned when the tokens reserved for the completion
+// leave no room for the prompt.
+var ErrNoPromptRoom = errors.New("max_tokens leaves no room for the prompt in the context window")
+
+// contextWindows lists context sizes in tokens by model name prefix. The
+// longest matching prefix wins, so specific entries override family defaults.
+var contextWindows = map[string]int{
+	// OpenAI
+	"gpt-3.5-turbo":      16385,
+	"gpt-35-turbo":       16385,
+	"gpt-4":              8192,
+	"gpt-4-32k":          32768,
+	"gpt-4-turbo":        128000,
+	"gpt-4-1106":         128000,
+	"gpt-4-0125":         128000,
+	"gpt-4o":             128000,
+	"chatgpt-4o":         128000,
+	"gpt-4.1":            1047576,
+	"gpt-4.5":            128000,
+	"gpt-5":              400000,
+	"o1":                 200000,
+	"o1-mini":            128000,
+	"o1-preview":         128000,
+	"o3":                 200000,
+	"o4-mini":            200000,
+	"text-embedding-3":   8191,
+	"text-embedding-ada": 8191,
+
+	// Anthropic
+	"claude-":  200000,
+	"claude-2": 100000,
+
+	// Google
+	"gemini-":          1048576,
+	"gemini-1.0":       32760,
+	"gemini-1.5-pro":   2097152,
+	"gemini-1.5-flash": 1048576,
+	"gemini-2":         1048576,
+
+	// DeepSeek
+	"deepseek-chat":     128000,
+	"deepseek-reasoner": 128000,
+	"deepseek-coder":    128000,
+
+	// Common Ollama models
+	"llama2":     4096,
+	"llama3":     8192,
+	"llama3.1":   131072,
+	"llama3.2":   131072,
+	"llama3.3":   131072,
+	"codellama":  16384,
+	"mistral":    32768,
+	"mixtral":    32768,
+	"qwen2":      32768,
+	"qwen2.5":    32768,
+	"qwen3":      40960,
+	"gemma2":     8192,
+	"gemma3":     131072,
+	"phi3":       4096,
+	"phi4":       16384,
+	"deepseek-r": 131072,
+}
+
+var contextPrefixes = func() []string {
+	prefixes := make([]string, 0, len(contextWindows))
+	for p := range contextWindows {
+		prefixes = append(prefixes, p)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	return prefixes
+}()
+
+// ContextWindow returns the context size of model in tokens and whether the
+// model was found in the table. Vendor prefixes ("anthropic/claude-...") and
+// Ollama tags ("llama3.1:8b") are ignored for the lookup.
+func ContextWindow(model string) (int, bool) {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		name = name[:i]
+	}
+
+	for _, p := range contextPrefixes {
+		if strings.HasPrefix(name, p) {
+			return contextWindows[p], true
+		}
+	}
+	return DefaultContextWindow, false
+}
+
+// Budget is the number of prompt tokens that can be sent to a model.
+type Budget struct {
+	// Window is the model context size.
+	Window int
+	// Reserved is kept free for the completion.
+	Reserved int
+}
+
+// NewBudget builds the budget of model. A positive override replaces the
+// context table, and maxTokens is reserved for the answer.
+func NewBudget(model string, override, maxTokens int) Budget {
+	window := override
+	if window <= 0 {
+		window, _ = ContextWindow(model)
+	}
+	return Budget{Window: window, Reserved: max(maxTokens, 0)}
+}
+
+// Available returns the prompt tokens left after reserving the completion
+// and a small safety margin for message framing and tokenizer drift. It
+// returns ErrNoPromptRoom when nothing is left.
+func (b Budget) Available() (int, error) {
+	margin := max(b.Window/50, 64)
+	available := b.Window - b.Reserved - margin
+	if available <= 0 {
+		return 0, fmt.Errorf("%w (window %d, max_tokens %d, margin %d tokens)", ErrNoPromptRoom, b.Window, b.Reserved, margin)
+	}
+	return available, nil
+}
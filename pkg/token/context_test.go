@@ -0,0 +1,61 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		model  string
+		want   int
+		wantOK bool
+	}{
+		{"gpt-4", 8192, true},
+		{"gpt-4-0613", 8192, true},
+		{"gpt-4-32k", 32768, true},
+		{"gpt-4-turbo-2024-04-09", 128000, true},
+		{"gpt-4o-mini", 128000, true},
+		{"gpt-3.5-turbo", 16385, true},
+		{"o1-mini", 128000, true},
+		{"o1", 200000, true},
+		{"claude-sonnet-4-5", 200000, true},
+		{"anthropic/claude-3.5-sonnet", 200000, true},
+		{"gemini-1.5-pro-002", 2097152, true},
+		{"gemini-2.5-flash", 1048576, true},
+		{"deepseek-reasoner", 128000, true},
+		{"llama3.1:8b", 131072, true},
+		{"llama3:latest", 8192, true},
+		{"my-finetune", DefaultContextWindow, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, ok := ContextWindow(tt.model)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+func TestBudget(t *testing.T) {
+	b := NewBudget("gpt-4", 0, 1000)
+	assert.Equal(t, Budget{Window: 8192, Reserved: 1000}, b)
+	available, err := b.Available()
+	require.NoError(t, err)
+	assert.Equal(t, 8192-1000-163, available)
+
+	b = NewBudget("gpt-4", 4096, 500)
+	assert.Equal(t, 4096, b.Window)
+	available, err = b.Available()
+	require.NoError(t, err)
+	assert.Equal(t, 4096-500-81, available)
+
+	_, err = NewBudget("gpt-4", 100, 1000).Available()
+	assert.ErrorIs(t, err, ErrNoPromptRoom)
+
+	_, err = NewBudget("gpt-4", 0, 8192-163).Available()
+	assert.ErrorIs(t, err, ErrNoPromptRoom, "a large max_tokens uses up the known window")
+}
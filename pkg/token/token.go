@@ -0,0 +1,161 @@
+// Package token estimates prompt sizes and model context windows so that
+// requests can be budgeted before they are sent.
+package token
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// Counter counts the tokens of a text for a particular model family.
+type Counter interface {
+	// Name identifies the encoding, e.g. "o200k_base" or "heuristic".
+	Name() string
+	// Count returns the number of tokens in text.
+	Count(text string) int
+}
+
+var (
+	codecMu sync.Mutex
+	codecs  = make(map[tokenizer.Encoding]tokenizer.Codec)
+)
+
+// ForModel returns a BPE counter for OpenAI-family models and a heuristic
+// counter for every other model.
+func ForModel(model string) Counter {
+	enc, ok := openAIEncoding(model)
+	if !ok {
+		return Heuristic{}
+	}
+	codec, err := loadCodec(enc)
+	if err != nil {
+		return Heuristic{}
+	}
+	return bpe{codec: codec}
+}
+
+// Count is a shorthand for ForModel(model).Count(text).
+func Count(model, text string) int {
+	return ForModel(model).Count(text)
+}
+
+// openAIEncoding resolves the BPE encoding of an OpenAI model. Vendor
+// prefixes used by gateways such as OpenRouter ("openai/gpt-4o") are ignored,
+// and unknown gpt-* and o-series models fall back to the newest encoding.
+func openAIEncoding(model string) (tokenizer.Encoding, bool) {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if vendor, rest, found := strings.Cut(name, "/"); found {
+		if vendor != "openai" {
+			return "", false
+		}
+		name = rest
+	}
+
+	switch {
+	case strings.HasPrefix(name, "gpt-4o"), strings.HasPrefix(name, "chatgpt-4o"),
+		strings.HasPrefix(name, "gpt-4.1"), strings.HasPrefix(name, "gpt-4.5"),
+		strings.HasPrefix(name, "gpt-5"), strings.HasPrefix(name, "o1"),
+		strings.HasPrefix(name, "o3"), strings.HasPrefix(name, "o4"):
+		return tokenizer.O200kBase, true
+	case strings.HasPrefix(name, "gpt-4"), strings.HasPrefix(name, "gpt-3.5"),
+		strings.HasPrefix(name, "gpt-35"), strings.HasPrefix(name, "text-embedding-"):
+		return tokenizer.Cl100kBase, true
+	case strings.HasPrefix(name, "gpt-"):
+		return tokenizer.O200kBase, true
+	}
+	return "", false
+}
+
+func loadCodec(enc tokenizer.Encoding) (tokenizer.Codec, error) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+
+	if codec, ok := codecs[enc]; ok {
+		return codec, nil
+	}
+	codec, err := tokenizer.Get(enc)
+	if err != nil {
+		return nil, err
+	}
+	codecs[enc] = codec
+	return codec, nil
+}
+
+type bpe struct {
+	codec tokenizer.Codec
+}
+
+func (b bpe) Name() string {
+	return b.codec.GetName()
+}
+
+func (b bpe) Count(text string) int {
+	n, err := b.codec.Count(text)
+	if err != nil {
+		return Heuristic{}.Count(text)
+	}
+	return n
+}
+
+// Heuristic approximates token counts for models without a local tokenizer.
+// Runs of letters and digits cost one token per six bytes, runs of symbols
+// one token per two bytes and every non-ASCII rune one token. Whitespace runs
+// cost one token, except for a single space, which BPE vocabularies merge
+// into the following word. On code and prose this lands slightly above the
+// OpenAI tokenizers, which is the safe side for budgeting.
+type Heuristic struct{}
+
+func (Heuristic) Name() string {
+	return "heuristic"
+}
+
+func (Heuristic) Count(text string) int {
+	const (
+		none = iota
+		word
+		symbol
+		space
+	)
+
+	tokens, class, runLen := 0, none, 0
+	singleSpace := false
+	flush := func() {
+		switch class {
+		case word:
+			tokens += (runLen + 5) / 6
+		case symbol:
+			tokens += (runLen + 1) / 2
+		case space:
+			if !singleSpace {
+				tokens++
+			}
+		}
+		class, runLen = none, 0
+	}
+
+	for _, r := range text {
+		next := symbol
+		switch {
+		case r >= utf8.RuneSelf:
+			flush()
+			tokens++
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			next = word
+		case unicode.IsSpace(r):
+			next = space
+		}
+		if next != class {
+			flush()
+			class = next
+		}
+		runLen++
+		singleSpace = runLen == 1 && r == ' '
+	}
+	flush()
+	return tokens
+}
@@ -0,0 +1,53 @@
+package token
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"gpt-4o", "o200k_base"},
+		{"gpt-4o-mini", "o200k_base"},
+		{"openai/gpt-4.1", "o200k_base"},
+		{"o3-mini", "o200k_base"},
+		{"gpt-5.1", "o200k_base"},
+		{"gpt-4-turbo", "cl100k_base"},
+		{"gpt-3.5-turbo", "cl100k_base"},
+		{"claude-sonnet-4-5", "heuristic"},
+		{"anthropic/claude-3.5-sonnet", "heuristic"},
+		{"deepseek-chat", "heuristic"},
+		{"llama3.1:8b", "heuristic"},
+		{"", "heuristic"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			assert.Equal(t, tt.want, ForModel(tt.model).Name())
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	// "hello world" is two tokens in both OpenAI encodings
+	assert.Equal(t, 2, Count("gpt-4o", "hello world"))
+	assert.Equal(t, 2, Count("gpt-3.5-turbo", "hello world"))
+	assert.Equal(t, 0, Count("gpt-4o", ""))
+}
+
+func TestHeuristic(t *testing.T) {
+	h := Heuristic{}
+	assert.Equal(t, 0, h.Count(""))
+	assert.Equal(t, 1, h.Count("abc"))
+	assert.Equal(t, 2, h.Count("abcdefg"))
+	assert.Equal(t, 4, h.Count("你好世界"))
+
+	diff := strings.Repeat("+\tif err != nil {\n+\t\treturn err\n+\t}\n", 100)
+	bpe := Count("gpt-4o", diff)
+	assert.GreaterOrEqual(t, h.Count(diff), bpe, "heuristic should not underestimate code")
+}
@@ -4,26 +4,34 @@ import (
 	"bytes"
 	"embed"
 	"html/template"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
 	"sync"
+	texttemplate "text/template"
 )
 
 const (
 	// Prompt template file names
-	CodeReviewFileDiffTmpl  = "code_review_file_diff.tmpl"
-	CommitMessagePrefixTmpl = "conventional_commit.tmpl"
-	CommitMessageTitleTmpl  = "summarize_title.tmpl"
-	CommitFileDiffTmpl      = "summarize_file_diff.tmpl"
-	TranslationTmpl         = "translation.tmpl"
+	CodeReviewFileDiffTmpl         = "code_review_file_diff.tmpl"
+	CodeReviewJSONTmpl             = "code_review_json.tmpl"
+	CodeReviewJSONRepairTmpl       = "code_review_json_repair.tmpl"
+	CodeReviewAggregateTmpl        = "code_review_aggregate.tmpl"
+	CodeReviewAggregateSummaryTmpl = "code_review_aggregate_summary.tmpl"
+	CommitMessagePrefixTmpl        = "conventional_commit.tmpl"
+	CommitMessageTitleTmpl         = "summarize_title.tmpl"
+	CommitFileDiffTmpl             = "summarize_file_diff.tmpl"
+	CommitMessageJSONTmpl          = "commit_message_json.tmpl"
+	TranslationTmpl                = "translation.tmpl"
 
 	// PlaceHolders
 	FileDiff      = "file_diffs"
 	SummaryPoint  = "summary_points"
 	OutputLang    = "output_language"
 	OutputMessage = "output_message"
+	ReviewChunks  = "review_chunks"
 )
 
 //go:embed template/*
@@ -44,7 +52,7 @@ func SetTemplateDir(dir string) {
 
 // GetPromptTmpl reads a template file and executes it with the provided data.
 func GetPromptTmpl(file string, data map[string]any) (string, error) {
-	buf, err := processTmpl(file, data)
+	buf, err := processTmpl(file, data, true)
 	if err != nil {
 		return "", err
 	}
@@ -52,12 +60,30 @@ func GetPromptTmpl(file string, data map[string]any) (string, error) {
 	return buf.String(), nil
 }
 
-func processTmpl(file string, data map[string]any) (*bytes.Buffer, error) {
+// GetRawPromptTmpl is GetPromptTmpl without HTML escaping, for prompts that
+// must quote earlier model output verbatim.
+func GetRawPromptTmpl(file string, data map[string]any) (string, error) {
+	buf, err := processTmpl(file, data, false)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func processTmpl(file string, data map[string]any, escape bool) (*bytes.Buffer, error) {
 	output, err := loadTemplate(file)
 	if err != nil {
 		return nil, err
 	}
-	tmpl, err := template.New("").Parse(string(output))
+	var tmpl interface {
+		Execute(w io.Writer, data any) error
+	}
+	if escape {
+		tmpl, err = template.New("").Parse(string(output))
+	} else {
+		tmpl, err = texttemplate.New("").Parse(string(output))
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -3,6 +3,7 @@ package prompt
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -51,3 +52,23 @@ func TestCustomTemplatePathTraversal(t *testing.T) {
 		t.Fatal("expected error for path traversal")
 	}
 }
+
+func TestGetRawPromptTmpl(t *testing.T) {
+	text := `{"summary": "a<b", "findings": [`
+
+	content, err := GetRawPromptTmpl(CodeReviewJSONRepairTmpl, map[string]any{OutputMessage: text})
+	if err != nil {
+		t.Fatalf("GetRawPromptTmpl returned error: %v", err)
+	}
+	if !strings.Contains(content, text) {
+		t.Fatalf("expected the raw text in the prompt, got %q", content)
+	}
+
+	escaped, err := GetPromptTmpl(CodeReviewJSONRepairTmpl, map[string]any{OutputMessage: text})
+	if err != nil {
+		t.Fatalf("GetPromptTmpl returned error: %v", err)
+	}
+	if strings.Contains(escaped, text) {
+		t.Fatal("expected GetPromptTmpl to escape the text")
+	}
+}
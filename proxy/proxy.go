@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net/http"
@@ -29,7 +30,26 @@ func (c *customTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 		}
 	}
 
-	return c.transport.RoundTrip(req)
+	resp, err := c.transport.RoundTrip(req)
+	if err == nil {
+		if observe, ok := req.Context().Value(observerKey{}).(ResponseObserver); ok {
+			observe(resp)
+		}
+	}
+	return resp, err
+}
+
+// ResponseObserver is called with every response received for a request
+// whose context carries it. It must not read or close the body.
+type ResponseObserver func(resp *http.Response)
+
+type observerKey struct{}
+
+// WithResponseObserver returns a context that makes clients built by
+// Config.New report their responses to observe. Decorators use it to see
+// status codes and headers that provider SDKs do not expose in errors.
+func WithResponseObserver(ctx context.Context, observe ResponseObserver) context.Context {
+	return context.WithValue(ctx, observerKey{}, observe)
 }
 
 // toHTTPHeaders converts a slice of strings in the format "Key=Value" to http.Header.
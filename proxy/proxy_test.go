@@ -1,7 +1,9 @@
 package proxy
 
 import (
+	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -185,3 +187,28 @@ func TestCustomTransport_RoundTrip(t *testing.T) {
 		assert.Contains(t, err.Error(), "origin transport is not set")
 	})
 }
+
+func TestWithResponseObserver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := (&Config{}).New()
+	require.NoError(t, err)
+
+	var seen []int
+	ctx := WithResponseObserver(context.Background(), func(resp *http.Response) {
+		seen = append(seen, resp.StatusCode)
+		assert.Equal(t, "3", resp.Header.Get("Retry-After"))
+	})
+	for _, c := range []context.Context{ctx, context.Background()} {
+		req, err := http.NewRequestWithContext(c, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	assert.Equal(t, []int{http.StatusTooManyRequests}, seen)
+}
@@ -0,0 +1,330 @@
+package review
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/loveRyujin/ReviewBot/git"
+)
+
+// DroppableFiles lists generated and vendored files that are dropped first
+// when a diff does not fit the context window, in addition to the configured
+// exclude list.
+var DroppableFiles = []string{
+	"**/package-lock.json",
+	"**/npm-shrinkwrap.json",
+	"**/yarn.lock",
+	"**/pnpm-lock.yaml",
+	"**/Cargo.lock",
+	"**/Gemfile.lock",
+	"**/composer.lock",
+	"**/poetry.lock",
+	"**/Pipfile.lock",
+	"**/uv.lock",
+	"**/go.sum",
+	"**/vendor/",
+	"**/*.min.js",
+	"**/*.min.css",
+	"**/*.map",
+	"**/*.pb.go",
+	"**/*_gen.go",
+	"**/*.gen.go",
+	"**/*.snap",
+}
+
+// ErrPromptTooLarge is returned when even the prompt template alone does not
+// fit the context window.
+var ErrPromptTooLarge = errors.New("prompt template exceeds the model context window")
+
+// FitOptions describes the budget a diff must fit into.
+type FitOptions struct {
+	// Budget is the number of prompt tokens the model accepts; zero or less
+	// disables budgeting, so callers must reject budgets with no room left.
+	Budget int
+	// Count counts tokens with the tokenizer of the target model.
+	Count TokenEstimator
+	// Render builds the prompt that embeds the diff; it is used to measure
+	// the template overhead.
+	Render func(diff string) (string, error)
+	// Exclude lists file patterns that may be dropped; DroppableFiles and the
+	// default git excludes are always included.
+	Exclude []string
+	// ChunkTokens, when positive, enables chunked review: diffs above it or
+	// above the budget are split instead of truncated.
+	ChunkTokens int
+}
+
+// FitResult is a diff that fits the budget together with what was removed.
+type FitResult struct {
+	Diff string
+	// Tokens is the size of the rendered prompt for Diff.
+	Tokens int
+	// Chunks is set when the diff has to be reviewed in more than one request.
+	Chunks []Chunk
+	// Dropped lists files removed because they matched an exclude pattern.
+	Dropped []string
+	// ContextLines is the number of context lines kept around changes, or -1
+	// when the diff context was left untouched.
+	ContextLines int
+	// Truncated lists files whose hunks were cut to fit the budget.
+	Truncated []string
+}
+
+// Notes describes every reduction applied to the diff, one line each.
+func (r *FitResult) Notes() []string {
+	var notes []string
+	if len(r.Dropped) > 0 {
+		notes = append(notes, fmt.Sprintf("dropped %d excluded file(s): %s", len(r.Dropped), strings.Join(r.Dropped, ", ")))
+	}
+	if r.ContextLines >= 0 {
+		notes = append(notes, fmt.Sprintf("reduced diff context to %d line(s)", r.ContextLines))
+	}
+	if len(r.Chunks) > 1 {
+		notes = append(notes, fmt.Sprintf("switched to chunked review with %d chunks", len(r.Chunks)))
+	}
+	if len(r.Truncated) > 0 {
+		notes = append(notes, fmt.Sprintf("truncated %d file(s): %s", len(r.Truncated), strings.Join(r.Truncated, ", ")))
+	}
+	return notes
+}
+
+// Fit shrinks diff until its prompt fits opts.Budget. It drops excluded and
+// generated files first, then removes context lines, and finally either
+// switches to chunked review or truncates the remaining files.
+func Fit(diff string, opts FitOptions) (*FitResult, error) {
+	count := opts.Count
+	if count == nil {
+		count = EstimateTokens
+	}
+
+	rendered, err := opts.Render(diff)
+	if err != nil {
+		return nil, err
+	}
+	tokens := count(rendered)
+	diffTokens := count(diff)
+	overhead := max(tokens-diffTokens, 0)
+	available := opts.Budget - overhead
+	if opts.Budget > 0 && available <= 0 {
+		return nil, fmt.Errorf("%w (%d > %d tokens)", ErrPromptTooLarge, overhead, opts.Budget)
+	}
+
+	result := &FitResult{Diff: diff, Tokens: tokens, ContextLines: -1}
+	fits := func() bool { return opts.Budget <= 0 || diffTokens <= available }
+	if fits() && (opts.ChunkTokens <= 0 || diffTokens <= opts.ChunkTokens) {
+		return result, nil
+	}
+
+	files, err := git.ParseDiff(diff)
+	if err != nil {
+		return nil, err
+	}
+	if !fits() {
+		patterns := append(append(git.DefaultExcludes(), DroppableFiles...), opts.Exclude...)
+		if files, result.Dropped = dropFiles(files, patterns); len(result.Dropped) > 0 {
+			result.Diff = git.FormatDiff(files)
+			diffTokens = count(result.Diff)
+		}
+	}
+	for keep := 1; keep >= 0 && !fits(); keep-- {
+		files = trimContext(files, keep)
+		result.Diff = git.FormatDiff(files)
+		result.ContextLines = keep
+		diffTokens = count(result.Diff)
+	}
+
+	switch {
+	case opts.ChunkTokens > 0 && (!fits() || diffTokens > opts.ChunkTokens):
+		budget := opts.ChunkTokens
+		if opts.Budget > 0 {
+			budget = min(budget, available)
+		}
+		result.Chunks = splitFiles(files, budget, count)
+		if opts.Budget > 0 {
+			for i, c := range result.Chunks {
+				if c.Tokens <= available {
+					continue
+				}
+				chunkFiles, err := git.ParseDiff(c.Diff)
+				if err != nil {
+					return nil, err
+				}
+				var cut []string
+				chunkFiles, cut = truncateFiles(chunkFiles, available, count)
+				result.Chunks[i].Diff = git.FormatDiff(chunkFiles)
+				result.Chunks[i].Tokens = count(result.Chunks[i].Diff)
+				result.Truncated = append(result.Truncated, cut...)
+			}
+		}
+	case !fits():
+		files, result.Truncated = truncateFiles(files, available, count)
+		result.Diff = git.FormatDiff(files)
+		diffTokens = count(result.Diff)
+	}
+
+	result.Tokens = overhead + diffTokens
+	return result, nil
+}
+
+// dropFiles removes every file matching one of patterns.
+func dropFiles(files []*git.FileDiff, patterns []string) ([]*git.FileDiff, []string) {
+	var kept []*git.FileDiff
+	var dropped []string
+	for _, f := range files {
+		if matchAny(patterns, f.Name()) {
+			dropped = append(dropped, f.Name())
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, dropped
+}
+
+func matchAny(patterns []string, name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, p := range patterns {
+		if git.MatchExclude(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateFiles keeps whole hunks, in order, for as long as they fit budget
+// and returns the files that lost hunks.
+func truncateFiles(files []*git.FileDiff, budget int, count TokenEstimator) ([]*git.FileDiff, []string) {
+	var kept []*git.FileDiff
+	var truncated []string
+	used := 0
+	for _, f := range files {
+		headerTokens := count(f.Header())
+		if used+headerTokens > budget {
+			truncated = append(truncated, f.Name())
+			continue
+		}
+
+		n, hunkTokens := 0, 0
+		for _, h := range f.Hunks {
+			t := count(h.String())
+			if used+headerTokens+hunkTokens+t > budget {
+				break
+			}
+			hunkTokens += t
+			n++
+		}
+		if n < len(f.Hunks) {
+			truncated = append(truncated, f.Name())
+		}
+		if n == 0 && len(f.Hunks) > 0 {
+			continue
+		}
+		g := *f
+		g.Hunks = f.Hunks[:n]
+		kept = append(kept, &g)
+		used += headerTokens + hunkTokens
+	}
+	return kept, truncated
+}
+
+// TrimContext rewrites every hunk of diff to keep at most keep context lines
+// around changes. Hunks are split where the remaining context no longer
+// connects two changes, and their headers are recomputed.
+func TrimContext(diff string, keep int) (string, error) {
+	files, err := git.ParseDiff(diff)
+	if err != nil {
+		return "", err
+	}
+	return git.FormatDiff(trimContext(files, keep)), nil
+}
+
+func trimContext(files []*git.FileDiff, keep int) []*git.FileDiff {
+	trimmed := make([]*git.FileDiff, 0, len(files))
+	for _, f := range files {
+		g := *f
+		g.Hunks = nil
+		for _, h := range f.Hunks {
+			g.Hunks = append(g.Hunks, trimHunk(h, keep)...)
+		}
+		trimmed = append(trimmed, &g)
+	}
+	return trimmed
+}
+
+func trimHunk(h *git.Hunk, keep int) []*git.Hunk {
+	// distance from each line to the nearest change
+	dist := make([]int, len(h.Lines))
+	last := -1 << 30
+	for i, l := range h.Lines {
+		if l.Kind != git.LineContext {
+			last = i
+		}
+		dist[i] = i - last
+	}
+	last = 1 << 30
+	for i := len(h.Lines) - 1; i >= 0; i-- {
+		if h.Lines[i].Kind != git.LineContext {
+			last = i
+		}
+		dist[i] = min(dist[i], last-i)
+	}
+
+	// an empty side names the line before the hunk
+	oldLine, newLine := h.OldStart, h.NewStart
+	if h.OldLines == 0 {
+		oldLine++
+	}
+	if h.NewLines == 0 {
+		newLine++
+	}
+
+	var hunks []*git.Hunk
+	var run *git.Hunk
+	flush := func() {
+		if run == nil {
+			return
+		}
+		if run.OldLines == 0 {
+			run.OldStart--
+		}
+		if run.NewLines == 0 {
+			run.NewStart--
+		}
+		hunks = append(hunks, run)
+		run = nil
+	}
+	for i, l := range h.Lines {
+		if dist[i] > keep {
+			flush()
+		} else {
+			if run == nil {
+				run = &git.Hunk{OldStart: oldLine, NewStart: newLine, Section: h.Section}
+			}
+			run.Lines = append(run.Lines, l)
+		}
+
+		switch l.Kind {
+		case git.LineDeleted:
+			oldLine++
+			if run != nil {
+				run.OldLines++
+			}
+		case git.LineAdded:
+			newLine++
+			if run != nil {
+				run.NewLines++
+			}
+		default:
+			oldLine++
+			newLine++
+			if run != nil {
+				run.OldLines++
+				run.NewLines++
+			}
+		}
+	}
+	flush()
+	return hunks
+}
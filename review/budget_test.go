@@ -0,0 +1,144 @@
+package review
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrimContext(t *testing.T) {
+	hunk := "diff --git a/x.go b/x.go\n--- a/x.go\n+++ b/x.go\n" +
+		"@@ -10,6 +10,6 @@ func x() {\n a\n+b\n c\n d\n e\n-f\n g\n"
+	header := "diff --git a/x.go b/x.go\n--- a/x.go\n+++ b/x.go\n"
+
+	tests := []struct {
+		keep int
+		want string
+	}{
+		{
+			keep: 0,
+			want: header +
+				"@@ -10,0 +11 @@ func x() {\n+b\n" +
+				"@@ -14 +14,0 @@ func x() {\n-f\n",
+		},
+		{
+			keep: 1,
+			want: header +
+				"@@ -10,2 +10,3 @@ func x() {\n a\n+b\n c\n" +
+				"@@ -13,3 +14,2 @@ func x() {\n e\n-f\n g\n",
+		},
+		{
+			keep: 3,
+			want: header + "@@ -10,6 +10,6 @@ func x() {\n a\n+b\n c\n d\n e\n-f\n g\n",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := TrimContext(hunk, tt.keep)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "keep=%d", tt.keep)
+	}
+}
+
+func TestTrimContext_NoNewlineMarker(t *testing.T) {
+	diff := "--- a/x\n+++ b/x\n@@ -1,3 +1,3 @@\n a\n b\n-c\n\\ No newline at end of file\n+d\n\\ No newline at end of file\n"
+	want := "diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -3 +3 @@\n-c\n\\ No newline at end of file\n+d\n\\ No newline at end of file\n"
+	got, err := TrimContext(diff, 0)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestTrimContext_EmptySide(t *testing.T) {
+	diff := "diff --git a/x b/x\nnew file mode 100644\n--- /dev/null\n+++ b/x\n@@ -0,0 +1,2 @@\n+a\n+b\n"
+	got, err := TrimContext(diff, 0)
+	require.NoError(t, err)
+	assert.Equal(t, diff, got)
+}
+
+func renderWithOverhead(diff string) (string, error) {
+	return "REVIEW THIS\n" + diff, nil
+}
+
+func TestFit(t *testing.T) {
+	lockDiff := "diff --git a/web/yarn.lock b/web/yarn.lock\n--- a/web/yarn.lock\n+++ b/web/yarn.lock\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"
+	codeDiff := "diff --git a/x.go b/x.go\n--- a/x.go\n+++ b/x.go\n@@ -10,6 +10,6 @@\n a\n+b\n c\n d\n e\n-f\n g\n"
+	diff := lockDiff + codeDiff
+
+	t.Run("fits untouched", func(t *testing.T) {
+		fit, err := Fit(diff, FitOptions{Budget: 100, Count: lineCount, Render: renderWithOverhead})
+		require.NoError(t, err)
+		assert.Equal(t, diff, fit.Diff)
+		assert.Equal(t, lineCount(diff)+1, fit.Tokens)
+		assert.Empty(t, fit.Notes())
+	})
+
+	t.Run("drops excluded files first", func(t *testing.T) {
+		fit, err := Fit(diff, FitOptions{Budget: 12, Count: lineCount, Render: renderWithOverhead})
+		require.NoError(t, err)
+		assert.Equal(t, codeDiff, fit.Diff)
+		assert.Equal(t, []string{"web/yarn.lock"}, fit.Dropped)
+		assert.Equal(t, -1, fit.ContextLines)
+		assert.Equal(t, []string{"dropped 1 excluded file(s): web/yarn.lock"}, fit.Notes())
+	})
+
+	t.Run("configured excludes", func(t *testing.T) {
+		fit, err := Fit(codeDiff, FitOptions{Budget: 5, Count: lineCount, Render: renderWithOverhead, Exclude: []string{"*.go"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"x.go"}, fit.Dropped)
+		assert.Empty(t, fit.Diff)
+	})
+
+	t.Run("trims context lines", func(t *testing.T) {
+		wide := "--- a/y.go\n+++ b/y.go\n@@ -1,7 +1,7 @@\n 1\n 2\n 3\n-x\n+y\n 4\n 5\n 6\n"
+		fit, err := Fit(wide, FitOptions{Budget: 9, Count: lineCount, Render: renderWithOverhead})
+		require.NoError(t, err)
+		assert.Equal(t, 1, fit.ContextLines)
+		assert.Equal(t, "diff --git a/y.go b/y.go\n--- a/y.go\n+++ b/y.go\n@@ -3,3 +3,3 @@\n 3\n-x\n+y\n 4\n", fit.Diff)
+		assert.Equal(t, []string{"reduced diff context to 1 line(s)"}, fit.Notes())
+	})
+
+	t.Run("truncates without chunking", func(t *testing.T) {
+		fit, err := Fit(diff, FitOptions{Budget: 6, Count: lineCount, Render: renderWithOverhead})
+		require.NoError(t, err)
+		assert.Equal(t, 0, fit.ContextLines)
+		assert.Equal(t, []string{"x.go"}, fit.Truncated)
+		assert.Equal(t, "diff --git a/x.go b/x.go\n--- a/x.go\n+++ b/x.go\n@@ -10,0 +11 @@\n+b\n", fit.Diff)
+		assert.LessOrEqual(t, fit.Tokens, 6)
+	})
+
+	t.Run("switches to chunks", func(t *testing.T) {
+		fit, err := Fit(diff, FitOptions{Budget: 6, Count: lineCount, Render: renderWithOverhead, ChunkTokens: 1000})
+		require.NoError(t, err)
+		require.Len(t, fit.Chunks, 2)
+		assert.Empty(t, fit.Truncated)
+		for _, c := range fit.Chunks {
+			assert.LessOrEqual(t, c.Tokens, 5)
+		}
+		assert.Contains(t, fit.Notes(), "switched to chunked review with 2 chunks")
+	})
+
+	t.Run("chunks above chunk tokens even when the window fits", func(t *testing.T) {
+		fit, err := Fit(diff, FitOptions{Budget: 100, Count: lineCount, Render: renderWithOverhead, ChunkTokens: 10})
+		require.NoError(t, err)
+		assert.Equal(t, diff, fit.Diff)
+		require.Len(t, fit.Chunks, 2)
+		assert.Empty(t, fit.Dropped)
+	})
+
+	t.Run("template alone too large", func(t *testing.T) {
+		_, err := Fit(diff, FitOptions{Budget: 1, Count: lineCount, Render: renderWithOverhead})
+		assert.ErrorIs(t, err, ErrPromptTooLarge)
+	})
+}
+
+func TestFit_DefaultExcludes(t *testing.T) {
+	diff := "diff --git a/bin/app b/bin/app\n--- a/bin/app\n+++ b/bin/app\n@@ -1 +1 @@\n-x\n+y\n" +
+		"diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-x\n+y\n"
+
+	fit, err := Fit(diff, FitOptions{Budget: 9, Count: lineCount, Render: renderWithOverhead})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bin/app"}, fit.Dropped)
+	assert.True(t, strings.HasPrefix(fit.Diff, "diff --git a/main.go"))
+}
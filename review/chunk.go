@@ -0,0 +1,104 @@
+package review
+
+import (
+	"strings"
+
+	"github.com/loveRyujin/ReviewBot/git"
+)
+
+// Chunk is a slice of a unified diff that is reviewed in a single request.
+type Chunk struct {
+	Files  []string
+	Diff   string
+	Tokens int
+}
+
+// TokenEstimator returns the approximate number of tokens in s.
+type TokenEstimator func(s string) int
+
+// EstimateTokens approximates token counts at four bytes per token, which is
+// close enough for code and English prose to budget requests.
+func EstimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// SplitDiff splits a unified diff into chunks of at most budget tokens.
+// Files are kept whole when they fit and are otherwise split between hunks,
+// repeating the file header in every piece so that each chunk remains a
+// valid patch. A single hunk larger than the budget becomes its own chunk.
+// A budget of zero or less disables splitting.
+func SplitDiff(diff string, budget int, estimate TokenEstimator) ([]Chunk, error) {
+	if estimate == nil {
+		estimate = EstimateTokens
+	}
+	if strings.TrimSpace(diff) == "" {
+		return nil, nil
+	}
+	files, err := git.ParseDiff(diff)
+	if err != nil {
+		return nil, err
+	}
+	if budget <= 0 {
+		return []Chunk{{Files: fileNames(files), Diff: diff, Tokens: estimate(diff)}}, nil
+	}
+	return splitFiles(files, budget, estimate), nil
+}
+
+// splitFiles packs parsed files into chunks of at most budget tokens.
+func splitFiles(files []*git.FileDiff, budget int, estimate TokenEstimator) []Chunk {
+	var pieces []Chunk
+	for _, f := range files {
+		whole := f.String()
+		if tokens := estimate(whole); tokens <= budget || len(f.Hunks) < 2 {
+			pieces = append(pieces, Chunk{Files: []string{f.Name()}, Diff: whole, Tokens: tokens})
+			continue
+		}
+
+		var sb strings.Builder
+		header := f.Header()
+		tokens, headerTokens := 0, estimate(header)
+		flush := func() {
+			if sb.Len() > 0 {
+				pieces = append(pieces, Chunk{Files: []string{f.Name()}, Diff: header + sb.String(), Tokens: headerTokens + tokens})
+				sb.Reset()
+				tokens = 0
+			}
+		}
+		for _, h := range f.Hunks {
+			hunk := h.String()
+			ht := estimate(hunk)
+			if sb.Len() > 0 && headerTokens+tokens+ht > budget {
+				flush()
+			}
+			sb.WriteString(hunk)
+			tokens += ht
+		}
+		flush()
+	}
+
+	var chunks []Chunk
+	var cur Chunk
+	for _, p := range pieces {
+		if cur.Diff != "" && cur.Tokens+p.Tokens > budget {
+			chunks = append(chunks, cur)
+			cur = Chunk{}
+		}
+		cur.Diff += p.Diff
+		cur.Tokens += p.Tokens
+		if len(cur.Files) == 0 || cur.Files[len(cur.Files)-1] != p.Files[0] {
+			cur.Files = append(cur.Files, p.Files...)
+		}
+	}
+	if cur.Diff != "" {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
+
+func fileNames(files []*git.FileDiff) []string {
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	return names
+}
@@ -0,0 +1,137 @@
+package review
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const multiFileDiff = `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -1,2 +1,3 @@
+ package a
++// A
+ 
+@@ -20,2 +21,3 @@ func A() {
+ 	return
++	// end
+ }
+diff --git a/b.go b/b.go
+new file mode 100644
+--- /dev/null
++++ b/b.go
+@@ -0,0 +1 @@
++package b
+diff --git a/c.go b/c.go
+deleted file mode 100644
+--- a/c.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package c
+`
+
+// lineCount makes budgets in tests independent of byte lengths.
+func lineCount(s string) int {
+	return strings.Count(s, "\n")
+}
+
+func splitDiff(t *testing.T, diff string, budget int) []Chunk {
+	t.Helper()
+	chunks, err := SplitDiff(diff, budget, lineCount)
+	require.NoError(t, err)
+	return chunks
+}
+
+func TestSplitDiff(t *testing.T) {
+	t.Run("fits in one chunk", func(t *testing.T) {
+		chunks := splitDiff(t, multiFileDiff, 100)
+		require.Len(t, chunks, 1)
+		assert.Equal(t, multiFileDiff, chunks[0].Diff)
+		assert.Equal(t, []string{"a.go", "b.go", "c.go"}, chunks[0].Files)
+		assert.Equal(t, lineCount(multiFileDiff), chunks[0].Tokens)
+	})
+
+	t.Run("splits per file", func(t *testing.T) {
+		chunks := splitDiff(t, multiFileDiff, 12)
+		require.Len(t, chunks, 2)
+		assert.Equal(t, []string{"a.go"}, chunks[0].Files)
+		assert.Equal(t, []string{"b.go", "c.go"}, chunks[1].Files)
+		assert.Equal(t, multiFileDiff, chunks[0].Diff+chunks[1].Diff)
+		for _, c := range chunks {
+			assert.LessOrEqual(t, c.Tokens, 12)
+		}
+	})
+
+	t.Run("splits large files per hunk", func(t *testing.T) {
+		chunks := splitDiff(t, multiFileDiff, 11)
+		require.Len(t, chunks, 4)
+		assert.Equal(t, []string{"a.go"}, chunks[0].Files)
+		assert.Equal(t, []string{"a.go"}, chunks[1].Files)
+		assert.Equal(t, []string{"b.go"}, chunks[2].Files)
+		assert.Equal(t, []string{"c.go"}, chunks[3].Files)
+
+		header := "diff --git a/a.go b/a.go\nindex 1111111..2222222 100644\n--- a/a.go\n+++ b/a.go\n"
+		assert.Equal(t, header+"@@ -1,2 +1,3 @@\n package a\n+// A\n \n", chunks[0].Diff)
+		assert.Equal(t, header+"@@ -20,2 +21,3 @@ func A() {\n \treturn\n+\t// end\n }\n", chunks[1].Diff)
+	})
+
+	t.Run("oversized hunk stays whole", func(t *testing.T) {
+		chunks := splitDiff(t, multiFileDiff, 1)
+		require.Len(t, chunks, 4)
+		for _, c := range chunks {
+			assert.Contains(t, c.Diff, "@@ ")
+		}
+	})
+
+	t.Run("zero budget disables splitting", func(t *testing.T) {
+		chunks := splitDiff(t, multiFileDiff, 0)
+		require.Len(t, chunks, 1)
+		assert.Equal(t, []string{"a.go", "b.go", "c.go"}, chunks[0].Files)
+	})
+
+	t.Run("empty diff", func(t *testing.T) {
+		chunks, err := SplitDiff("  \n", 10, nil)
+		require.NoError(t, err)
+		assert.Empty(t, chunks)
+	})
+}
+
+func TestSplitDiff_PlainUnifiedDiff(t *testing.T) {
+	diff := "--- a/x.txt\t2024-01-01\n+++ b/x.txt\t2024-01-02\n@@ -1 +1 @@\n-old\n+new\n" +
+		"--- a/y.txt\n+++ b/y.txt\n@@ -1 +1 @@\n--- not a header\n+++ not a header\n"
+
+	chunks := splitDiff(t, diff, 5)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, []string{"x.txt"}, chunks[0].Files)
+	assert.Equal(t, []string{"y.txt"}, chunks[1].Files)
+	assert.Equal(t, "diff --git a/x.txt b/x.txt\n--- a/x.txt\n+++ b/x.txt\n@@ -1 +1 @@\n-old\n+new\n", chunks[0].Diff)
+	assert.Equal(t, "diff --git a/y.txt b/y.txt\n--- a/y.txt\n+++ b/y.txt\n@@ -1 +1 @@\n--- not a header\n+++ not a header\n", chunks[1].Diff)
+}
+
+func TestSplitDiff_RenamesAndQuotedPaths(t *testing.T) {
+	diff := "diff --git a/old.go b/new.go\nsimilarity index 90%\nrename from old.go\nrename to new.go\n" +
+		"--- a/old.go\n+++ b/new.go\n@@ -1 +1 @@\n-a\n+b\n@@ -9 +9 @@\n-c\n+d\n" +
+		"diff --git \"a/caf\\303\\251.go\" \"b/caf\\303\\251.go\"\n--- \"a/caf\\303\\251.go\"\n+++ \"b/caf\\303\\251.go\"\n@@ -1 +1 @@\n-x\n+y\n"
+
+	chunks := splitDiff(t, diff, 8)
+	require.Len(t, chunks, 3)
+	assert.Equal(t, []string{"new.go"}, chunks[0].Files)
+	assert.Equal(t, []string{"new.go"}, chunks[1].Files)
+	assert.Equal(t, []string{"café.go"}, chunks[2].Files)
+	assert.True(t, strings.HasPrefix(chunks[1].Diff, "diff --git a/old.go b/new.go\nsimilarity index 90%\nrename from old.go\nrename to new.go\n"))
+}
+
+func TestSplitDiff_Malformed(t *testing.T) {
+	_, err := SplitDiff("diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -1,3 +1,3 @@\n a\n", 10, lineCount)
+	assert.Error(t, err)
+}
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, EstimateTokens(""))
+	assert.Equal(t, 1, EstimateTokens("abc"))
+	assert.Equal(t, 2, EstimateTokens("abcdefgh"))
+}
@@ -0,0 +1,204 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/prompt"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultConcurrency is the number of chunks reviewed in parallel when
+// ChunkOptions.Concurrency is not set.
+const DefaultConcurrency = 4
+
+// ChunkOptions configures a chunked review.
+type ChunkOptions struct {
+	// Concurrency bounds the number of in-flight requests.
+	Concurrency int
+	// Lang is the output language passed to the structured review prompt.
+	Lang string
+	// OnProgress, when set, is called after each chunk has been reviewed.
+	OnProgress func(done, total int)
+}
+
+func (o ChunkOptions) concurrency() int {
+	if o.Concurrency <= 0 {
+		return DefaultConcurrency
+	}
+	return o.Concurrency
+}
+
+// forEachChunk runs fn for every chunk with bounded concurrency, cancelling
+// the remaining work on the first error.
+func forEachChunk(ctx context.Context, chunks []Chunk, opts ChunkOptions, fn func(ctx context.Context, i int, c Chunk) (ai.TokenUsage, error)) (ai.TokenUsage, error) {
+	var (
+		mu    sync.Mutex
+		usage ai.TokenUsage
+		done  int
+	)
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(opts.concurrency())
+	for i, c := range chunks {
+		g.Go(func() error {
+			u, err := fn(ctx, i, c)
+			if err != nil {
+				return fmt.Errorf("review chunk %d/%d (%s): %w", i+1, len(chunks), strings.Join(c.Files, ", "), err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			usage = usage.Add(u)
+			done++
+			if opts.OnProgress != nil {
+				opts.OnProgress(done, len(chunks))
+			}
+			return nil
+		})
+	}
+	err := g.Wait()
+	return usage, err
+}
+
+// ReviewChunks reviews every chunk with the free-form review prompt and
+// returns the reviews in chunk order.
+func ReviewChunks(ctx context.Context, client ai.TextGenerator, chunks []Chunk, opts ChunkOptions) ([]string, ai.TokenUsage, error) {
+	reviews := make([]string, len(chunks))
+	usage, err := forEachChunk(ctx, chunks, opts, func(ctx context.Context, i int, c Chunk) (ai.TokenUsage, error) {
+		instruction, err := prompt.GetPromptTmpl(prompt.CodeReviewFileDiffTmpl, map[string]any{prompt.FileDiff: c.Diff})
+		if err != nil {
+			return ai.TokenUsage{}, err
+		}
+		resp, err := client.ChatCompletion(ctx, instruction)
+		if err != nil {
+			return ai.TokenUsage{}, err
+		}
+		reviews[i] = strings.TrimSpace(resp.Text)
+		return resp.TokenUsage, nil
+	})
+	if err != nil {
+		return nil, usage, err
+	}
+	return reviews, usage, nil
+}
+
+// AggregatePrompt renders the final pass that merges per-chunk reviews into
+// a single review without duplicated remarks.
+func AggregatePrompt(chunks []Chunk, reviews []string) (string, error) {
+	return prompt.GetPromptTmpl(prompt.CodeReviewAggregateTmpl, map[string]any{
+		prompt.ReviewChunks: formatChunkReviews(chunks, reviews),
+	})
+}
+
+// StructuredChunks reviews every chunk with the JSON prompt, merges and
+// dedupes the findings and asks the model once more for an overall summary.
+func StructuredChunks(ctx context.Context, client ai.TextGenerator, chunks []Chunk, opts ChunkOptions) (*Result, ai.TokenUsage, error) {
+	switch len(chunks) {
+	case 0:
+		return &Result{}, ai.TokenUsage{}, nil
+	case 1:
+		return Structured(ctx, client, chunks[0].Diff, opts.Lang)
+	}
+
+	results := make([]*Result, len(chunks))
+	usage, err := forEachChunk(ctx, chunks, opts, func(ctx context.Context, i int, c Chunk) (ai.TokenUsage, error) {
+		result, u, err := Structured(ctx, client, c.Diff, opts.Lang)
+		results[i] = result
+		return u, err
+	})
+	if err != nil {
+		return nil, usage, err
+	}
+
+	var findings []Finding
+	summaries := make([]string, len(results))
+	for i, r := range results {
+		findings = append(findings, r.Findings...)
+		summaries[i] = r.Summary
+	}
+	merged := &Result{Findings: MergeFindings(findings)}
+
+	instruction, err := prompt.GetPromptTmpl(prompt.CodeReviewAggregateSummaryTmpl, map[string]any{
+		prompt.ReviewChunks: formatChunkReviews(chunks, summaries),
+		prompt.OutputLang:   opts.Lang,
+	})
+	if err != nil {
+		return nil, usage, err
+	}
+	resp, err := client.ChatCompletion(ctx, instruction)
+	if err != nil {
+		return nil, usage, err
+	}
+	merged.Summary = strings.TrimSpace(resp.Text)
+
+	return merged, usage.Add(resp.TokenUsage), nil
+}
+
+func formatChunkReviews(chunks []Chunk, reviews []string) string {
+	var sb strings.Builder
+	for i, r := range reviews {
+		if strings.TrimSpace(r) == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "### Part %d/%d: %s\n\n%s\n\n", i+1, len(reviews), strings.Join(chunks[i].Files, ", "), strings.TrimSpace(r))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// MergeFindings removes duplicates reported by overlapping chunks. Two
+// findings are duplicates when they share file and category, their line
+// ranges overlap or touch, and their titles match after normalization. The
+// most severe copy is kept and its range widened to cover both.
+func MergeFindings(findings []Finding) []Finding {
+	merged := make([]Finding, 0, len(findings))
+	for _, f := range findings {
+		dup := -1
+		for i, m := range merged {
+			if isDuplicate(m, f) {
+				dup = i
+				break
+			}
+		}
+		if dup < 0 {
+			merged = append(merged, f)
+			continue
+		}
+
+		m := &merged[dup]
+		start, end := min(m.StartLine, f.StartLine), max(m.EndLine, f.EndLine)
+		if m.StartLine == 0 || f.StartLine == 0 {
+			start = max(m.StartLine, f.StartLine)
+		}
+		if f.Severity.Rank() < m.Severity.Rank() {
+			*m = f
+		}
+		if m.Suggestion == "" {
+			m.Suggestion = f.Suggestion
+		}
+		m.StartLine, m.EndLine = start, end
+	}
+	SortFindings(merged)
+	return merged
+}
+
+func isDuplicate(a, b Finding) bool {
+	if a.File != b.File || a.Category != b.Category {
+		return false
+	}
+	if normalizeTitle(a.Title) != normalizeTitle(b.Title) {
+		return false
+	}
+	if a.StartLine == 0 || b.StartLine == 0 {
+		return true
+	}
+	return a.StartLine <= b.EndLine+1 && b.StartLine <= a.EndLine+1
+}
+
+func normalizeTitle(s string) string {
+	s = strings.ToLower(strings.TrimRight(strings.TrimSpace(s), ".!"))
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,116 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func promptContains(s string) any {
+	return mock.MatchedBy(func(text string) bool { return strings.Contains(text, s) })
+}
+
+func requestContains(s string) any {
+	return mock.MatchedBy(func(req *ai.Request) bool { return strings.Contains(req.Messages[0].Content, s) })
+}
+
+func TestReviewChunks(t *testing.T) {
+	chunks := splitDiff(t, multiFileDiff, 11)
+	require.Len(t, chunks, 4)
+
+	client := new(mocks.MockTextGenerator)
+	client.On("ChatCompletion", mock.Anything, promptContains("// A")).Return(&ai.Response{Text: "review 1", TokenUsage: ai.TokenUsage{TotalTokens: 1}}, nil)
+	client.On("ChatCompletion", mock.Anything, promptContains("// end")).Return(&ai.Response{Text: "review 2", TokenUsage: ai.TokenUsage{TotalTokens: 2}}, nil)
+	client.On("ChatCompletion", mock.Anything, promptContains("package b")).Return(&ai.Response{Text: "review 3", TokenUsage: ai.TokenUsage{TotalTokens: 3}}, nil)
+	client.On("ChatCompletion", mock.Anything, promptContains("package c")).Return(&ai.Response{Text: "  ", TokenUsage: ai.TokenUsage{TotalTokens: 4}}, nil)
+
+	var calls atomic.Int32
+	reviews, usage, err := ReviewChunks(context.Background(), client, chunks, ChunkOptions{
+		Concurrency: 2,
+		OnProgress: func(done, total int) {
+			calls.Add(1)
+			assert.Equal(t, 4, total)
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"review 1", "review 2", "review 3", ""}, reviews)
+	assert.Equal(t, 10, usage.TotalTokens)
+	assert.Equal(t, int32(4), calls.Load())
+
+	aggregate, err := AggregatePrompt(chunks, reviews)
+	require.NoError(t, err)
+	assert.Contains(t, aggregate, "### Part 1/4: a.go\n\nreview 1")
+	assert.Contains(t, aggregate, "### Part 3/4: b.go\n\nreview 3")
+	assert.NotContains(t, aggregate, "Part 4/4")
+}
+
+func TestReviewChunks_Error(t *testing.T) {
+	chunks := splitDiff(t, multiFileDiff, 11)
+
+	client := new(mocks.MockTextGenerator)
+	client.On("ChatCompletion", mock.Anything, promptContains("package b")).Return(nil, errors.New("rate limited"))
+	client.On("ChatCompletion", mock.Anything, mock.Anything).Return(&ai.Response{Text: "ok"}, nil)
+
+	_, _, err := ReviewChunks(context.Background(), client, chunks, ChunkOptions{Concurrency: 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "review chunk 3/4 (b.go)")
+	assert.Contains(t, err.Error(), "rate limited")
+}
+
+func TestStructuredChunks(t *testing.T) {
+	chunks := splitDiff(t, multiFileDiff, 12)
+	require.Len(t, chunks, 2)
+
+	client := new(mocks.MockTextGenerator)
+	client.On("Chat", mock.Anything, requestContains("// A")).Return(&ai.Response{
+		Text: `{"summary":"a is fine","findings":[
+			{"file":"a.go","start_line":2,"severity":"low","category":"style","title":"Comment style"},
+			{"file":"a.go","start_line":22,"severity":"medium","category":"bug","title":"Dead code"}]}`,
+		TokenUsage: ai.TokenUsage{TotalTokens: 5},
+	}, nil)
+	client.On("Chat", mock.Anything, requestContains("package b")).Return(&ai.Response{
+		Text: `{"summary":"b is new","findings":[
+			{"file":"a.go","start_line":3,"severity":"high","category":"style","title":"comment style.","suggestion":"Use godoc"},
+			{"file":"b.go","start_line":1,"severity":"info","category":"documentation","title":"Missing package doc"}]}`,
+		TokenUsage: ai.TokenUsage{TotalTokens: 7},
+	}, nil)
+	client.On("ChatCompletion", mock.Anything, mock.MatchedBy(func(text string) bool {
+		return strings.Contains(text, "a is fine") && strings.Contains(text, "b is new")
+	})).Return(&ai.Response{Text: " Overall fine. ", TokenUsage: ai.TokenUsage{TotalTokens: 3}}, nil)
+
+	result, usage, err := StructuredChunks(context.Background(), client, chunks, ChunkOptions{Lang: "English"})
+	require.NoError(t, err)
+	assert.Equal(t, "Overall fine.", result.Summary)
+	assert.Equal(t, 15, usage.TotalTokens)
+	require.Len(t, result.Findings, 3)
+
+	assert.Equal(t, Finding{
+		File: "a.go", StartLine: 2, EndLine: 3, Severity: SeverityHigh, Category: CategoryStyle,
+		Title: "comment style.", Message: "comment style.", Suggestion: "Use godoc",
+	}, result.Findings[0])
+	assert.Equal(t, "Dead code", result.Findings[1].Title)
+	assert.Equal(t, "Missing package doc", result.Findings[2].Title)
+	client.AssertExpectations(t)
+}
+
+func TestMergeFindings(t *testing.T) {
+	findings := []Finding{
+		{File: "a.go", StartLine: 10, EndLine: 12, Severity: SeverityLow, Category: CategoryBug, Title: "Nil check"},
+		{File: "a.go", StartLine: 40, EndLine: 40, Severity: SeverityLow, Category: CategoryBug, Title: "Nil check"},
+		{File: "a.go", StartLine: 13, EndLine: 14, Severity: SeverityMedium, Category: CategoryBug, Title: "nil  check"},
+		{File: "a.go", StartLine: 11, EndLine: 11, Severity: SeverityLow, Category: CategoryStyle, Title: "Nil check"},
+		{File: "b.go", StartLine: 11, EndLine: 11, Severity: SeverityLow, Category: CategoryBug, Title: "Nil check"},
+	}
+
+	merged := MergeFindings(findings)
+	require.Len(t, merged, 4)
+	assert.Equal(t, Finding{File: "a.go", StartLine: 10, EndLine: 14, Severity: SeverityMedium, Category: CategoryBug, Title: "nil  check"}, merged[0])
+}
@@ -0,0 +1,193 @@
+package review
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity ranks how urgently a finding should be addressed.
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+	SeverityInfo     Severity = "info"
+)
+
+// Category groups findings by the kind of problem they describe.
+type Category string
+
+const (
+	CategoryBug             Category = "bug"
+	CategorySecurity        Category = "security"
+	CategoryPerformance     Category = "performance"
+	CategoryMaintainability Category = "maintainability"
+	CategoryStyle           Category = "style"
+	CategoryTesting         Category = "testing"
+	CategoryDocumentation   Category = "documentation"
+	CategoryOther           Category = "other"
+)
+
+// Severities lists the accepted severities from most to least severe.
+var Severities = []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo}
+
+// Categories lists the accepted categories.
+var Categories = []Category{
+	CategoryBug,
+	CategorySecurity,
+	CategoryPerformance,
+	CategoryMaintainability,
+	CategoryStyle,
+	CategoryTesting,
+	CategoryDocumentation,
+	CategoryOther,
+}
+
+// severityAliases maps common model spellings onto the canonical severities.
+var severityAliases = map[string]Severity{
+	"critical": SeverityCritical,
+	"blocker":  SeverityCritical,
+	"high":     SeverityHigh,
+	"major":    SeverityHigh,
+	"error":    SeverityHigh,
+	"medium":   SeverityMedium,
+	"moderate": SeverityMedium,
+	"warning":  SeverityMedium,
+	"low":      SeverityLow,
+	"minor":    SeverityLow,
+	"info":     SeverityInfo,
+	"note":     SeverityInfo,
+	"nit":      SeverityInfo,
+}
+
+// categoryAliases maps common model spellings onto the canonical categories.
+var categoryAliases = map[string]Category{
+	"bug":             CategoryBug,
+	"correctness":     CategoryBug,
+	"logic":           CategoryBug,
+	"security":        CategorySecurity,
+	"vulnerability":   CategorySecurity,
+	"performance":     CategoryPerformance,
+	"perf":            CategoryPerformance,
+	"maintainability": CategoryMaintainability,
+	"readability":     CategoryMaintainability,
+	"design":          CategoryMaintainability,
+	"style":           CategoryStyle,
+	"formatting":      CategoryStyle,
+	"testing":         CategoryTesting,
+	"test":            CategoryTesting,
+	"tests":           CategoryTesting,
+	"documentation":   CategoryDocumentation,
+	"docs":            CategoryDocumentation,
+	"other":           CategoryOther,
+}
+
+// Finding is a single review comment anchored to a line range of a file.
+type Finding struct {
+	File       string   `json:"file"`
+	StartLine  int      `json:"start_line"`
+	EndLine    int      `json:"end_line"`
+	Severity   Severity `json:"severity"`
+	Category   Category `json:"category"`
+	Title      string   `json:"title"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion,omitempty"`
+}
+
+var errEmptyFinding = errors.New("finding has neither title nor message")
+
+// normalize repairs a finding in place so that it satisfies the schema:
+// enum values are canonicalized, line ranges are ordered and non-negative,
+// and missing titles are derived from the message.
+func (f *Finding) normalize() error {
+	f.File = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(f.File), "a/"), "b/")
+	f.Title = strings.TrimSpace(f.Title)
+	f.Message = strings.TrimSpace(f.Message)
+	f.Suggestion = strings.TrimSpace(f.Suggestion)
+
+	if f.Title == "" && f.Message == "" {
+		return errEmptyFinding
+	}
+	if f.Title == "" {
+		f.Title = firstSentence(f.Message)
+	}
+	if f.Message == "" {
+		f.Message = f.Title
+	}
+
+	if sev, ok := severityAliases[strings.ToLower(strings.TrimSpace(string(f.Severity)))]; ok {
+		f.Severity = sev
+	} else {
+		f.Severity = SeverityMedium
+	}
+	if cat, ok := categoryAliases[strings.ToLower(strings.TrimSpace(string(f.Category)))]; ok {
+		f.Category = cat
+	} else {
+		f.Category = CategoryOther
+	}
+
+	if f.StartLine < 0 {
+		f.StartLine = 0
+	}
+	if f.EndLine < 0 {
+		f.EndLine = 0
+	}
+	if f.EndLine == 0 {
+		f.EndLine = f.StartLine
+	}
+	if f.StartLine == 0 {
+		f.StartLine = f.EndLine
+	}
+	if f.EndLine < f.StartLine {
+		f.StartLine, f.EndLine = f.EndLine, f.StartLine
+	}
+	return nil
+}
+
+// Validate reports whether the finding satisfies the schema without repairing it.
+func (f Finding) Validate() error {
+	if strings.TrimSpace(f.Title) == "" || strings.TrimSpace(f.Message) == "" {
+		return errEmptyFinding
+	}
+	if !slices.Contains(Severities, f.Severity) {
+		return fmt.Errorf("invalid severity %q", f.Severity)
+	}
+	if !slices.Contains(Categories, f.Category) {
+		return fmt.Errorf("invalid category %q", f.Category)
+	}
+	if f.StartLine < 0 || f.EndLine < f.StartLine {
+		return fmt.Errorf("invalid line range %d-%d", f.StartLine, f.EndLine)
+	}
+	return nil
+}
+
+// Rank orders severities so that lower values are more severe.
+func (s Severity) Rank() int {
+	for i, sev := range Severities {
+		if sev == s {
+			return i
+		}
+	}
+	return len(Severities)
+}
+
+func firstSentence(s string) string {
+	if i := strings.IndexAny(s, ".\n"); i > 0 {
+		s = s[:i]
+	}
+	const maxTitle = 80
+	if len(s) > maxTitle {
+		// cut at the last complete rune within the limit
+		cut := maxTitle
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = strings.TrimSpace(s[:cut]) + "..."
+	}
+	return s
+}
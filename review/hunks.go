@@ -0,0 +1,88 @@
+package review
+
+import (
+	"github.com/loveRyujin/ReviewBot/git"
+)
+
+// LineRange is an inclusive range of line numbers in the new version of a file.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// Hunks maps file paths to the line ranges touched by a patch.
+type Hunks map[string][]LineRange
+
+// ParseHunks collects the new-side line ranges of every hunk in a unified diff.
+// Deleted files and pure deletions are skipped because they have no lines to
+// point at in the new version. A malformed tail still yields the hunks
+// parsed before it.
+func ParseHunks(diff string) Hunks {
+	hunks := make(Hunks)
+
+	files, _ := git.ParseDiff(diff)
+	for _, f := range files {
+		if f.NewName == "" {
+			continue
+		}
+		for _, h := range f.Hunks {
+			if h.NewLines == 0 {
+				continue
+			}
+			hunks[f.NewName] = append(hunks[f.NewName], LineRange{Start: h.NewStart, End: h.NewStart + h.NewLines - 1})
+		}
+	}
+	return hunks
+}
+
+// Locate anchors a finding to the hunks of its file. A range that overlaps a
+// hunk is clipped to it; a range outside every hunk, or a finding without
+// line numbers, is moved to the closest hunk. ok is false when the file is
+// not part of the patch.
+func (h Hunks) Locate(f Finding) (r LineRange, ok bool) {
+	ranges := h[f.File]
+	if len(ranges) == 0 {
+		return LineRange{}, false
+	}
+
+	best, bestDist := ranges[0], -1
+	for _, hr := range ranges {
+		if f.StartLine <= hr.End && f.EndLine >= hr.Start {
+			return LineRange{Start: max(f.StartLine, hr.Start), End: min(f.EndLine, hr.End)}, true
+		}
+		dist := hr.Start - f.EndLine
+		if f.StartLine > hr.End {
+			dist = f.StartLine - hr.End
+		}
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = hr, dist
+		}
+	}
+	return best, true
+}
+
+// Placement is a finding anchored to changed lines of the new version.
+type Placement struct {
+	Finding Finding
+	Lines   LineRange
+}
+
+// Place anchors findings to the hunks for inline comments. Findings whose
+// file is not part of the patch are returned as unplaced.
+func (h Hunks) Place(findings []Finding) (placed []Placement, unplaced []Finding) {
+	for _, f := range findings {
+		if r, ok := h.Locate(f); ok {
+			placed = append(placed, Placement{Finding: f, Lines: r})
+		} else {
+			unplaced = append(unplaced, f)
+		}
+	}
+	return placed, unplaced
+}
+
+// Moved reports whether the finding was reported outside the lines it is
+// anchored to.
+func (p Placement) Moved() bool {
+	f := p.Finding
+	return f.StartLine > 0 && (f.StartLine < p.Lines.Start || f.EndLine > p.Lines.End)
+}
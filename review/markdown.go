@@ -0,0 +1,87 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown renders the finding as the body of an inline review comment.
+func (f Finding) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**[%s] %s: %s**", strings.ToUpper(string(f.Severity)), titleCase(string(f.Category)), f.Title)
+	if f.Message != "" && f.Message != f.Title {
+		sb.WriteString("\n\n")
+		sb.WriteString(f.Message)
+	}
+	if f.Suggestion != "" {
+		sb.WriteString("\n\n**Suggestion:** ")
+		sb.WriteString(f.Suggestion)
+	}
+	return sb.String()
+}
+
+// Markdown renders the comment of a placed finding and notes when it had to
+// be moved onto the changed lines.
+func (p Placement) Markdown() string {
+	body := p.Finding.Markdown()
+	if p.Moved() {
+		body += fmt.Sprintf("\n\n_Reported for %s, outside the changed lines._", lineRef(p.Finding))
+	}
+	return body
+}
+
+// SummaryMarkdown renders the summary comment of a review: the overall
+// assessment, the finding counts and the findings that could not be placed
+// as inline comments.
+func (r *Report) SummaryMarkdown(unplaced []Finding) string {
+	var sb strings.Builder
+	sb.WriteString("## ReviewBot\n\n")
+	if summary := strings.TrimSpace(r.Summary); summary != "" {
+		sb.WriteString(summary)
+		sb.WriteString("\n\n")
+	}
+
+	if len(r.Findings) == 0 {
+		sb.WriteString("No issues found.\n")
+	} else {
+		counts := make(map[Severity]int)
+		for _, f := range r.Findings {
+			counts[f.Severity]++
+		}
+		var parts []string
+		for _, s := range Severities {
+			if counts[s] > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", counts[s], s))
+			}
+		}
+		fmt.Fprintf(&sb, "**Findings:** %s\n", strings.Join(parts, ", "))
+	}
+
+	if len(unplaced) > 0 {
+		sb.WriteString("\n### Findings outside the diff\n\n")
+		for _, f := range unplaced {
+			fmt.Fprintf(&sb, "- **[%s] %s**", strings.ToUpper(string(f.Severity)), f.Title)
+			if f.File != "" {
+				fmt.Fprintf(&sb, " `%s`", f.File)
+				if f.StartLine > 0 {
+					fmt.Fprintf(&sb, " (%s)", lineRef(f))
+				}
+			}
+			if f.Message != "" && f.Message != f.Title {
+				sb.WriteString(": ")
+				sb.WriteString(f.Message)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	fmt.Fprintf(&sb, "\n---\n_Reviewed by ReviewBot using %s/%s_\n", r.Provider, r.Model)
+	return sb.String()
+}
+
+func lineRef(f Finding) string {
+	if f.EndLine > f.StartLine {
+		return fmt.Sprintf("lines %d-%d", f.StartLine, f.EndLine)
+	}
+	return fmt.Sprintf("line %d", f.StartLine)
+}
@@ -0,0 +1,74 @@
+package review
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/loveRyujin/ReviewBot/pkg/jsonx"
+)
+
+// Result is the structured answer expected from the model.
+type Result struct {
+	Summary  string    `json:"summary"`
+	Findings []Finding `json:"findings"`
+}
+
+// Parse extracts a Result from a model response. It tolerates code fences,
+// prose around the JSON payload, trailing commas, a bare findings array and
+// out-of-range enum values, which are repaired rather than rejected.
+func Parse(text string) (*Result, error) {
+	payload, err := jsonx.Extract(text)
+	if err != nil {
+		return nil, fmt.Errorf("review: %w", err)
+	}
+
+	result, err := decode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("review: decode response: %w", err)
+	}
+
+	result.Summary = strings.TrimSpace(result.Summary)
+	findings := make([]Finding, 0, len(result.Findings))
+	for _, f := range result.Findings {
+		if err := f.normalize(); err != nil {
+			continue
+		}
+		findings = append(findings, f)
+	}
+	SortFindings(findings)
+	result.Findings = findings
+
+	return result, nil
+}
+
+// SortFindings orders findings by severity, then file and line.
+func SortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity.Rank() != b.Severity.Rank() {
+			return a.Severity.Rank() < b.Severity.Rank()
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.StartLine < b.StartLine
+	})
+}
+
+// decode accepts either a Result object or a bare array of findings.
+func decode(payload string) (*Result, error) {
+	if strings.HasPrefix(payload, "[") {
+		var findings []Finding
+		if err := jsonx.Unmarshal(payload, &findings); err != nil {
+			return nil, err
+		}
+		return &Result{Findings: findings}, nil
+	}
+
+	var result Result
+	if err := jsonx.Unmarshal(payload, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,101 @@
+package review
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/loveRyujin/ReviewBot/pkg/jsonx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		wantSummary  string
+		wantFindings []Finding
+	}{
+		{
+			name:        "plain object",
+			text:        `{"summary":"ok","findings":[{"file":"main.go","start_line":3,"end_line":4,"severity":"high","category":"bug","title":"Nil deref","message":"x may be nil"}]}`,
+			wantSummary: "ok",
+			wantFindings: []Finding{
+				{File: "main.go", StartLine: 3, EndLine: 4, Severity: SeverityHigh, Category: CategoryBug, Title: "Nil deref", Message: "x may be nil"},
+			},
+		},
+		{
+			name:        "code fence with prose",
+			text:        "Here is the review:\n```json\n{\"summary\": \"fine\", \"findings\": []}\n```\nThanks!",
+			wantSummary: "fine",
+		},
+		{
+			name:        "trailing commas",
+			text:        `{"summary":"s","findings":[{"title":"t","message":"m",},],}`,
+			wantSummary: "s",
+			wantFindings: []Finding{
+				{Severity: SeverityMedium, Category: CategoryOther, Title: "t", Message: "m"},
+			},
+		},
+		{
+			name: "bare findings array",
+			text: `[{"file":"b/a.go","start_line":9,"severity":"warning","category":"perf","message":"Allocates in a loop. Hoist it."}]`,
+			wantFindings: []Finding{
+				{File: "a.go", StartLine: 9, EndLine: 9, Severity: SeverityMedium, Category: CategoryPerformance, Title: "Allocates in a loop", Message: "Allocates in a loop. Hoist it."},
+			},
+		},
+		{
+			name: "swapped lines and empty findings dropped",
+			text: `{"findings":[{"title":"t","start_line":10,"end_line":2},{"file":"x.go"}]}`,
+			wantFindings: []Finding{
+				{StartLine: 2, EndLine: 10, Severity: SeverityMedium, Category: CategoryOther, Title: "t", Message: "t"},
+			},
+		},
+		{
+			name:        "sorted by severity",
+			text:        `{"summary":"s","findings":[{"title":"low","severity":"low"},{"title":"crit","severity":"critical"}]}`,
+			wantSummary: "s",
+			wantFindings: []Finding{
+				{Severity: SeverityCritical, Category: CategoryOther, Title: "crit", Message: "crit"},
+				{Severity: SeverityLow, Category: CategoryOther, Title: "low", Message: "low"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Parse(tt.text)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSummary, result.Summary)
+			if len(tt.wantFindings) == 0 {
+				assert.Empty(t, result.Findings)
+				return
+			}
+			assert.Equal(t, tt.wantFindings, result.Findings)
+			for _, f := range result.Findings {
+				assert.NoError(t, f.Validate())
+			}
+		})
+	}
+}
+
+func TestParse_MultibyteTitle(t *testing.T) {
+	// 78 bytes of CJK, then an emoji straddling the 80 byte limit
+	message := strings.Repeat("空指针", 8) + "空指" + "🙂" + strings.Repeat("解引用", 10)
+	result, err := Parse(`[{"file":"a.go","start_line":1,"message":"` + message + `"}]`)
+	require.NoError(t, err)
+	require.Len(t, result.Findings, 1)
+
+	title := result.Findings[0].Title
+	assert.True(t, utf8.ValidString(title), "title %q is not valid UTF-8", title)
+	assert.Equal(t, strings.Repeat("空指针", 8)+"空指...", title)
+}
+
+func TestParse_Errors(t *testing.T) {
+	_, err := Parse("The code looks good to me.")
+	assert.ErrorIs(t, err, jsonx.ErrNoJSON)
+
+	_, err = Parse(`{"summary": "unterminated`)
+	assert.Error(t, err)
+}
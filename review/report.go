@@ -0,0 +1,52 @@
+package review
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+)
+
+// Report is the machine-readable output of a review run.
+type Report struct {
+	Provider string `json:"provider"`
+	// Commit is set when the report covers a single commit of a range.
+	Commit     string        `json:"commit,omitempty"`
+	Model      string        `json:"model"`
+	Summary    string        `json:"summary"`
+	Findings   []Finding     `json:"findings"`
+	TokenUsage ai.TokenUsage `json:"token_usage"`
+}
+
+// NewReport wraps a parsed result with provider metadata and token usage.
+func NewReport(provider, model string, result *Result, usage ai.TokenUsage) *Report {
+	findings := result.Findings
+	if findings == nil {
+		findings = []Finding{}
+	}
+	return &Report{
+		Provider:   provider,
+		Model:      model,
+		Summary:    result.Summary,
+		Findings:   findings,
+		TokenUsage: usage,
+	}
+}
+
+// WriteJSON encodes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	return writeJSON(w, r)
+}
+
+// WriteJSONReports encodes several reports, e.g. one per reviewed commit, as
+// an indented JSON array.
+func WriteJSONReports(w io.Writer, reports []*Report) error {
+	return writeJSON(w, reports)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
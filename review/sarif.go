@@ -0,0 +1,237 @@
+package review
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/loveRyujin/ReviewBot/pkg/version"
+)
+
+const (
+	// SARIFVersion is the version of the SARIF specification produced by WriteSARIF.
+	SARIFVersion = "2.1.0"
+	// SARIFSchema is the URI of the SARIF 2.1.0 JSON schema.
+	SARIFSchema = "https://docs.oasis-open.org/sarif/sarif/v2.1.0/errata01/os/schemas/sarif-schema-2.1.0.json"
+
+	sarifToolName = "ReviewBot"
+	sarifToolURI  = "https://github.com/loveRyujin/ReviewBot"
+	srcRoot       = "%SRCROOT%"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool      `json:"tool"`
+	Results     []sarifResult  `json:"results"`
+	Invocations []sarifInvoke  `json:"invocations,omitempty"`
+	Properties  map[string]any `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string        `json:"id"`
+	Name                 string        `json:"name"`
+	ShortDescription     sarifMessage  `json:"shortDescription"`
+	DefaultConfiguration sarifRuleConf `json:"defaultConfiguration"`
+}
+
+type sarifRuleConf struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	RuleIndex  int             `json:"ruleIndex"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations,omitempty"`
+	Properties map[string]any  `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+type sarifInvoke struct {
+	ExecutionSuccessful bool `json:"executionSuccessful"`
+}
+
+// SARIFLevel maps a severity onto a SARIF result level.
+func SARIFLevel(s Severity) string {
+	switch s {
+	case SeverityCritical, SeverityHigh:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// RuleID returns the SARIF rule id used for findings of a category.
+func RuleID(c Category) string {
+	return "reviewbot/" + string(c)
+}
+
+// WriteSARIF encodes the report as a SARIF 2.1.0 log with one rule per
+// category. Finding locations are anchored to the hunks of diff so that
+// code-scanning dashboards only annotate changed lines.
+func (r *Report) WriteSARIF(w io.Writer, diff string) error {
+	return WriteSARIFRuns(w, SARIFRun{Report: r, Diff: diff})
+}
+
+// SARIFRun pairs a report with the diff it was produced from.
+type SARIFRun struct {
+	Report *Report
+	Diff   string
+}
+
+// WriteSARIFRuns encodes several reports, e.g. one per reviewed commit, as a
+// single SARIF log with one run each.
+func WriteSARIFRuns(w io.Writer, runs ...SARIFRun) error {
+	log := sarifLog{
+		Schema:  SARIFSchema,
+		Version: SARIFVersion,
+		Runs:    make([]sarifRun, 0, len(runs)),
+	}
+	for _, run := range runs {
+		log.Runs = append(log.Runs, run.Report.sarifRun(ParseHunks(run.Diff)))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(log)
+}
+
+func (r *Report) sarifRun(hunks Hunks) sarifRun {
+	rules := make([]sarifRule, 0, len(Categories))
+	ruleIndex := make(map[Category]int, len(Categories))
+	for i, c := range Categories {
+		ruleIndex[c] = i
+		rules = append(rules, sarifRule{
+			ID:                   RuleID(c),
+			Name:                 titleCase(string(c)),
+			ShortDescription:     sarifMessage{Text: titleCase(string(c)) + " issue reported by AI code review"},
+			DefaultConfiguration: sarifRuleConf{Level: "warning"},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(r.Findings))
+	for _, f := range r.Findings {
+		idx, ok := ruleIndex[f.Category]
+		if !ok {
+			idx = ruleIndex[CategoryOther]
+		}
+		result := sarifResult{
+			RuleID:     rules[idx].ID,
+			RuleIndex:  idx,
+			Level:      SARIFLevel(f.Severity),
+			Message:    sarifMessage{Text: resultMessage(f)},
+			Properties: map[string]any{"severity": string(f.Severity)},
+		}
+		if loc, ok := sarifLocationFor(f, hunks); ok {
+			result.Locations = []sarifLocation{loc}
+		}
+		results = append(results, result)
+	}
+
+	properties := map[string]any{
+		"provider":   r.Provider,
+		"model":      r.Model,
+		"tokenUsage": r.TokenUsage,
+	}
+	if r.Summary != "" {
+		properties["summary"] = r.Summary
+	}
+	if r.Commit != "" {
+		properties["commit"] = r.Commit
+	}
+
+	return sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           sarifToolName,
+			Version:        version.Get().GitVersion,
+			InformationURI: sarifToolURI,
+			Rules:          rules,
+		}},
+		Results:     results,
+		Invocations: []sarifInvoke{{ExecutionSuccessful: true}},
+		Properties:  properties,
+	}
+}
+
+// sarifLocationFor builds the physical location of a finding. Findings whose
+// file is not part of the diff keep the reported lines, if any.
+func sarifLocationFor(f Finding, hunks Hunks) (sarifLocation, bool) {
+	if f.File == "" {
+		return sarifLocation{}, false
+	}
+
+	loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: f.File, URIBaseID: srcRoot},
+	}}
+	r, ok := hunks.Locate(f)
+	if !ok {
+		r = LineRange{Start: f.StartLine, End: f.EndLine}
+	}
+	if r.Start > 0 {
+		loc.PhysicalLocation.Region = &sarifRegion{StartLine: r.Start, EndLine: max(r.End, r.Start)}
+	}
+	return loc, true
+}
+
+func resultMessage(f Finding) string {
+	var sb strings.Builder
+	sb.WriteString(f.Title)
+	if f.Message != "" && f.Message != f.Title {
+		sb.WriteString("\n\n")
+		sb.WriteString(f.Message)
+	}
+	if f.Suggestion != "" {
+		sb.WriteString("\n\nSuggestion: ")
+		sb.WriteString(f.Suggestion)
+	}
+	return sb.String()
+}
+
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,156 @@
+package review
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -10,3 +10,5 @@ func main() {
+ 	a := 1
++	b := 2
++	c := 3
+ 	fmt.Println(a)
+ 	return
+@@ -40,2 +42,3 @@ func helper() {
+ 	return
++	// unreachable
+ }
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package old
+-
+`
+
+func compileSARIFSchema(t *testing.T) *jsonschema.Schema {
+	t.Helper()
+
+	raw, err := os.ReadFile("testdata/sarif-schema-2.1.0.json")
+	require.NoError(t, err)
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	require.NoError(t, err)
+
+	compiler := jsonschema.NewCompiler()
+	require.NoError(t, compiler.AddResource(SARIFSchema, doc))
+	schema, err := compiler.Compile(SARIFSchema)
+	require.NoError(t, err)
+	return schema
+}
+
+func TestParseHunks(t *testing.T) {
+	hunks := ParseHunks(sampleDiff)
+	assert.Equal(t, Hunks{"main.go": {{Start: 10, End: 14}, {Start: 42, End: 44}}}, hunks)
+}
+
+func TestHunks_Locate(t *testing.T) {
+	hunks := ParseHunks(sampleDiff)
+
+	tests := []struct {
+		name   string
+		f      Finding
+		want   LineRange
+		wantOK bool
+	}{
+		{name: "inside hunk", f: Finding{File: "main.go", StartLine: 11, EndLine: 12}, want: LineRange{11, 12}, wantOK: true},
+		{name: "clipped to hunk", f: Finding{File: "main.go", StartLine: 8, EndLine: 11}, want: LineRange{10, 11}, wantOK: true},
+		{name: "snapped to nearest hunk", f: Finding{File: "main.go", StartLine: 38, EndLine: 38}, want: LineRange{42, 44}, wantOK: true},
+		{name: "no line numbers", f: Finding{File: "main.go"}, want: LineRange{10, 14}, wantOK: true},
+		{name: "file outside diff", f: Finding{File: "other.go", StartLine: 3, EndLine: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := hunks.Locate(tt.f)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReport_WriteSARIF(t *testing.T) {
+	report := NewReport("openai", "gpt-4o", &Result{
+		Summary: "Two issues",
+		Findings: []Finding{
+			{File: "main.go", StartLine: 11, EndLine: 12, Severity: SeverityCritical, Category: CategorySecurity, Title: "Injection", Message: "Unsanitized input", Suggestion: "Escape it"},
+			{File: "main.go", StartLine: 99, EndLine: 99, Severity: SeverityLow, Category: CategoryStyle, Title: "Naming", Message: "Naming"},
+			{Severity: SeverityMedium, Category: CategoryOther, Title: "General", Message: "No location"},
+		},
+	}, ai.TokenUsage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3})
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteSARIF(&buf, sampleDiff))
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.NoError(t, compileSARIFSchema(t).Validate(doc))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Len(t, run.Tool.Driver.Rules, len(Categories))
+	require.Len(t, run.Results, 3)
+
+	first := run.Results[0]
+	assert.Equal(t, "reviewbot/security", first.RuleID)
+	assert.Equal(t, run.Tool.Driver.Rules[first.RuleIndex].ID, first.RuleID)
+	assert.Equal(t, "error", first.Level)
+	assert.Equal(t, "Injection\n\nUnsanitized input\n\nSuggestion: Escape it", first.Message.Text)
+	require.Len(t, first.Locations, 1)
+	assert.Equal(t, "main.go", first.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, &sarifRegion{StartLine: 11, EndLine: 12}, first.Locations[0].PhysicalLocation.Region)
+
+	second := run.Results[1]
+	assert.Equal(t, "note", second.Level)
+	assert.Equal(t, &sarifRegion{StartLine: 42, EndLine: 44}, second.Locations[0].PhysicalLocation.Region)
+
+	third := run.Results[2]
+	assert.Equal(t, "warning", third.Level)
+	assert.Empty(t, third.Locations)
+}
+
+func TestReport_WriteSARIFEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewReport("ollama", "llama3", &Result{}, ai.TokenUsage{}).WriteSARIF(&buf, ""))
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.NoError(t, compileSARIFSchema(t).Validate(doc))
+	assert.Contains(t, buf.String(), `"results": []`)
+}
+
+func TestWriteSARIFRuns(t *testing.T) {
+	first := NewReport("openai", "gpt-4o", &Result{
+		Findings: []Finding{{File: "main.go", StartLine: 11, Severity: SeverityHigh, Category: CategoryBug, Title: "Bug", Message: "Off by one"}},
+	}, ai.TokenUsage{})
+	first.Commit = "1111111"
+	second := NewReport("openai", "gpt-4o", &Result{}, ai.TokenUsage{})
+	second.Commit = "2222222"
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSARIFRuns(&buf, SARIFRun{Report: first, Diff: sampleDiff}, SARIFRun{Report: second}))
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.NoError(t, compileSARIFSchema(t).Validate(doc))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Len(t, log.Runs, 2)
+	assert.Equal(t, "1111111", log.Runs[0].Properties["commit"])
+	assert.Len(t, log.Runs[0].Results, 1)
+	assert.Equal(t, "2222222", log.Runs[1].Properties["commit"])
+	assert.Empty(t, log.Runs[1].Results)
+}
@@ -0,0 +1,63 @@
+package review
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/prompt"
+)
+
+const structuredSystemPrompt = "You are a meticulous code reviewer that only answers with JSON."
+
+// Structured asks the model for findings on diff and returns the parsed
+// result. When the answer cannot be parsed, the model is asked once to
+// repair its own output before giving up.
+func Structured(ctx context.Context, client ai.TextGenerator, diff, lang string) (*Result, ai.TokenUsage, error) {
+	instruction, err := prompt.GetPromptTmpl(prompt.CodeReviewJSONTmpl, map[string]any{
+		prompt.FileDiff:   diff,
+		prompt.OutputLang: lang,
+	})
+	if err != nil {
+		return nil, ai.TokenUsage{}, err
+	}
+
+	resp, err := client.Chat(ctx, jsonRequest(instruction))
+	if err != nil {
+		return nil, ai.TokenUsage{}, err
+	}
+	usage := resp.TokenUsage
+
+	result, parseErr := Parse(resp.Text)
+	if parseErr == nil {
+		return result, usage, nil
+	}
+
+	repair, err := prompt.GetRawPromptTmpl(prompt.CodeReviewJSONRepairTmpl, map[string]any{
+		prompt.OutputMessage: resp.Text,
+	})
+	if err != nil {
+		return nil, usage, err
+	}
+	resp, err = client.Chat(ctx, jsonRequest(repair))
+	if err != nil {
+		return nil, usage, err
+	}
+	usage = usage.Add(resp.TokenUsage)
+
+	result, err = Parse(resp.Text)
+	if err != nil {
+		return nil, usage, fmt.Errorf("model did not return valid review JSON (first attempt: %v): %w", parseErr, err)
+	}
+	return result, usage, nil
+}
+
+// jsonRequest builds a deterministic single-turn request for JSON answers.
+func jsonRequest(text string) *ai.Request {
+	temperature := float32(0)
+	return &ai.Request{
+		System:      structuredSystemPrompt,
+		Messages:    []ai.Message{{Role: ai.RoleUser, Content: text}},
+		Temperature: &temperature,
+	}
+}
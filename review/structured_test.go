@@ -0,0 +1,72 @@
+package review
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/loveRyujin/ReviewBot/ai"
+	"github.com/loveRyujin/ReviewBot/pkg/jsonx"
+	"github.com/loveRyujin/ReviewBot/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructured(t *testing.T) {
+	client := new(mocks.MockTextGenerator)
+	client.On("Chat", mock.Anything, mock.MatchedBy(func(req *ai.Request) bool {
+		return req.System == structuredSystemPrompt && req.Temperature != nil && *req.Temperature == 0
+	})).Return(&ai.Response{
+		Text:       `{"summary":"one issue","findings":[{"file":"a.go","start_line":1,"severity":"low","category":"style","title":"t","message":"m"}]}`,
+		TokenUsage: ai.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}, nil).Once()
+
+	result, usage, err := Structured(context.Background(), client, "diff", "English")
+	require.NoError(t, err)
+	assert.Equal(t, "one issue", result.Summary)
+	require.Len(t, result.Findings, 1)
+	assert.Equal(t, 15, usage.TotalTokens)
+	client.AssertExpectations(t)
+}
+
+func TestStructured_RepairsInvalidJSON(t *testing.T) {
+	client := new(mocks.MockTextGenerator)
+	client.On("Chat", mock.Anything, mock.Anything).Return(&ai.Response{
+		Text:       "Looks fine overall, no JSON here.",
+		TokenUsage: ai.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}, nil).Once()
+	client.On("Chat", mock.Anything, mock.Anything).Return(&ai.Response{
+		Text:       `{"summary":"Looks fine overall","findings":[]}`,
+		TokenUsage: ai.TokenUsage{PromptTokens: 20, CompletionTokens: 3, TotalTokens: 23},
+	}, nil).Once()
+
+	result, usage, err := Structured(context.Background(), client, "diff", "English")
+	require.NoError(t, err)
+	assert.Equal(t, "Looks fine overall", result.Summary)
+	assert.Equal(t, 38, usage.TotalTokens)
+	client.AssertExpectations(t)
+}
+
+func TestStructured_RepairQuotesRawOutput(t *testing.T) {
+	broken := `{"summary": "a<b && c>d", "findings": [`
+	client := new(mocks.MockTextGenerator)
+	client.On("Chat", mock.Anything, mock.Anything).Return(&ai.Response{Text: broken}, nil).Once()
+	client.On("Chat", mock.Anything, mock.MatchedBy(func(req *ai.Request) bool {
+		return strings.Contains(req.Messages[0].Content, "TEXT:\n"+broken)
+	})).Return(&ai.Response{Text: `{"summary":"a<b && c>d","findings":[]}`}, nil).Once()
+
+	result, _, err := Structured(context.Background(), client, "diff", "English")
+	require.NoError(t, err)
+	assert.Equal(t, "a<b && c>d", result.Summary)
+	client.AssertExpectations(t)
+}
+
+func TestStructured_GivesUpAfterRepair(t *testing.T) {
+	client := new(mocks.MockTextGenerator)
+	client.On("Chat", mock.Anything, mock.Anything).Return(&ai.Response{Text: "still prose"}, nil).Twice()
+
+	_, _, err := Structured(context.Background(), client, "diff", "English")
+	assert.ErrorIs(t, err, jsonx.ErrNoJSON)
+	client.AssertExpectations(t)
+}
@@ -0,0 +1,66 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+)
+
+// External is the name of the source reading a diff produced elsewhere: the
+// first argument, the diff file, or piped stdin, in that order.
+const External = "external"
+
+func init() {
+	Register(External, externalSource{})
+}
+
+type externalSource struct{}
+
+func (externalSource) Diff(_ context.Context, in Input) (string, error) {
+	var diff string
+	var err error
+	switch {
+	case len(in.Args) != 0:
+		diff = in.Args[0]
+	case in.DiffFile != "":
+		diff, err = readFile(in.DiffFile, in.MaxSize)
+	case isPiped(in.Stdin):
+		diff, err = LimitRead(in.Stdin, in.MaxSize)
+	}
+	if err != nil {
+		return "", err
+	}
+	if diff == "" {
+		return "", errors.New("please provide the diff content to review")
+	}
+	return diff, nil
+}
+
+func readFile(name string, maxSize int) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return LimitRead(f, maxSize)
+}
+
+// isPiped reports whether r has input to read. Terminals are skipped so
+// the command does not block waiting for the user.
+func isPiped(r io.Reader) bool {
+	if r == nil {
+		return false
+	}
+	f, ok := r.(*os.File)
+	if !ok {
+		return true
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice == 0
+}
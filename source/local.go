@@ -0,0 +1,23 @@
+package source
+
+import (
+	"context"
+	"errors"
+)
+
+// Local is the name of the source reading the staged changes of the
+// repository in the working directory.
+const Local = "local"
+
+func init() {
+	Register(Local, localSource{})
+}
+
+type localSource struct{}
+
+func (localSource) Diff(_ context.Context, in Input) (string, error) {
+	if in.Git == nil {
+		return "", errors.New("source: local source needs a git command")
+	}
+	return in.Git.DiffFiles()
+}
@@ -0,0 +1,118 @@
+// Package source provides the diffs reviewed by the review command. Every
+// source is registered under the name selected with --mode.
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/loveRyujin/ReviewBot/git"
+)
+
+// ErrInputTooLarge is returned when a diff reaches the input size limit.
+var ErrInputTooLarge = git.ErrInputTooLarge
+
+// Input is what a source may read the diff from.
+type Input struct {
+	// Args are the positional arguments of the review command.
+	Args []string
+	// DiffFile is the path of a file holding the diff.
+	DiffFile string
+	// Stdin is read when it is piped, not a terminal.
+	Stdin io.Reader
+	// Git runs the git commands of the local repository.
+	Git *git.Command
+	// MaxSize is the input size limit in bytes; diffs of this size or
+	// larger are rejected. Zero disables the limit.
+	MaxSize int
+}
+
+// DiffSource produces the diff to review.
+type DiffSource interface {
+	Diff(ctx context.Context, in Input) (string, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]DiffSource)
+)
+
+// Register makes a diff source available under the given name. It is
+// intended to be called from an init function and panics if the name is
+// empty, the source is nil, or the name is already registered.
+func Register(name string, src DiffSource) {
+	name = normalizeName(name)
+	if name == "" {
+		panic("source: Register called with empty source name")
+	}
+	if src == nil {
+		panic("source: Register source is nil for " + name)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, dup := registry[name]; dup {
+		panic("source: Register called twice for " + name)
+	}
+	registry[name] = src
+}
+
+// Sources returns the sorted names of all registered sources.
+func Sources() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Read looks up the named source and reads its diff. The size limit is
+// enforced here for every source, so sources only need to stop reading
+// early with LimitRead.
+func Read(ctx context.Context, name string, in Input) (string, error) {
+	registryMu.RLock()
+	src, ok := registry[normalizeName(name)]
+	registryMu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("unsupported diff source %q (registered: %s)", name, strings.Join(Sources(), ", "))
+	}
+	diff, err := src.Diff(ctx, in)
+	if err != nil {
+		return "", err
+	}
+	if in.MaxSize > 0 && len(diff) >= in.MaxSize {
+		return "", ErrInputTooLarge
+	}
+	return diff, nil
+}
+
+// LimitRead reads r to the end, or returns ErrInputTooLarge as soon as
+// maxSize bytes have been read. Zero disables the limit.
+func LimitRead(r io.Reader, maxSize int) (string, error) {
+	if maxSize <= 0 {
+		b, err := io.ReadAll(r)
+		return string(b), err
+	}
+	b, err := io.ReadAll(io.LimitReader(r, int64(maxSize)))
+	if err != nil {
+		return "", err
+	}
+	if len(b) >= maxSize {
+		return "", ErrInputTooLarge
+	}
+	return string(b), nil
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,105 @@
+package source
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleDiff = "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n"
+
+type stubSource string
+
+func (s stubSource) Diff(context.Context, Input) (string, error) {
+	return string(s), nil
+}
+
+// withSource registers a stub source for the duration of a test.
+func withSource(t *testing.T, name, diff string) {
+	t.Helper()
+
+	Register(name, stubSource(diff))
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, normalizeName(name))
+		registryMu.Unlock()
+	})
+}
+
+func TestRead(t *testing.T) {
+	withSource(t, "stub", sampleDiff)
+
+	diff, err := Read(context.Background(), " STUB ", Input{})
+	require.NoError(t, err)
+	assert.Equal(t, sampleDiff, diff)
+	assert.Equal(t, []string{External, Local, "stub"}, Sources())
+
+	// the limit applies to every source
+	_, err = Read(context.Background(), "stub", Input{MaxSize: len(sampleDiff)})
+	assert.ErrorIs(t, err, ErrInputTooLarge)
+
+	_, err = Read(context.Background(), "missing", Input{})
+	assert.EqualError(t, err, `unsupported diff source "missing" (registered: external, local, stub)`)
+}
+
+func TestRegisterPanics(t *testing.T) {
+	assert.Panics(t, func() { Register("", stubSource("")) })
+	assert.Panics(t, func() { Register("nil", nil) })
+	assert.Panics(t, func() { Register(Local, stubSource("")) })
+}
+
+func TestExternalSource(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "changes.diff")
+	require.NoError(t, os.WriteFile(file, []byte(sampleDiff), 0o644))
+
+	tests := []struct {
+		name string
+		in   Input
+		want string
+		err  string
+	}{
+		{name: "args", in: Input{Args: []string{sampleDiff}, DiffFile: file}, want: sampleDiff},
+		{name: "file", in: Input{DiffFile: file, Stdin: strings.NewReader("ignored")}, want: sampleDiff},
+		{name: "stdin", in: Input{Stdin: strings.NewReader(sampleDiff)}, want: sampleDiff},
+		{name: "empty", in: Input{}, err: "please provide the diff content to review"},
+		{name: "missing file", in: Input{DiffFile: filepath.Join(t.TempDir(), "nope")}, err: "no such file"},
+		{name: "large args", in: Input{Args: []string{sampleDiff}, MaxSize: 10}, err: ErrInputTooLarge.Error()},
+		{name: "large file", in: Input{DiffFile: file, MaxSize: 10}, err: ErrInputTooLarge.Error()},
+		{name: "large stdin", in: Input{Stdin: strings.NewReader(sampleDiff), MaxSize: 10}, err: ErrInputTooLarge.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := Read(ctx, External, tt.in)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, diff)
+		})
+	}
+}
+
+func TestLimitRead(t *testing.T) {
+	got, err := LimitRead(strings.NewReader("abc"), 4)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", got)
+
+	_, err = LimitRead(strings.NewReader("abcd"), 4)
+	assert.ErrorIs(t, err, ErrInputTooLarge)
+
+	got, err = LimitRead(strings.NewReader("abcd"), 0)
+	require.NoError(t, err)
+	assert.Equal(t, "abcd", got)
+}
+
+func TestLocalSourceNeedsGit(t *testing.T) {
+	_, err := Read(context.Background(), Local, Input{})
+	assert.ErrorContains(t, err, "needs a git command")
+}
@@ -12,6 +12,21 @@ type MockTextGenerator struct {
 	mock.Mock
 }
 
+// Chat mocks the Chat method.
+func (m *MockTextGenerator) Chat(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ai.Response), args.Error(1)
+}
+
+// StreamChat mocks the StreamChat method.
+func (m *MockTextGenerator) StreamChat(ctx context.Context, req *ai.Request, handler ai.ChunkHandler) (ai.TokenUsage, error) {
+	args := m.Called(ctx, req, handler)
+	return args.Get(0).(ai.TokenUsage), args.Error(1)
+}
+
 // ChatCompletion mocks the ChatCompletion method.
 func (m *MockTextGenerator) ChatCompletion(ctx context.Context, text string) (*ai.Response, error) {
 	args := m.Called(ctx, text)
@@ -22,7 +37,7 @@ func (m *MockTextGenerator) ChatCompletion(ctx context.Context, text string) (*a
 }
 
 // StreamChatCompletion mocks the StreamChatCompletion method.
-func (m *MockTextGenerator) StreamChatCompletion(ctx context.Context, text string, handler ai.ChunkHandler) error {
+func (m *MockTextGenerator) StreamChatCompletion(ctx context.Context, text string, handler ai.ChunkHandler) (ai.TokenUsage, error) {
 	args := m.Called(ctx, text, handler)
-	return args.Error(0)
+	return args.Get(0).(ai.TokenUsage), args.Error(1)
 }